@@ -0,0 +1,66 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/nexa"
+	"rag-terminal/internal/ollama"
+	"rag-terminal/internal/openai"
+)
+
+// BaseURL returns the address the client for cfg connects to
+func BaseURL(cfg config.BackendConfig) string {
+	if cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+
+	switch cfg.Provider {
+	case config.ProviderOllama:
+		return ollama.DefaultBaseURL
+	case config.ProviderOpenAI:
+		return openai.DefaultBaseURL
+	default:
+		return nexa.DefaultBaseURL
+	}
+}
+
+// New creates the client for the configured provider
+func New(cfg config.BackendConfig) (llm.Backend, error) {
+	opts := transportOptions(cfg)
+
+	switch cfg.Provider {
+	case config.ProviderNexa, "":
+		return nexa.NewClient(cfg.BaseURL, opts), nil
+	case config.ProviderOllama:
+		return ollama.NewClient(cfg.BaseURL, opts), nil
+	case config.ProviderOpenAI:
+		return openai.NewClient(cfg.BaseURL, cfg.APIKey, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown backend provider %q", cfg.Provider)
+	}
+}
+
+// transportOptions converts the configured timeouts and retry policies.
+// Zero values fall back to the transport defaults.
+func transportOptions(cfg config.BackendConfig) llm.TransportOptions {
+	retryPolicy := func(policy config.RetryPolicyConfig) llm.RetryPolicy {
+		return llm.RetryPolicy{
+			MaxAttempts:    policy.MaxAttempts,
+			InitialBackoff: time.Duration(policy.InitialBackoffMs) * time.Millisecond,
+			MaxBackoff:     time.Duration(policy.MaxBackoffMs) * time.Millisecond,
+		}
+	}
+
+	return llm.TransportOptions{
+		ConnectTimeout: time.Duration(cfg.ConnectTimeoutSeconds) * time.Second,
+		ReadTimeout:    time.Duration(cfg.ReadTimeoutSeconds) * time.Second,
+		Retry: map[llm.Operation]llm.RetryPolicy{
+			llm.OpChat:       retryPolicy(cfg.Retry.Chat),
+			llm.OpEmbeddings: retryPolicy(cfg.Retry.Embeddings),
+			llm.OpRerank:     retryPolicy(cfg.Retry.Rerank),
+		},
+	}
+}
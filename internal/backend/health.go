@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/llm"
+)
+
+// healthCheckTimeout bounds the whole startup check, so an unresponsive server doesn't hang the UI
+const healthCheckTimeout = 15 * time.Second
+
+// Health check steps, in the order they run
+const (
+	CheckServer         = "Server reachable"
+	CheckModelList      = "Model list"
+	CheckTextModel      = "Text generation model"
+	CheckEmbeddingModel = "Embedding model"
+)
+
+// Check is the outcome of one health check step
+type Check struct {
+	Name string
+	Err  error // nil when the step passed
+}
+
+// Diagnostics describes whether the configured backend can serve the application
+type Diagnostics struct {
+	Provider string
+	BaseURL  string
+	Checks   []Check
+	Models   []llm.Model // Installed models, empty when they couldn't be listed
+}
+
+// Healthy reports whether every check passed
+func (d *Diagnostics) Healthy() bool {
+	for _, check := range d.Checks {
+		if check.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHealth probes the backend server and verifies that at least one text generation
+// and one embedding model are installed. Later steps are skipped once one fails.
+func CheckHealth(ctx context.Context, client llm.Backend, cfg config.BackendConfig) *Diagnostics {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	diag := &Diagnostics{
+		Provider: cfg.Provider,
+		BaseURL:  BaseURL(cfg),
+	}
+
+	if err := client.Ping(ctx); err != nil {
+		diag.Checks = append(diag.Checks, Check{Name: CheckServer, Err: err})
+		return diag
+	}
+	diag.Checks = append(diag.Checks, Check{Name: CheckServer})
+
+	models, err := client.GetModels()
+	if err != nil {
+		diag.Checks = append(diag.Checks, Check{Name: CheckModelList, Err: err})
+		return diag
+	}
+	diag.Checks = append(diag.Checks, Check{Name: CheckModelList})
+	diag.Models = models
+
+	counts := make(map[string]int)
+	for _, m := range models {
+		counts[m.Type]++
+	}
+
+	for _, required := range []struct{ name, modelType string }{
+		{CheckTextModel, llm.ModelTypeTextGeneration},
+		{CheckEmbeddingModel, llm.ModelTypeEmbeddings},
+	} {
+		check := Check{Name: required.name}
+		if counts[required.modelType] == 0 {
+			check.Err = fmt.Errorf("no %s model installed", required.modelType)
+		}
+		diag.Checks = append(diag.Checks, check)
+	}
+
+	return diag
+}
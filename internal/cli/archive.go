@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/export"
+)
+
+// runArchive writes a chat with its embeddings to a tar.gz that can be imported elsewhere
+func runArchive(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("archive", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	chatName := fs.String("chat", "", "chat name or ID (required)")
+	embedModel := fs.String("embed", "", "embedding model the chat was built with (defaults to the model recorded on the chat)")
+	outPath := fs.String("out", "", "archive file to write (required)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rag-terminal archive --chat <name> [--embed <model>] --out <file.tar.gz>")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if *chatName == "" || *outPath == "" {
+		fmt.Fprintln(stderr, "Error: --chat and --out are required")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	env, err := openEnvironment()
+	if err != nil {
+		return report(stderr, err)
+	}
+	defer env.close()
+
+	ctx := context.Background()
+	chat, err := env.findChat(ctx, *chatName)
+	if err != nil {
+		return report(stderr, err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	// The model recorded on the chat is authoritative; --embed is only needed for older chats
+	dimensions := cfg.EmbeddingDimensions
+	if chat.EmbedModel != "" {
+		if *embedModel != "" && *embedModel != chat.EmbedModel {
+			return report(stderr, withCode(ExitModelUnavailable, "chat %q was embedded with %s, not %s", chat.Name, chat.EmbedModel, *embedModel))
+		}
+		*embedModel = chat.EmbedModel
+		dimensions = chat.EmbeddingDimensions
+	} else if *embedModel == "" {
+		fmt.Fprintln(stderr, "Error: chat has no recorded embedding model, --embed is required")
+		return ExitUsage
+	}
+
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return report(stderr, fmt.Errorf("failed to create archive: %w", err))
+	}
+
+	manifest, err := export.WriteArchive(ctx, env.store, chat.ID, *embedModel, dimensions, f)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write archive: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(*outPath)
+		return report(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Archived %q to %s: %d documents, %d chunks, %d context messages (%s, %d dimensions)\n",
+		chat.Name, *outPath, manifest.Documents, manifest.Chunks, manifest.ContextMessages,
+		manifest.EmbeddingModel, manifest.EmbeddingDimensions)
+	return ExitOK
+}
+
+// runImport creates a chat from an archive, re-embedding it on request when the embedding model differs
+func runImport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	embedModel := fs.String("embed", "", "embedding model used for this chat store (required)")
+	reembed := fs.Bool("reembed", false, "re-embed chunks with --embed at the configured dimensions if the archive was built with another model or size")
+	name := fs.String("name", "", "name for the imported chat (default: name in the archive)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rag-terminal import --embed <model> [--reembed] [--name <chat name>] <file.tar.gz>")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if *embedModel == "" || fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "Error: --embed and one archive file are required")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return report(stderr, fmt.Errorf("failed to open archive: %w", err))
+	}
+	defer f.Close()
+
+	env, err := openEnvironment()
+	if err != nil {
+		return report(stderr, err)
+	}
+	defer env.close()
+
+	if *reembed {
+		if err := env.requireModels(map[string]string{*embedModel: "embeddings"}); err != nil {
+			return report(stderr, err)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	chat, manifest, err := export.ImportArchive(ctx, env.store, f, export.ImportOptions{
+		EmbedModel: *embedModel,
+		Dimensions: cfg.EmbeddingDimensions, // Only used when re-embedding
+		Reembed:    *reembed,
+		Backend:    env.backend,
+		Name:       *name,
+	})
+	if err != nil {
+		if errors.Is(err, export.ErrEmbeddingMismatch) {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			fmt.Fprintln(stderr, "Run again with --reembed to embed the archive with your model, or use the model named in the archive.")
+			return ExitModelUnavailable
+		}
+		return report(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Imported %q as %s: %d documents, %d chunks, %d context messages\n",
+		chat.Name, chat.ID, manifest.Documents, manifest.Chunks, manifest.ContextMessages)
+	return ExitOK
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"rag-terminal/internal/models"
+	"rag-terminal/internal/rag"
+	"rag-terminal/internal/vector"
+)
+
+// runAsk answers a single question in an existing chat, streaming tokens to stdout
+func runAsk(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ask", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	chatName := fs.String("chat", "", "chat name or ID (required)")
+	llmModel := fs.String("llm", "", "text generation model (required)")
+	embedModel := fs.String("embed", "", "embedding model (required)")
+	rerankModel := fs.String("rerank", "", "optional reranking model")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rag-terminal ask --chat <name> --llm <model> --embed <model> [--rerank <model>] \"question\"")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "The question is read from stdin when no argument is given.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if *chatName == "" || *llmModel == "" || *embedModel == "" {
+		fmt.Fprintln(stderr, "Error: --chat, --llm and --embed are required")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	question := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if question == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: failed to read question from stdin: %v\n", err)
+			return ExitUsage
+		}
+		question = strings.TrimSpace(string(data))
+	}
+	if question == "" {
+		fmt.Fprintln(stderr, "Error: no question given")
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env, err := openEnvironment()
+	if err != nil {
+		return report(stderr, err)
+	}
+	defer env.close()
+
+	if err := ask(ctx, env, *chatName, *llmModel, *embedModel, *rerankModel, question, stdout, stderr); err != nil {
+		return report(stderr, err)
+	}
+
+	return ExitOK
+}
+
+// ask runs the question through the regular pipeline, so the exchange is stored in the chat like in the UI.
+// The answer and its sources go to stdout, warnings to stderr.
+func ask(ctx context.Context, env *environment, chatName, llmModel, embedModel, rerankModel, question string, stdout, stderr io.Writer) error {
+	chat, err := env.findChat(ctx, chatName)
+	if err != nil {
+		return err
+	}
+
+	if err := env.requireModels(map[string]string{
+		llmModel:    "text-generation",
+		embedModel:  "embeddings",
+		rerankModel: "reranking",
+	}); err != nil {
+		return err
+	}
+
+	if err := env.useChatModels(ctx, chat, llmModel, embedModel); err != nil {
+		return err
+	}
+
+	session, err := env.store.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	pipeline := rag.NewPipeline(env.backend, env.store, rerankModel)
+	events, errChan := pipeline.ProcessUserMessage(ctx, chat, llmModel, embedModel, question)
+
+	var answer strings.Builder
+	var sources []vector.Source
+	wroteNewline := true
+	for event := range events {
+		switch event.Type {
+		case models.EventToken:
+			if _, err := io.WriteString(stdout, event.Token); err != nil {
+				return fmt.Errorf("failed to write answer: %w", err)
+			}
+			answer.WriteString(event.Token)
+			if event.Token != "" {
+				wroteNewline = strings.HasSuffix(event.Token, "\n")
+			}
+		case models.EventSources:
+			sources = event.Sources
+		case models.EventWarning:
+			fmt.Fprintf(stderr, "Warning: %s\n", event.Message)
+		}
+	}
+	if !wroteNewline {
+		fmt.Fprintln(stdout)
+	}
+
+	// The pipeline reports its failure before closing the events
+	if err := <-errChan; err != nil {
+		return fmt.Errorf("failed to answer: %w", err)
+	}
+
+	if cited := vector.CitedSources(answer.String(), sources); len(cited) > 0 {
+		fmt.Fprintln(stdout, "\nSources:")
+		for _, source := range cited {
+			fmt.Fprintf(stdout, "  [%s] %s\n", source.ID, source.Location())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rag-terminal/internal/backend"
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/document"
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/vector"
+)
+
+// Exit codes returned by subcommands so scripts can tell failures apart
+const (
+	ExitOK               = 0
+	ExitError            = 1 // Generation or other runtime error
+	ExitUsage            = 2 // Invalid flags or arguments
+	ExitNoSuchChat       = 3 // --chat matched no chat (or more than one)
+	ExitModelUnavailable = 4 // The backend is unreachable or a requested model isn't installed
+)
+
+// command is a non-interactive subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+func commands() []command {
+	return []command{
+		{name: "ask", summary: "Ask a question in an existing chat and stream the answer", run: runAsk},
+		{name: "ingest", summary: "Load files and directories into an existing chat", run: runIngest},
+		{name: "export", summary: "Write a chat as Markdown, JSON or HTML", run: runExport},
+		{name: "archive", summary: "Write a chat with its embeddings to a portable tar.gz", run: runArchive},
+		{name: "import", summary: "Create a chat from an archive", run: runImport},
+		{name: "reembed", summary: "Embed a chat again with another embedding model", run: runReembed},
+		{name: "serve", summary: "Serve chats over an OpenAI-compatible HTTP API", run: runServe},
+	}
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return ExitOK
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return ExitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: rag-terminal [command] [flags]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Without a command the interactive terminal UI is started.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Run 'rag-terminal <command> -h' for command flags.")
+}
+
+// exitError carries the exit code a failure should map to
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+func withCode(code int, format string, args ...any) error {
+	return &exitError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// report prints err to stderr and returns its exit code
+func report(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "Error: %v\n", err)
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitError
+}
+
+// environment holds the shared services a subcommand needs
+type environment struct {
+	store   *vector.BadgerStore
+	backend llm.Backend
+}
+
+// openEnvironment opens the chat store the interactive UI uses and creates the configured backend client
+func openEnvironment() (*environment, error) {
+	if err := logging.InitLogger(); err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := backend.New(cfg.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := vector.NewBadgerStore(filepath.Join(homeDir, ".rag-terminal", "db"), document.KeywordAnalyzer())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vector store: %w", err)
+	}
+
+	return &environment{
+		store:   store,
+		backend: client,
+	}, nil
+}
+
+func (env *environment) close() {
+	if err := env.store.Close(); err != nil {
+		logging.Error("Failed to close vector store: %v", err)
+	}
+	logging.Close()
+}
+
+// findChat resolves a chat by ID or exact name
+func (env *environment) findChat(ctx context.Context, nameOrID string) (*vector.Chat, error) {
+	chats, err := env.store.ListChats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	var matches []vector.Chat
+	for _, chat := range chats {
+		if chat.ID == nameOrID {
+			return &chat, nil
+		}
+		if chat.Name == nameOrID {
+			matches = append(matches, chat)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, withCode(ExitNoSuchChat, "no chat named %q", nameOrID)
+	case 1:
+		return &matches[0], nil
+	default:
+		ids := make([]string, len(matches))
+		for i, chat := range matches {
+			ids[i] = chat.ID
+		}
+		return nil, withCode(ExitNoSuchChat, "chat name %q is ambiguous, use one of the IDs: %s", nameOrID, strings.Join(ids, ", "))
+	}
+}
+
+// useChatModels records the models on the chat, saving it if that changed. Chats whose vectors come
+// from another embedding model are refused, since their search would compare incompatible vectors.
+func (env *environment) useChatModels(ctx context.Context, chat *vector.Chat, llmModel, embedModel string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	if chat.RecordModels(llmModel, embedModel, cfg.EmbeddingDimensions) {
+		if err := env.store.UpdateChat(ctx, chat); err != nil {
+			logging.Error("Failed to record models of chat %s: %v", chat.ID, err)
+		}
+	}
+
+	if err := chat.CheckEmbedding(embedModel, cfg.EmbeddingDimensions); err != nil {
+		return withCode(ExitModelUnavailable, "%v; use --embed %s or move the chat with 'rag-terminal reembed'", err, chat.EmbedModel)
+	}
+	return nil
+}
+
+// requireModels checks that every named model is installed with the expected type.
+// Keys are model names, values the type reported by llm.Backend.GetModels.
+func (env *environment) requireModels(required map[string]string) error {
+	models, err := env.backend.GetModels()
+	if err != nil {
+		return withCode(ExitModelUnavailable, "failed to list models: %v", err)
+	}
+
+	installed := make(map[string]string, len(models))
+	for _, m := range models {
+		installed[m.Name] = m.Type
+	}
+
+	for name, modelType := range required {
+		if name == "" {
+			continue
+		}
+		actual, ok := installed[name]
+		if !ok {
+			return withCode(ExitModelUnavailable, "model %q is not available", name)
+		}
+		if actual != modelType {
+			return withCode(ExitModelUnavailable, "model %q is a %s model, expected %s", name, actual, modelType)
+		}
+	}
+
+	return nil
+}
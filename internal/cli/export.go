@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"rag-terminal/internal/export"
+)
+
+// runExport writes a chat as a Markdown transcript, a JSON bundle or an HTML page
+func runExport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	chatName := fs.String("chat", "", "chat name or ID (required)")
+	formatName := fs.String("format", "", "markdown, json or html (default: from the --out extension, else markdown)")
+	outPath := fs.String("out", "", "file to write; - or empty writes to stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rag-terminal export --chat <name> [--format markdown|json|html] [--out <file>]")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if *chatName == "" {
+		fmt.Fprintln(stderr, "Error: --chat is required")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	format := export.FormatMarkdown
+	switch {
+	case *formatName != "":
+		f, err := export.ParseFormat(*formatName)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ExitUsage
+		}
+		format = f
+	case *outPath != "" && *outPath != "-":
+		if f, err := export.ParseFormat(filepath.Ext(*outPath)); err == nil {
+			format = f
+		}
+	}
+
+	env, err := openEnvironment()
+	if err != nil {
+		return report(stderr, err)
+	}
+	defer env.close()
+
+	ctx := context.Background()
+	chat, err := env.findChat(ctx, *chatName)
+	if err != nil {
+		return report(stderr, err)
+	}
+
+	bundle, err := export.Load(ctx, env.store, chat.ID)
+	if err != nil {
+		return report(stderr, err)
+	}
+
+	if *outPath == "" || *outPath == "-" {
+		if err := export.Write(stdout, bundle, format); err != nil {
+			return report(stderr, err)
+		}
+		return ExitOK
+	}
+
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return report(stderr, fmt.Errorf("failed to create output file: %w", err))
+	}
+	if err := export.Write(f, bundle, format); err != nil {
+		f.Close()
+		return report(stderr, err)
+	}
+	if err := f.Close(); err != nil {
+		return report(stderr, fmt.Errorf("failed to write output file: %w", err))
+	}
+
+	fmt.Fprintf(stderr, "Exported %q to %s\n", chat.Name, *outPath)
+	return ExitOK
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/document"
+)
+
+// ingestSummary counts file outcomes of an ingest run
+type ingestSummary struct {
+	Type        string  `json:"type"` // Always "summary", distinguishes the line from file reports in JSON output
+	Chat        string  `json:"chat"`
+	Loaded      int     `json:"loaded"`
+	Duplicates  int     `json:"duplicates"`
+	Unsupported int     `json:"unsupported"`
+	Failed      int     `json:"failed"`
+	Chunks      int     `json:"chunks"`
+	Seconds     float64 `json:"seconds"`
+	Error       string  `json:"error,omitempty"` // Set when loading was aborted
+}
+
+// fileLine is a file report as written in JSON output
+type fileLine struct {
+	Type string `json:"type"` // Always "file"
+	document.FileReport
+}
+
+// runIngest loads files and directories into an existing chat, printing one line per file and a summary
+func runIngest(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	chatName := fs.String("chat", "", "chat name or ID (required)")
+	embedModel := fs.String("embed", "", "embedding model (required)")
+	jsonOutput := fs.Bool("json", false, "write file reports and the summary as JSON lines")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rag-terminal ingest --chat <name> --embed <model> [--json] <paths...>")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if *chatName == "" || *embedModel == "" {
+		fmt.Fprintln(stderr, "Error: --chat and --embed are required")
+		fs.Usage()
+		return ExitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(stderr, "Error: no paths given")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env, err := openEnvironment()
+	if err != nil {
+		return report(stderr, err)
+	}
+	defer env.close()
+
+	summary, err := ingest(ctx, env, *chatName, *embedModel, fs.Args(), *jsonOutput, stdout)
+	if err != nil {
+		if summary == nil {
+			return report(stderr, err)
+		}
+		summary.Error = err.Error()
+	}
+
+	writeSummary(stdout, summary, *jsonOutput)
+
+	if err != nil {
+		return report(stderr, err)
+	}
+	if summary.Failed > 0 {
+		return ExitError
+	}
+	return ExitOK
+}
+
+// ingest embeds the given paths into the chat through DocumentManager.LoadMultipleDocuments.
+// A non-nil summary is returned once loading has started, even if it was aborted.
+func ingest(ctx context.Context, env *environment, chatName, embedModel string, paths []string, jsonOutput bool, stdout io.Writer) (*ingestSummary, error) {
+	chat, err := env.findChat(ctx, chatName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.requireModels(map[string]string{embedModel: "embeddings"}); err != nil {
+		return nil, err
+	}
+
+	if err := env.useChatModels(ctx, chat, "", embedModel); err != nil {
+		return nil, err
+	}
+
+	session, err := env.store.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	pathResults := make([]document.PathDetectionResult, len(paths))
+	for i, path := range paths {
+		pathResults[i] = document.PathDetectionResult{HasPath: true, Path: path}
+		if info, err := os.Stat(path); err == nil {
+			pathResults[i].Exists = true
+			pathResults[i].IsDirectory = info.IsDir()
+			pathResults[i].IsFile = !info.IsDir()
+		}
+	}
+
+	summary := &ingestSummary{Type: "summary", Chat: chat.Name}
+	startTime := time.Now()
+
+	// Reports arrive on the loading goroutine before the response channel is closed,
+	// so summary is safe to read once the channel is drained
+	dm := document.NewDocumentManager(env.backend, env.store, cfg)
+	dm.SetFileReporter(func(fileReport document.FileReport) {
+		switch fileReport.Status {
+		case document.FileLoaded:
+			summary.Loaded++
+			summary.Chunks += fileReport.Chunks
+		case document.FileDuplicate:
+			summary.Duplicates++
+		case document.FileUnsupported:
+			summary.Unsupported++
+		case document.FileFailed:
+			summary.Failed++
+		}
+		writeFileReport(stdout, fileReport, jsonOutput)
+	})
+
+	responseChan, errorChan, err := dm.LoadMultipleDocuments(ctx, chat, embedModel, pathResults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load documents: %w", err)
+	}
+
+	// Progress and warning events are meant for the chat view; file reports replace them here
+	for range responseChan {
+	}
+	summary.Seconds = time.Since(startTime).Seconds()
+
+	if err := <-errorChan; err != nil {
+		return summary, fmt.Errorf("loading aborted: %w", err)
+	}
+
+	return summary, nil
+}
+
+func writeFileReport(w io.Writer, fileReport document.FileReport, jsonOutput bool) {
+	if jsonOutput {
+		writeJSONLine(w, fileLine{Type: "file", FileReport: fileReport})
+		return
+	}
+
+	switch fileReport.Status {
+	case document.FileLoaded:
+		fmt.Fprintf(w, "%-12s %s (%d chunks)\n", fileReport.Status, fileReport.Path, fileReport.Chunks)
+	case document.FileDuplicate:
+		fmt.Fprintf(w, "%-12s %s (duplicate of %s)\n", fileReport.Status, fileReport.Path, fileReport.DuplicateOf)
+	case document.FileFailed:
+		fmt.Fprintf(w, "%-12s %s: %s\n", fileReport.Status, fileReport.Path, fileReport.Error)
+	default:
+		fmt.Fprintf(w, "%-12s %s\n", fileReport.Status, fileReport.Path)
+	}
+}
+
+func writeSummary(w io.Writer, summary *ingestSummary, jsonOutput bool) {
+	if jsonOutput {
+		writeJSONLine(w, summary)
+		return
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintf(w, "Chat %q: %d loaded, %d duplicates skipped, %d unsupported, %d failed (%d chunks in %.1fs)\n",
+		summary.Chat, summary.Loaded, summary.Duplicates, summary.Unsupported, summary.Failed, summary.Chunks, summary.Seconds)
+}
+
+func writeJSONLine(w io.Writer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "%s\n", data)
+}
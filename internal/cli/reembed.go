@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/rag"
+)
+
+// runReembed moves a chat to another embedding model, resuming an interrupted run
+func runReembed(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("reembed", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	chatName := fs.String("chat", "", "chat name or ID (required)")
+	embedModel := fs.String("embed", "", "new embedding model (required)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rag-terminal reembed --chat <name> --embed <model>")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "An interrupted run continues where it stopped when started again with the same model.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if *chatName == "" || *embedModel == "" {
+		fmt.Fprintln(stderr, "Error: --chat and --embed are required")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env, err := openEnvironment()
+	if err != nil {
+		return report(stderr, err)
+	}
+	defer env.close()
+
+	chat, err := env.findChat(ctx, *chatName)
+	if err != nil {
+		return report(stderr, err)
+	}
+
+	if err := env.requireModels(map[string]string{*embedModel: "embeddings"}); err != nil {
+		return report(stderr, err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	// Report every tenth of the way, batches are too frequent for a log
+	lastTenth := -1
+	progress := func(done, total int) {
+		tenth := 10
+		if total > 0 {
+			tenth = done * 10 / total
+		}
+		if tenth != lastTenth {
+			lastTenth = tenth
+			fmt.Fprintf(stdout, "%d/%d vectors\n", done, total)
+		}
+	}
+
+	reembedder := rag.NewReembedder(env.store, env.backend)
+	if err := reembedder.ReembedChat(ctx, chat.ID, *embedModel, cfg.EmbeddingDimensions, progress); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w; run the command again to resume", err)
+		}
+		return report(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Re-embedded %q with %s (%d dimensions)\n", chat.Name, *embedModel, cfg.EmbeddingDimensions)
+	return ExitOK
+}
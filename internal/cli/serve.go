@@ -0,0 +1,395 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/models"
+	"rag-terminal/internal/rag"
+	"rag-terminal/internal/vector"
+)
+
+// runServe exposes stored chats over an OpenAI-compatible HTTP API
+func runServe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	llmModel := fs.String("llm", "", "text generation model (required)")
+	embedModel := fs.String("embed", "", "embedding model (required)")
+	rerankModel := fs.String("rerank", "", "optional reranking model")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rag-terminal serve --llm <model> --embed <model> [--rerank <model>] [--addr host:port]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "The model field of a chat completion request selects a chat by name or ID.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if *llmModel == "" || *embedModel == "" {
+		fmt.Fprintln(stderr, "Error: --llm and --embed are required")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env, err := openEnvironment()
+	if err != nil {
+		return report(stderr, err)
+	}
+	defer env.close()
+
+	if err := env.requireModels(map[string]string{
+		*llmModel:    "text-generation",
+		*embedModel:  "embeddings",
+		*rerankModel: "reranking",
+	}); err != nil {
+		return report(stderr, err)
+	}
+
+	srv := &server{
+		env:        env,
+		pipeline:   rag.NewPipeline(env.backend, env.store, *rerankModel),
+		llmModel:   *llmModel,
+		embedModel: *embedModel,
+	}
+
+	httpServer := &http.Server{
+		Addr:    *addr,
+		Handler: srv.routes(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(stdout, "Serving chats on http://%s/v1\n", *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return report(stderr, fmt.Errorf("server failed: %w", err))
+	}
+
+	return ExitOK
+}
+
+// server answers HTTP requests against the chat store.
+// Every request works on its own chat session, so requests for different chats run concurrently.
+type server struct {
+	env        *environment
+	pipeline   rag.Pipeline
+	llmModel   string
+	embedModel string
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	mux.HandleFunc("GET /v1/models", s.handleModels)
+	mux.HandleFunc("GET /v1/chats", s.handleChats)
+	mux.HandleFunc("GET /v1/chats/{id}/documents", s.handleDocuments)
+	return mux
+}
+
+// chatCompletionRequest is the subset of the OpenAI request the server understands
+type chatCompletionRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+	Stream bool `json:"stream"`
+}
+
+type completionMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type completionChoice struct {
+	Index        int                `json:"index"`
+	Message      *completionMessage `json:"message,omitempty"`
+	Delta        *completionMessage `json:"delta,omitempty"`
+	FinishReason *string            `json:"finish_reason"`
+}
+
+type completionResponse struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []completionChoice `json:"choices"`
+	Sources []vector.Source    `json:"sources,omitempty"` // Cited document excerpts; not part of the OpenAI format
+}
+
+// handleChatCompletions answers the last user message through the RAG pipeline of the chat named by model.
+// Earlier messages in the request are ignored; the chat's stored history provides the context.
+func (s *server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	question := ""
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			question = strings.TrimSpace(req.Messages[i].Content)
+			break
+		}
+	}
+	if req.Model == "" || question == "" {
+		writeAPIError(w, http.StatusBadRequest, "model and a user message are required")
+		return
+	}
+
+	chat, err := s.env.findChat(r.Context(), req.Model)
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+	if err := s.env.useChatModels(r.Context(), chat, s.llmModel, s.embedModel); err != nil {
+		writeChatError(w, err)
+		return
+	}
+
+	completion := completionResponse{
+		ID:      "chatcmpl-" + uuid.New().String(),
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+
+	err = func() error {
+		events, errChan := s.pipeline.ProcessUserMessage(r.Context(), chat, s.llmModel, s.embedModel, question)
+
+		if req.Stream {
+			return streamCompletion(w, completion, events, errChan)
+		}
+
+		var answer strings.Builder
+		var sources []vector.Source
+		for event := range events {
+			switch event.Type {
+			case models.EventToken:
+				answer.WriteString(event.Token)
+			case models.EventSources:
+				sources = event.Sources
+			}
+		}
+		if err := <-errChan; err != nil {
+			return fmt.Errorf("failed to answer: %w", err)
+		}
+
+		stop := "stop"
+		completion.Object = "chat.completion"
+		completion.Choices = []completionChoice{{
+			Message:      &completionMessage{Role: "assistant", Content: answer.String()},
+			FinishReason: &stop,
+		}}
+		completion.Sources = vector.CitedSources(answer.String(), sources)
+		writeJSON(w, http.StatusOK, completion)
+		return nil
+	}()
+	if err != nil {
+		logging.Error("Chat completion for %s failed: %v", chat.Name, err)
+		// Streaming responses have already sent their headers
+		if !req.Stream {
+			writeAPIError(w, http.StatusInternalServerError, err.Error())
+		}
+	}
+}
+
+// streamCompletion writes pipeline tokens as server-sent events in the OpenAI chunk format.
+// The cited sources go out with the final chunk.
+func streamCompletion(w http.ResponseWriter, completion completionResponse, events <-chan models.Event, errChan <-chan error) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	completion.Object = "chat.completion.chunk"
+
+	send := func(choice completionChoice) {
+		completion.Choices = []completionChoice{choice}
+		data, err := json.Marshal(completion)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	send(completionChoice{Delta: &completionMessage{Role: "assistant"}})
+	var answer strings.Builder
+	var sources []vector.Source
+	for event := range events {
+		switch {
+		case event.Type == models.EventSources:
+			sources = event.Sources
+		case event.Type == models.EventToken && event.Token != "":
+			send(completionChoice{Delta: &completionMessage{Content: event.Token}})
+			answer.WriteString(event.Token)
+		}
+	}
+
+	err := <-errChan
+	finishReason := "stop"
+	if err != nil {
+		finishReason = "error"
+	} else {
+		completion.Sources = vector.CitedSources(answer.String(), sources)
+	}
+	send(completionChoice{Delta: &completionMessage{}, FinishReason: &finishReason})
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to answer: %w", err)
+	}
+	return nil
+}
+
+// chatInfo is a chat as listed by the API
+type chatInfo struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Name      string `json:"name"`
+	Created   int64  `json:"created"`
+	FileCount int    `json:"file_count"`
+	OwnedBy   string `json:"owned_by"`
+}
+
+func (s *server) listChats(ctx context.Context) ([]chatInfo, error) {
+	chats, err := s.env.store.ListChats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	infos := make([]chatInfo, len(chats))
+	for i, chat := range chats {
+		infos[i] = chatInfo{
+			ID:        chat.ID,
+			Name:      chat.Name,
+			Created:   chat.CreatedAt.Unix(),
+			FileCount: chat.FileCount,
+			OwnedBy:   "rag-terminal",
+		}
+	}
+	return infos, nil
+}
+
+// handleModels lists chats in the OpenAI model list format, since the model field selects a chat
+func (s *server) handleModels(w http.ResponseWriter, r *http.Request) {
+	infos, err := s.listChats(r.Context())
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for i := range infos {
+		infos[i].Object = "model"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": infos})
+}
+
+func (s *server) handleChats(w http.ResponseWriter, r *http.Request) {
+	infos, err := s.listChats(r.Context())
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for i := range infos {
+		infos[i].Object = "chat"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": infos})
+}
+
+func (s *server) handleDocuments(w http.ResponseWriter, r *http.Request) {
+	chat, err := s.env.findChat(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+
+	session, err := s.env.store.OpenChat(r.Context(), chat.ID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer session.Close()
+
+	docs, err := session.GetDocuments(r.Context())
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if docs == nil {
+		docs = []vector.Document{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": docs})
+}
+
+// writeChatError maps a failure to use a chat to 404 when it doesn't exist, 409 when it was
+// embedded with another model, or 500
+func writeChatError(w http.ResponseWriter, err error) {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.code {
+		case ExitNoSuchChat:
+			writeAPIError(w, http.StatusNotFound, err.Error())
+			return
+		case ExitModelUnavailable:
+			writeAPIError(w, http.StatusConflict, err.Error())
+			return
+		}
+	}
+	writeAPIError(w, http.StatusInternalServerError, err.Error())
+}
+
+// writeAPIError writes an error body in the OpenAI format
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	errType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errType = "server_error"
+	}
+	writeJSON(w, status, map[string]any{
+		"error": map[string]string{"message": message, "type": errType},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Error("Failed to write response: %v", err)
+	}
+}
@@ -13,12 +13,89 @@ const (
 	DefaultConfigFile = "config.yaml"
 )
 
+// defaultRecentTurns is used when the config file doesn't set recent_turns
+const defaultRecentTurns = 3
+
 // Config represents the application configuration
 type Config struct {
-	TokenBudget           TokenBudgetConfig `yaml:"token_budget"`
-	CodeTokenBudget       TokenBudgetConfig `yaml:"code_token_budget"`
-	EmbeddingDimensions   int               `yaml:"embedding_dimensions"`
-	DefaultSystemPrompt   string            `yaml:"default_system_prompt"`
+	TokenBudget          TokenBudgetConfig `yaml:"token_budget"`
+	CodeTokenBudget      TokenBudgetConfig `yaml:"code_token_budget"`
+	EmbeddingDimensions  int               `yaml:"embedding_dimensions"`
+	DefaultSystemPrompt  string            `yaml:"default_system_prompt"`
+	WatchIntervalSeconds int               `yaml:"watch_interval_seconds"`
+	SummaryIntervalTurns int               `yaml:"summary_interval_turns"` // Turns between regenerations of a chat's rolling summary
+	RecentTurns          *int              `yaml:"recent_turns"`           // Last turns sent verbatim with each message, within the history budget; 0 sends none
+	Retrieval            RetrievalConfig   `yaml:"retrieval"`
+	Backend              BackendConfig     `yaml:"backend"`
+
+	// Tokenizers: tokenizer.json, GGUF file or model directory per model name, for exact
+	// token counts when the backend doesn't report model locations (e.g. Ollama)
+	Tokenizers map[string]string `yaml:"tokenizers,omitempty"`
+}
+
+// Supported inference backends
+const (
+	ProviderNexa   = "nexa"
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai" // Any OpenAI-compatible server, e.g. llama.cpp's llama-server
+)
+
+// BackendConfig selects the local runtime serving models
+type BackendConfig struct {
+	// Provider: nexa, ollama or openai
+	// Default: nexa
+	Provider string `yaml:"provider"`
+
+	// BaseURL: server address; empty uses the provider's default
+	// (nexa http://127.0.0.1:18181, ollama http://127.0.0.1:11434, openai http://127.0.0.1:8080/v1)
+	BaseURL string `yaml:"base_url"`
+
+	// APIKey: bearer token sent to openai servers that require one
+	APIKey string `yaml:"api_key"`
+
+	// ConnectTimeoutSeconds: time allowed to connect to the server
+	// Default: 10
+	ConnectTimeoutSeconds int `yaml:"connect_timeout_seconds"`
+
+	// ReadTimeoutSeconds: longest wait for the server to send data, both for the first
+	// response and between streamed tokens
+	// Default: 300
+	ReadTimeoutSeconds int `yaml:"read_timeout_seconds"`
+
+	// Retry: policies for requests failing with connection errors, timeouts or 429/502/503/504
+	Retry BackendRetryConfig `yaml:"retry"`
+}
+
+// BackendRetryConfig holds the retry policy per request kind
+type BackendRetryConfig struct {
+	Chat       RetryPolicyConfig `yaml:"chat"`
+	Embeddings RetryPolicyConfig `yaml:"embeddings"`
+	Rerank     RetryPolicyConfig `yaml:"rerank"`
+}
+
+// RetryPolicyConfig defines attempts and exponential backoff for one request kind
+type RetryPolicyConfig struct {
+	// MaxAttempts: total attempts including the first; 1 disables retries
+	MaxAttempts int `yaml:"max_attempts"`
+
+	// InitialBackoffMs: wait before the first retry, doubled for every further retry
+	InitialBackoffMs int `yaml:"initial_backoff_ms"`
+
+	// MaxBackoffMs: upper bound for the wait between retries
+	MaxBackoffMs int `yaml:"max_backoff_ms"`
+}
+
+// RetrievalConfig defines how vector and keyword (BM25) results are fused
+type RetrievalConfig struct {
+	// VectorWeight: weight of the embedding similarity ranking in reciprocal rank fusion
+	VectorWeight float64 `yaml:"vector_weight"`
+
+	// KeywordWeight: weight of the BM25 keyword ranking; 0 disables keyword search
+	KeywordWeight float64 `yaml:"keyword_weight"`
+
+	// RRFConstant: rank constant k in 1/(k+rank); higher values flatten the advantage of top ranks
+	// Default: 60
+	RRFConstant int `yaml:"rrf_k"`
 }
 
 // TokenBudgetConfig defines how available input tokens are allocated
@@ -36,6 +113,7 @@ type TokenBudgetConfig struct {
 }
 
 func DefaultConfig() *Config {
+	recentTurns := defaultRecentTurns
 	return &Config{
 		// Token budget for text/document files
 		TokenBudget: TokenBudgetConfig{
@@ -49,8 +127,26 @@ func DefaultConfig() *Config {
 			Excerpts:   0.15, // 15% for excerpts (code uses syntax-aware extraction, less excerpt needed)
 			History:    0.05, // 5% for history (prioritize code context over conversation)
 		},
-		EmbeddingDimensions: 786,
-		DefaultSystemPrompt: "You are helpful assistant. Give correct, structured and straight-to-the-point answers. Always think hard when answering. Do not repeat yourself.",
+		EmbeddingDimensions:  786,
+		DefaultSystemPrompt:  "You are helpful assistant. Give correct, structured and straight-to-the-point answers. Always think hard when answering. Do not repeat yourself.",
+		WatchIntervalSeconds: 30,
+		SummaryIntervalTurns: 5,
+		RecentTurns:          &recentTurns,
+		Retrieval: RetrievalConfig{
+			VectorWeight:  1.0,
+			KeywordWeight: 1.0,
+			RRFConstant:   60,
+		},
+		Backend: BackendConfig{
+			Provider:              ProviderNexa,
+			ConnectTimeoutSeconds: 10,
+			ReadTimeoutSeconds:    300,
+			Retry: BackendRetryConfig{
+				Chat:       RetryPolicyConfig{MaxAttempts: 2, InitialBackoffMs: 500, MaxBackoffMs: 5000},
+				Embeddings: RetryPolicyConfig{MaxAttempts: 3, InitialBackoffMs: 500, MaxBackoffMs: 5000},
+				Rerank:     RetryPolicyConfig{MaxAttempts: 2, InitialBackoffMs: 500, MaxBackoffMs: 5000},
+			},
+		},
 	}
 }
 
@@ -125,6 +221,54 @@ func Load() (*Config, error) {
 		needsSave = true
 	}
 
+	if cfg.WatchIntervalSeconds == 0 {
+		cfg.WatchIntervalSeconds = defaults.WatchIntervalSeconds
+		needsSave = true
+	}
+
+	if cfg.SummaryIntervalTurns == 0 {
+		cfg.SummaryIntervalTurns = defaults.SummaryIntervalTurns
+		needsSave = true
+	}
+
+	// Unlike the fields above, 0 is a valid setting, so only a missing value takes the default
+	if cfg.RecentTurns == nil {
+		cfg.RecentTurns = defaults.RecentTurns
+		needsSave = true
+	}
+
+	// Check Retrieval fields
+	if cfg.Retrieval.RRFConstant == 0 {
+		cfg.Retrieval = defaults.Retrieval
+		needsSave = true
+	}
+
+	// Check Backend fields
+	if cfg.Backend.Provider == "" {
+		cfg.Backend.Provider = defaults.Backend.Provider
+		needsSave = true
+	}
+	if cfg.Backend.ConnectTimeoutSeconds == 0 {
+		cfg.Backend.ConnectTimeoutSeconds = defaults.Backend.ConnectTimeoutSeconds
+		needsSave = true
+	}
+	if cfg.Backend.ReadTimeoutSeconds == 0 {
+		cfg.Backend.ReadTimeoutSeconds = defaults.Backend.ReadTimeoutSeconds
+		needsSave = true
+	}
+	if cfg.Backend.Retry.Chat.MaxAttempts == 0 {
+		cfg.Backend.Retry.Chat = defaults.Backend.Retry.Chat
+		needsSave = true
+	}
+	if cfg.Backend.Retry.Embeddings.MaxAttempts == 0 {
+		cfg.Backend.Retry.Embeddings = defaults.Backend.Retry.Embeddings
+		needsSave = true
+	}
+	if cfg.Backend.Retry.Rerank.MaxAttempts == 0 {
+		cfg.Backend.Retry.Rerank = defaults.Backend.Retry.Rerank
+		needsSave = true
+	}
+
 	// Check TokenBudget fields
 	if cfg.TokenBudget.InputRatio == 0 {
 		cfg.TokenBudget = defaults.TokenBudget
@@ -200,6 +344,54 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("embedding_dimensions must be positive, got %d", c.EmbeddingDimensions)
 	}
 
+	// Validate Retrieval
+	if c.Retrieval.VectorWeight < 0.0 || c.Retrieval.KeywordWeight < 0.0 {
+		return fmt.Errorf("retrieval weights must not be negative, got vector=%f keyword=%f", c.Retrieval.VectorWeight, c.Retrieval.KeywordWeight)
+	}
+	if c.Retrieval.VectorWeight == 0.0 && c.Retrieval.KeywordWeight == 0.0 {
+		return fmt.Errorf("retrieval.vector_weight and retrieval.keyword_weight must not both be 0")
+	}
+	if c.Retrieval.RRFConstant <= 0 {
+		return fmt.Errorf("retrieval.rrf_k must be positive, got %d", c.Retrieval.RRFConstant)
+	}
+
+	// Validate Backend
+	switch c.Backend.Provider {
+	case ProviderNexa, ProviderOllama, ProviderOpenAI:
+	default:
+		return fmt.Errorf("backend.provider must be one of %s, %s or %s, got %q", ProviderNexa, ProviderOllama, ProviderOpenAI, c.Backend.Provider)
+	}
+	if c.Backend.ConnectTimeoutSeconds <= 0 || c.Backend.ReadTimeoutSeconds <= 0 {
+		return fmt.Errorf("backend timeouts must be positive, got connect=%d read=%d", c.Backend.ConnectTimeoutSeconds, c.Backend.ReadTimeoutSeconds)
+	}
+	for name, policy := range map[string]RetryPolicyConfig{
+		"chat":       c.Backend.Retry.Chat,
+		"embeddings": c.Backend.Retry.Embeddings,
+		"rerank":     c.Backend.Retry.Rerank,
+	} {
+		if policy.MaxAttempts < 1 {
+			return fmt.Errorf("backend.retry.%s.max_attempts must be at least 1, got %d", name, policy.MaxAttempts)
+		}
+		if policy.InitialBackoffMs < 0 || policy.MaxBackoffMs < policy.InitialBackoffMs {
+			return fmt.Errorf("backend.retry.%s backoff must satisfy 0 <= initial_backoff_ms <= max_backoff_ms, got %d and %d", name, policy.InitialBackoffMs, policy.MaxBackoffMs)
+		}
+	}
+
+	// Validate WatchIntervalSeconds
+	if c.WatchIntervalSeconds <= 0 {
+		return fmt.Errorf("watch_interval_seconds must be positive, got %d", c.WatchIntervalSeconds)
+	}
+
+	// Validate SummaryIntervalTurns
+	if c.SummaryIntervalTurns <= 0 {
+		return fmt.Errorf("summary_interval_turns must be positive, got %d", c.SummaryIntervalTurns)
+	}
+
+	// Validate RecentTurns
+	if c.RecentTurns != nil && *c.RecentTurns < 0 {
+		return fmt.Errorf("recent_turns must not be negative, got %d", *c.RecentTurns)
+	}
+
 	return nil
 }
 
@@ -229,6 +421,14 @@ func (c *Config) validateTokenBudget(name string, budget *TokenBudgetConfig) err
 	return nil
 }
 
+// GetRecentTurns returns the number of latest turns sent verbatim with each message
+func (c *Config) GetRecentTurns() int {
+	if c.RecentTurns == nil {
+		return defaultRecentTurns
+	}
+	return *c.RecentTurns
+}
+
 // GetChunksBudget returns the calculated percentage for chunks
 func (c *Config) GetChunksBudget() float64 {
 	return 1.0 - c.TokenBudget.Excerpts - c.TokenBudget.History
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig writes a config file into a temporary home directory
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, DefaultConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, DefaultConfigFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadRecentTurns(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"missing", "embedding_dimensions: 768\n", defaultRecentTurns},
+		{"zero", "recent_turns: 0\n", 0},
+		{"set", "recent_turns: 5\n", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfig(t, tt.content)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if got := cfg.GetRecentTurns(); got != tt.want {
+				t.Errorf("GetRecentTurns() = %d, want %d", got, tt.want)
+			}
+
+			// The value written back by Load must read the same
+			cfg, err = Load()
+			if err != nil {
+				t.Fatalf("second Load failed: %v", err)
+			}
+			if got := cfg.GetRecentTurns(); got != tt.want {
+				t.Errorf("GetRecentTurns() after saving = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsNegativeRecentTurns(t *testing.T) {
+	writeConfig(t, "recent_turns: -1\n")
+
+	if _, err := Load(); err == nil {
+		t.Error("Load accepted a negative recent_turns")
+	}
+}
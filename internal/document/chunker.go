@@ -32,11 +32,27 @@ func NewChunker() *Chunker {
 // Chunk represents a single chunk of text
 type Chunk struct {
 	Content  string
-	StartPos int
-	EndPos   int
+	StartPos int // Byte offset of the chunk in the document content
+	EndPos   int // Byte offset just past the chunk
 	Index    int
 }
 
+// LineRange converts the byte offsets of a chunk into 1-based, inclusive line numbers of content.
+// ok is false if the offsets don't fit content, e.g. because the file changed since it was chunked.
+func LineRange(content string, startPos, endPos int) (startLine, endLine int, ok bool) {
+	if startPos < 0 || endPos < startPos || endPos > len(content) {
+		return 0, 0, false
+	}
+
+	startLine = strings.Count(content[:startPos], "\n") + 1
+	endLine = startLine + strings.Count(content[startPos:endPos], "\n")
+	// A range ending with a newline doesn't reach into the following line
+	if endPos > startPos && content[endPos-1] == '\n' {
+		endLine--
+	}
+	return startLine, endLine, true
+}
+
 // ChunkDocument splits a document into overlapping chunks
 // It attempts to preserve paragraph and sentence boundaries where possible
 // For code files, it uses structure-aware chunking
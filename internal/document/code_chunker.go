@@ -40,9 +40,11 @@ func (c *CodeChunker) ChunkCode(code string, maxChunkSize int) []Chunk {
 	// Convert code blocks to chunks
 	chunks := make([]Chunk, 0)
 	chunkIndex := 0
+	offsets := lineOffsets(code)
 
 	for _, block := range blocks {
 		content := c.optimizeCodeBlock(block)
+		startPos, endPos := blockSpan(offsets, len(code), block)
 
 		if len(strings.TrimSpace(content)) == 0 {
 			continue
@@ -55,8 +57,8 @@ func (c *CodeChunker) ChunkCode(code string, maxChunkSize int) []Chunk {
 				if len(strings.TrimSpace(subContent)) > 0 {
 					chunks = append(chunks, Chunk{
 						Content:  subContent,
-						StartPos: block.StartLine,
-						EndPos:   block.EndLine,
+						StartPos: startPos,
+						EndPos:   endPos,
 						Index:    chunkIndex,
 					})
 					chunkIndex++
@@ -65,8 +67,8 @@ func (c *CodeChunker) ChunkCode(code string, maxChunkSize int) []Chunk {
 		} else {
 			chunks = append(chunks, Chunk{
 				Content:  content,
-				StartPos: block.StartLine,
-				EndPos:   block.EndLine,
+				StartPos: startPos,
+				EndPos:   endPos,
 				Index:    chunkIndex,
 			})
 			chunkIndex++
@@ -78,28 +80,32 @@ func (c *CodeChunker) ChunkCode(code string, maxChunkSize int) []Chunk {
 			lines := strings.Split(code, "\n")
 			currentChunk := []string{}
 			currentSize := 0
+			chunkStart := 0
+			position := 0
 
 			for _, line := range lines {
 				lineSize := len(line) + 1
 				if currentSize+lineSize > maxChunkSize && len(currentChunk) > 0 {
 					chunks = append(chunks, Chunk{
 						Content:  strings.Join(currentChunk, "\n"),
-						StartPos: 0,
-						EndPos:   len(currentChunk),
+						StartPos: chunkStart,
+						EndPos:   position - 1, // Up to the newline ending the previous line
 						Index:    len(chunks),
 					})
 					currentChunk = []string{}
 					currentSize = 0
+					chunkStart = position
 				}
 				currentChunk = append(currentChunk, line)
 				currentSize += lineSize
+				position += lineSize
 			}
 
 			if len(currentChunk) > 0 {
 				chunks = append(chunks, Chunk{
 					Content:  strings.Join(currentChunk, "\n"),
-					StartPos: 0,
-					EndPos:   len(lines),
+					StartPos: chunkStart,
+					EndPos:   len(code),
 					Index:    len(chunks),
 				})
 			}
@@ -116,6 +122,34 @@ func (c *CodeChunker) ChunkCode(code string, maxChunkSize int) []Chunk {
 	return chunks
 }
 
+// lineOffsets returns the byte offset at which each line of code starts
+func lineOffsets(code string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(code); i++ {
+		if code[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// blockSpan converts the line range of a block (end exclusive) into byte offsets of code,
+// leaving out the newline that ends the block's last line
+func blockSpan(offsets []int, codeLen int, block CodeBlock) (int, int) {
+	start := codeLen
+	if block.StartLine < len(offsets) {
+		start = offsets[block.StartLine]
+	}
+	end := codeLen
+	if block.EndLine < len(offsets) {
+		end = offsets[block.EndLine] - 1
+	}
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
 // detectLanguage attempts to detect programming language from code
 func (c *CodeChunker) detectLanguage(code string) string {
 	// Check for language-specific patterns
@@ -505,7 +539,7 @@ func (c *CodeChunker) extractGenericBlocks(code string) []CodeBlock {
 			Name:      "",
 			Content:   strings.Join(currentBlock, "\n"),
 			StartLine: startLine,
-			EndLine:   len(lines) - 1,
+			EndLine:   len(lines),
 			Language:  "generic",
 		})
 	}
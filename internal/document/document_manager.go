@@ -2,35 +2,92 @@ package document
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"rag-terminal/internal/config"
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/logging"
-	"rag-terminal/internal/nexa"
+	"rag-terminal/internal/models"
 	"rag-terminal/internal/vector"
 )
 
 // DocumentManager handles document loading and embedding operations
 type DocumentManager struct {
-	nexaClient  *nexa.Client
+	backend     llm.Backend
 	vectorStore vector.VectorStore
 	config      *config.Config
+	onFile      func(FileReport) // Optional, called once per file by LoadMultipleDocuments
+}
+
+// FileStatus is the outcome of loading a single file
+type FileStatus string
+
+const (
+	FileLoaded      FileStatus = "loaded"      // Chunked, embedded and stored
+	FileDuplicate   FileStatus = "duplicate"   // Skipped, a document with the same content hash exists
+	FileUnsupported FileStatus = "unsupported" // Skipped, the file type isn't supported
+	FileFailed      FileStatus = "failed"      // Could not be read, parsed, embedded or stored
+)
+
+// FileReport describes what happened to a single file during a load
+type FileReport struct {
+	Path        string     `json:"path"`
+	Status      FileStatus `json:"status"`
+	Chunks      int        `json:"chunks,omitempty"`
+	DuplicateOf string     `json:"duplicate_of,omitempty"`
+	Error       string     `json:"error,omitempty"`
 }
 
 // NewDocumentManager creates a new document manager
-func NewDocumentManager(nexaClient *nexa.Client, vectorStore vector.VectorStore, cfg *config.Config) *DocumentManager {
+func NewDocumentManager(backend llm.Backend, vectorStore vector.VectorStore, cfg *config.Config) *DocumentManager {
 	return &DocumentManager{
-		nexaClient:  nexaClient,
+		backend:     backend,
 		vectorStore: vectorStore,
 		config:      cfg,
 	}
 }
 
+// SetFileReporter registers a callback that receives the outcome of every file handled by LoadMultipleDocuments.
+// The callback runs on the loading goroutine; all calls happen before the response channel is closed.
+func (dm *DocumentManager) SetFileReporter(onFile func(FileReport)) {
+	dm.onFile = onFile
+}
+
+// reportFile passes a file outcome to the registered reporter, if any
+func (dm *DocumentManager) reportFile(report FileReport) {
+	if dm.onFile != nil {
+		dm.onFile(report)
+	}
+}
+
+// reportLoadErrors reports the files a load skipped or failed on
+func (dm *DocumentManager) reportLoadErrors(errs []error) {
+	for _, err := range errs {
+		dm.reportFile(fileErrorReport("", err))
+	}
+}
+
+// fileErrorReport classifies a load error, using the path from a FileError when there is one
+func fileErrorReport(path string, err error) FileReport {
+	report := FileReport{Path: path, Status: FileFailed, Error: err.Error()}
+
+	var fileErr *FileError
+	if errors.As(err, &fileErr) {
+		report.Path = fileErr.Path
+	}
+	if errors.Is(err, ErrUnsupportedFile) {
+		report.Status = FileUnsupported
+	}
+
+	return report
+}
+
 // LoadDocuments loads documents from a file or directory path
-func (dm *DocumentManager) LoadDocuments(ctx context.Context, chat *vector.Chat, embedModel string, path string) (<-chan string, <-chan error, error) {
+func (dm *DocumentManager) LoadDocuments(ctx context.Context, chat *vector.Chat, embedModel string, path string) (<-chan models.Event, <-chan error, error) {
 	logging.Info("LoadDocuments called: path=%s, chatID=%s", path, chat.ID)
 
 	loader := NewLoader()
@@ -51,27 +108,30 @@ func (dm *DocumentManager) LoadDocuments(ctx context.Context, chat *vector.Chat,
 	}
 
 	// Create response channels
-	responseChan := make(chan string, 10)
+	events := make(chan models.Event, 10)
 	errorChan := make(chan error, 1)
 
 	// Process documents asynchronously
 	go func() {
-		defer close(responseChan)
+		defer close(events)
 		defer close(errorChan)
 
-		for _, doc := range loadResult.Documents {
-			if err := dm.ProcessDocument(ctx, chat, embedModel, doc, loader, responseChan); err != nil {
+		events <- models.StageEvent(models.StageEmbedding)
+		for i, doc := range loadResult.Documents {
+			if err := dm.ProcessDocument(ctx, chat, embedModel, doc, loader, events); err != nil {
 				errorChan <- err
 				return
 			}
+			events <- models.ProgressEvent(i+1, len(loadResult.Documents))
 		}
 	}()
 
-	return responseChan, errorChan, nil
+	return events, errorChan, nil
 }
 
-// LoadMultipleDocuments loads documents from multiple file or directory paths
-func (dm *DocumentManager) LoadMultipleDocuments(ctx context.Context, chat *vector.Chat, embedModel string, paths []PathDetectionResult) (<-chan string, <-chan error, error) {
+// LoadMultipleDocuments loads documents from multiple file or directory paths.
+// Progress counts embedded files; paths that can't be loaded and skipped duplicates are reported as warnings.
+func (dm *DocumentManager) LoadMultipleDocuments(ctx context.Context, chat *vector.Chat, embedModel string, paths []PathDetectionResult) (<-chan models.Event, <-chan error, error) {
 	logging.Info("LoadMultipleDocuments called: pathCount=%d, chatID=%s", len(paths), chat.ID)
 
 	if len(paths) == 0 {
@@ -79,16 +139,17 @@ func (dm *DocumentManager) LoadMultipleDocuments(ctx context.Context, chat *vect
 	}
 
 	// Create response channels
-	responseChan := make(chan string, 10)
+	events := make(chan models.Event, 10)
 	errorChan := make(chan error, 1)
 
 	// Process all paths asynchronously
 	go func() {
-		defer close(responseChan)
+		defer close(events)
 		defer close(errorChan)
 
 		loader := NewLoader()
-		totalSuccess := 0
+		totalSuccess := 0 // Files stored
+		processed := 0    // Files handled, whatever the outcome
 
 		badgerStore, ok := dm.vectorStore.(*vector.BadgerStore)
 		if !ok {
@@ -96,6 +157,14 @@ func (dm *DocumentManager) LoadMultipleDocuments(ctx context.Context, chat *vect
 			return
 		}
 
+		// Hold the chat open for the whole load so it isn't evicted between documents
+		session, err := badgerStore.OpenChat(ctx, chat.ID)
+		if err != nil {
+			errorChan <- fmt.Errorf("failed to open chat: %w", err)
+			return
+		}
+		defer session.Close()
+
 		// Count total documents to embed
 		totalDocsToEmbed := 0
 		for _, pathResult := range paths {
@@ -106,7 +175,8 @@ func (dm *DocumentManager) LoadMultipleDocuments(ctx context.Context, chat *vect
 		}
 
 		// Send initial progress
-		responseChan <- fmt.Sprintf("@@PROGRESS:0/%d@@", totalDocsToEmbed)
+		events <- models.StageEvent(models.StageEmbedding)
+		events <- models.ProgressEvent(0, totalDocsToEmbed)
 
 		// Reload loader for actual processing
 		loader = NewLoader()
@@ -118,72 +188,121 @@ func (dm *DocumentManager) LoadMultipleDocuments(ctx context.Context, chat *vect
 			loadResult, err := loader.LoadPath(ctx, pathResult.Path, chat.ID)
 			if err != nil {
 				logging.Error("Failed to load documents from path %s: %v", pathResult.Path, err)
-				responseChan <- fmt.Sprintf("⚠ Failed to load %s: %v\n", pathResult.Path, err)
+				events <- models.WarningEvent("Failed to load %s: %v", pathResult.Path, err)
+				dm.reportFile(fileErrorReport(pathResult.Path, err))
 				continue
 			}
 
+			dm.reportLoadErrors(loadResult.Errors)
+
 			if loadResult.SuccessCount == 0 {
 				logging.Info("No supported documents found in path: %s", pathResult.Path)
-				responseChan <- fmt.Sprintf("⚠ No supported documents in %s\n", pathResult.Path)
+				events <- models.WarningEvent("No supported documents in %s", pathResult.Path)
 				continue
 			}
 
-			// Process documents from this path using helper
+			// Process documents from this path using helper; a file that fails is reported and skipped
 			for _, doc := range loadResult.Documents {
-				if err := dm.ProcessDocument(ctx, chat, embedModel, doc, loader, responseChan); err != nil {
-					errorChan <- err
-					return
+				report, err := dm.processDocument(ctx, chat, embedModel, doc, loader, events)
+				if err != nil {
+					if isFatalLoadError(ctx, err) {
+						errorChan <- err
+						return
+					}
+					logging.Error("Skipping %s: %v", doc.FilePath, err)
+					events <- models.WarningEvent("Failed to load %s: %v", doc.FileName, err)
+					dm.discardDocument(ctx, session, doc)
+					report = FileReport{Path: doc.FilePath, Status: FileFailed, Error: err.Error()}
 				}
+				dm.reportFile(report)
 
-				totalSuccess++
+				processed++
+				if report.Status == FileLoaded {
+					totalSuccess++
+				}
 
 				// Send progress update
-				responseChan <- fmt.Sprintf("@@PROGRESS:%d/%d@@", totalSuccess, totalDocsToEmbed)
+				events <- models.ProgressEvent(processed, totalDocsToEmbed)
 			}
 		}
 
-		// Update chat file count
-		if totalSuccess > 0 {
-			chat.FileCount += totalSuccess
-			if err := badgerStore.UpdateChat(ctx, chat); err != nil {
+		// Remember loaded roots so watch mode can pick up later changes
+		watchPathsChanged := dm.addWatchPaths(chat, paths)
+
+		// Recount stored documents, so skipped duplicates aren't counted twice; this also saves the watch paths
+		if totalSuccess > 0 || watchPathsChanged {
+			if err := dm.syncFileCount(ctx, chat); err != nil {
 				logging.Error("Failed to update chat file count: %v", err)
 			}
 		}
 	}()
 
-	return responseChan, errorChan, nil
+	return events, errorChan, nil
+}
+
+// isFatalLoadError reports whether a failure to process one file ends the whole load,
+// because the remaining files would fail the same way
+func isFatalLoadError(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, vector.ErrChatClosed)
 }
 
-// ProcessDocument handles the complete pipeline for a single document
+// discardDocument removes what was stored of a document that failed to load, so a later
+// load doesn't skip the file as a duplicate of its incomplete copy
+func (dm *DocumentManager) discardDocument(ctx context.Context, session *vector.ChatSession, doc vector.Document) {
+	if err := session.DeleteDocument(ctx, doc.ID); err != nil {
+		logging.Error("Failed to remove incomplete document %s: %v", doc.FilePath, err)
+	}
+}
+
+// ProcessDocument handles the complete pipeline for a single document.
+// A skipped duplicate is reported as a warning on events, which may be nil.
 func (dm *DocumentManager) ProcessDocument(
 	ctx context.Context,
 	chat *vector.Chat,
 	embedModel string,
 	doc vector.Document,
 	loader *Loader,
-	responseChan chan<- string,
+	events chan<- models.Event,
 ) error {
-	badgerStore, ok := dm.vectorStore.(*vector.BadgerStore)
-	if !ok {
-		return fmt.Errorf("vector store is not BadgerStore type")
+	_, err := dm.processDocument(ctx, chat, embedModel, doc, loader, events)
+	return err
+}
+
+// processDocument is ProcessDocument that also reports whether the document was stored or skipped as a duplicate
+func (dm *DocumentManager) processDocument(
+	ctx context.Context,
+	chat *vector.Chat,
+	embedModel string,
+	doc vector.Document,
+	loader *Loader,
+	events chan<- models.Event,
+) (FileReport, error) {
+	report := FileReport{Path: doc.FilePath}
+
+	session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return report, fmt.Errorf("failed to open chat: %w", err)
 	}
+	defer session.Close()
 
 	logging.Debug("Processing document: %s (size=%d, hash=%s)", doc.FileName, doc.FileSize, doc.ContentHash)
 
 	// Check if document with same content hash already exists
-	existingDoc, err := badgerStore.FindDocumentByHash(ctx, doc.ContentHash)
+	existingDoc, err := session.FindDocumentByHash(ctx, doc.ContentHash)
 	if err == nil && existingDoc != nil {
 		logging.Info("Document %s already exists (duplicate of %s), skipping", doc.FileName, existingDoc.FileName)
-		if responseChan != nil {
-			responseChan <- fmt.Sprintf("Skipped %s (duplicate of %s)\n", doc.FileName, existingDoc.FileName)
+		if events != nil {
+			events <- models.WarningEvent("Skipped %s (duplicate of %s)", doc.FileName, existingDoc.FileName)
 		}
-		return nil // Not an error, just skipped
+		report.Status = FileDuplicate
+		report.DuplicateOf = existingDoc.FilePath
+		return report, nil // Not an error, just skipped
 	}
 
 	// Store document metadata
-	if err := badgerStore.StoreDocument(ctx, &doc); err != nil {
+	if err := session.StoreDocument(ctx, &doc); err != nil {
 		logging.Error("Failed to store document metadata for %s: %v", doc.FileName, err)
-		return fmt.Errorf("failed to store document %s: %w", doc.FileName, err)
+		return report, fmt.Errorf("failed to store document %s: %w", doc.FileName, err)
 	}
 	logging.Debug("Stored document metadata for %s", doc.FileName)
 
@@ -191,10 +310,34 @@ func (dm *DocumentManager) ProcessDocument(
 	chunks, err := loader.GetDocumentChunks(doc.ID, doc.FilePath, chat.ID)
 	if err != nil {
 		logging.Error("Failed to get chunks for %s: %v", doc.FileName, err)
-		return fmt.Errorf("failed to chunk document %s: %w", doc.FileName, err)
+		return report, fmt.Errorf("failed to chunk document %s: %w", doc.FileName, err)
 	}
 	logging.Debug("Created %d chunks for %s", len(chunks), doc.FileName)
 
+	if err := dm.embedChunks(ctx, embedModel, doc.FileName, chunks); err != nil {
+		return report, err
+	}
+
+	// Store chunks with embeddings
+	for i, chunk := range chunks {
+		if err := session.StoreDocumentChunk(ctx, &chunk); err != nil {
+			logging.Error("Failed to store chunk %d of %s: %v", i, doc.FileName, err)
+			return report, fmt.Errorf("failed to store chunk %d of %s: %w", i, doc.FileName, err)
+		}
+	}
+	logging.Info("Successfully stored %d chunks for %s", len(chunks), doc.FileName)
+
+	report.Status = FileLoaded
+	report.Chunks = len(chunks)
+	return report, nil
+}
+
+// embedChunks generates embeddings for all chunks of a document in one batch and attaches them in place
+func (dm *DocumentManager) embedChunks(ctx context.Context, embedModel string, fileName string, chunks []vector.DocumentChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+
 	// Prepare chunk contents for batch embedding
 	chunkContents := make([]string, len(chunks))
 	for i, chunk := range chunks {
@@ -202,23 +345,142 @@ func (dm *DocumentManager) ProcessDocument(
 	}
 
 	// Generate embeddings for all chunks in batch
-	logging.Debug("Generating embeddings for %d chunks of %s with dimensions=%d", len(chunks), doc.FileName, dm.config.EmbeddingDimensions)
-	embeddings, err := dm.nexaClient.GenerateEmbeddings(ctx, embedModel, chunkContents, &dm.config.EmbeddingDimensions)
+	logging.Debug("Generating embeddings for %d chunks of %s with dimensions=%d", len(chunks), fileName, dm.config.EmbeddingDimensions)
+	embeddings, err := dm.backend.GenerateEmbeddings(ctx, embedModel, chunkContents, &dm.config.EmbeddingDimensions)
 	if err != nil {
-		logging.Error("Failed to generate embeddings for %s: %v", doc.FileName, err)
-		return fmt.Errorf("failed to generate embeddings for %s: %w", doc.FileName, err)
+		logging.Error("Failed to generate embeddings for %s: %v", fileName, err)
+		return fmt.Errorf("failed to generate embeddings for %s: %w", fileName, err)
+	}
+	if len(embeddings) != len(chunks) {
+		return fmt.Errorf("failed to generate embeddings for %s: expected %d, got %d", fileName, len(chunks), len(embeddings))
 	}
-	logging.Debug("Generated %d embeddings for %s (dim=%d)", len(embeddings), doc.FileName, len(embeddings[0]))
+	logging.Debug("Generated %d embeddings for %s (dim=%d)", len(embeddings), fileName, len(embeddings[0]))
 
-	// Store chunks with embeddings
-	for i, chunk := range chunks {
-		chunk.Embedding = embeddings[i]
-		if err := badgerStore.StoreDocumentChunk(ctx, &chunk); err != nil {
-			logging.Error("Failed to store chunk %d of %s: %v", i, doc.FileName, err)
-			return fmt.Errorf("failed to store chunk %d of %s: %w", i, doc.FileName, err)
+	for i := range chunks {
+		chunks[i].Embedding = embeddings[i]
+	}
+
+	return nil
+}
+
+// DeleteDocument removes a document and its chunks from the chat and updates the chat file count
+func (dm *DocumentManager) DeleteDocument(ctx context.Context, chat *vector.Chat, docID string) error {
+	session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	if err := session.DeleteDocument(ctx, docID); err != nil {
+		logging.Error("Failed to delete document %s: %v", docID, err)
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	return dm.syncFileCount(ctx, chat)
+}
+
+// ReindexDocument re-reads a document from disk and replaces its chunks and embeddings.
+// Returns false without touching the store when the file content hasn't changed.
+func (dm *DocumentManager) ReindexDocument(ctx context.Context, chat *vector.Chat, embedModel string, docID string) (bool, error) {
+	session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	docs, err := session.GetDocuments(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	var existing *vector.Document
+	for i := range docs {
+		if docs[i].ID == docID {
+			existing = &docs[i]
+			break
 		}
 	}
-	logging.Info("Successfully stored %d chunks for %s", len(chunks), doc.FileName)
+	if existing == nil {
+		return false, fmt.Errorf("document not found: %s", docID)
+	}
+
+	loader := NewLoader()
+	loadResult, err := loader.LoadPath(ctx, existing.FilePath, chat.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to reload %s: %w", existing.FileName, err)
+	}
+	if loadResult.SuccessCount == 0 {
+		if len(loadResult.Errors) > 0 {
+			return false, fmt.Errorf("failed to reload %s: %w", existing.FileName, loadResult.Errors[0])
+		}
+		return false, fmt.Errorf("failed to reload %s: file is no longer supported", existing.FileName)
+	}
+
+	doc := loadResult.Documents[0]
+	if doc.ContentHash == existing.ContentHash {
+		logging.Info("Document %s is unchanged, skipping reindex", existing.FileName)
+		return false, nil
+	}
+
+	if err := dm.replaceDocument(ctx, chat, embedModel, loader, existing, doc); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// replaceDocument re-chunks and re-embeds a freshly loaded version of an existing document
+func (dm *DocumentManager) replaceDocument(ctx context.Context, chat *vector.Chat, embedModel string, loader *Loader, existing *vector.Document, doc vector.Document) error {
+	session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	// Keep the original identity so references to the document stay valid
+	doc.ID = existing.ID
+	doc.Metadata = existing.Metadata
+
+	chunks, err := loader.GetDocumentChunks(doc.ID, doc.FilePath, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to chunk document %s: %w", doc.FileName, err)
+	}
+
+	if err := dm.embedChunks(ctx, embedModel, doc.FileName, chunks); err != nil {
+		return err
+	}
+
+	if err := session.ReindexDocument(ctx, &doc, chunks); err != nil {
+		logging.Error("Failed to reindex document %s: %v", doc.FileName, err)
+		return fmt.Errorf("failed to reindex document %s: %w", doc.FileName, err)
+	}
+
+	return nil
+}
+
+// syncFileCount sets the chat file count to the number of stored documents
+func (dm *DocumentManager) syncFileCount(ctx context.Context, chat *vector.Chat) error {
+	badgerStore, ok := dm.vectorStore.(*vector.BadgerStore)
+	if !ok {
+		return fmt.Errorf("vector store is not BadgerStore type")
+	}
+
+	session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	count, err := session.GetDocumentCount(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	chat.FileCount = count
+	if err := badgerStore.UpdateChat(ctx, chat); err != nil {
+		logging.Error("Failed to update chat file count: %v", err)
+		return fmt.Errorf("failed to update chat: %w", err)
+	}
 
 	return nil
 }
@@ -271,15 +533,16 @@ func (dm *DocumentManager) FilterChunksByFiles(chunks []vector.DocumentChunk, fi
 }
 
 // GetAllChunksFromFiles retrieves all chunks for multiple specified files
-func (dm *DocumentManager) GetAllChunksFromFiles(ctx context.Context, filePaths []string) []vector.DocumentChunk {
-	store, ok := dm.vectorStore.(*vector.BadgerStore)
-	if !ok {
-		logging.Error("Vector store is not BadgerStore type")
+func (dm *DocumentManager) GetAllChunksFromFiles(ctx context.Context, chat *vector.Chat, filePaths []string) []vector.DocumentChunk {
+	session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		logging.Error("Failed to open chat: %v", err)
 		return []vector.DocumentChunk{}
 	}
+	defer session.Close()
 
 	// Get all documents to find matching document IDs
-	docs, err := store.GetDocuments(ctx)
+	docs, err := session.GetDocuments(ctx)
 	if err != nil {
 		logging.Error("Failed to get documents: %v", err)
 		return []vector.DocumentChunk{}
@@ -320,7 +583,7 @@ func (dm *DocumentManager) GetAllChunksFromFiles(ctx context.Context, filePaths
 
 	// Search with a dummy embedding to get all chunks, then filter by document IDs
 	dummyEmbedding := make([]float32, dm.config.EmbeddingDimensions)
-	_, allChunks, err := store.SearchSimilarContextAndChunks(ctx, dummyEmbedding, 200)
+	_, allChunks, err := session.SearchSimilarContextAndChunks(ctx, "", dummyEmbedding, 200)
 	if err != nil {
 		logging.Error("Failed to search chunks: %v", err)
 		return []vector.DocumentChunk{}
@@ -0,0 +1,208 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/models"
+	"rag-terminal/internal/vector"
+)
+
+// failingEmbedder embeds every text as a fixed vector, except texts containing "BROKEN"
+type failingEmbedder struct{}
+
+func (failingEmbedder) ChatCompletion(ctx context.Context, req llm.ChatCompletionRequest) (<-chan string, <-chan error, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
+func (failingEmbedder) ChatCompletionSync(ctx context.Context, req llm.ChatCompletionRequest) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (failingEmbedder) GenerateEmbeddings(ctx context.Context, model string, texts []string, dimensions *int) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "BROKEN") {
+			return nil, errors.New("embedding server rejected the input")
+		}
+		embeddings[i] = []float32{1, float32(len(text)), 0.5}
+	}
+	return embeddings, nil
+}
+
+func (failingEmbedder) Rerank(ctx context.Context, req llm.RerankingRequest) ([]float64, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (failingEmbedder) GetModels() ([]llm.Model, error) { return nil, nil }
+
+func (failingEmbedder) Ping(ctx context.Context) error { return nil }
+
+func TestLoadMultipleDocumentsSkipsFailingFiles(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"first.txt":  "The first document talks about apples and pears.",
+		"broken.txt": "This document is BROKEN and can't be embedded.",
+		"second.txt": "The second document talks about rivers and lakes.",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := vector.NewBadgerStore(t.TempDir(), vector.KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	chat := &vector.Chat{ID: "chat-1", Name: "test"}
+	if err := store.StoreChat(ctx, chat); err != nil {
+		t.Fatal(err)
+	}
+
+	dm := NewDocumentManager(failingEmbedder{}, store, config.DefaultConfig())
+	reports := make(map[string]FileReport)
+	dm.SetFileReporter(func(report FileReport) {
+		reports[filepath.Base(report.Path)] = report
+	})
+
+	events, errs, err := dm.LoadMultipleDocuments(ctx, chat, "embed", []PathDetectionResult{
+		{HasPath: true, Path: dir, Exists: true, IsDirectory: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	var lastProgress models.Event
+	for event := range events {
+		switch event.Type {
+		case models.EventWarning:
+			warnings = append(warnings, event.Message)
+		case models.EventProgress:
+			lastProgress = event
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("load aborted: %v", err)
+	}
+
+	for name, want := range map[string]FileStatus{"first.txt": FileLoaded, "second.txt": FileLoaded, "broken.txt": FileFailed} {
+		if got := reports[name].Status; got != want {
+			t.Errorf("%s reported as %q, want %q", name, got, want)
+		}
+	}
+	if reports["broken.txt"].Error == "" {
+		t.Error("failed file reported without an error")
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "broken.txt") {
+		t.Errorf("warnings = %q, want one about broken.txt", warnings)
+	}
+	if lastProgress.Done != 3 || lastProgress.Total != 3 {
+		t.Errorf("last progress %d/%d, want 3/3", lastProgress.Done, lastProgress.Total)
+	}
+
+	// The failed file leaves nothing behind, so loading it again isn't skipped as a duplicate
+	session, err := store.OpenChat(ctx, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	docs, err := session.GetDocuments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Errorf("%d documents stored, want 2", len(docs))
+	}
+	for _, doc := range docs {
+		if doc.FileName == "broken.txt" {
+			t.Error("failed document was stored")
+		}
+	}
+	if chat.FileCount != 2 {
+		t.Errorf("chat file count %d, want 2", chat.FileCount)
+	}
+}
+
+func TestLoadRecountsStoredDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	for _, name := range []string{"first.txt", "second.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("Contents of "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := vector.NewBadgerStore(t.TempDir(), vector.KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// The count starts out of step with the stored documents; loads recount rather than add to it
+	chat := &vector.Chat{ID: "chat-1", Name: "test", FileCount: 7}
+	if err := store.StoreChat(ctx, chat); err != nil {
+		t.Fatal(err)
+	}
+
+	dm := NewDocumentManager(failingEmbedder{}, store, config.DefaultConfig())
+	paths := []PathDetectionResult{{HasPath: true, Path: dir, Exists: true, IsDirectory: true}}
+
+	// The second load skips both files as duplicates; a new file is loaded alongside them
+	for i, want := range []int{2, 3} {
+		if i == 1 {
+			if err := os.WriteFile(filepath.Join(dir, "third.txt"), []byte("Contents of third.txt"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		events, errs, err := dm.LoadMultipleDocuments(ctx, chat, "embed", paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range events {
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("load %d aborted: %v", i+1, err)
+		}
+		if chat.FileCount != want {
+			t.Errorf("chat file count %d after load %d, want %d", chat.FileCount, i+1, want)
+		}
+	}
+}
+
+func TestIsFatalLoadError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"file failure", context.Background(), errors.New("failed to generate embeddings"), false},
+		{"closed chat", context.Background(), fmt.Errorf("failed to store chunk: %w", vector.ErrChatClosed), true},
+		{"canceled load", canceled, errors.New("failed to generate embeddings"), true},
+	}
+
+	for _, tt := range tests {
+		if got := isFatalLoadError(tt.ctx, tt.err); got != tt.want {
+			t.Errorf("%s: isFatalLoadError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
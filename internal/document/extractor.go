@@ -3,6 +3,10 @@ package document
 import (
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"rag-terminal/internal/vector"
 )
 
 // Extractor extracts relevant excerpts from chunks based on query
@@ -208,6 +212,53 @@ func (e *Extractor) extractTerms(text string) map[string]int {
 	return terms
 }
 
+// Terms splits text into lowercase keyword index terms, skipping stop words like extractTerms.
+// Unlike extractTerms it keeps non-ASCII letters, short codes with digits and snake_case identifiers
+// (both whole and split) so exact names can be matched.
+func (e *Extractor) Terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	})
+
+	var terms []string
+	for _, word := range words {
+		word = strings.Trim(word, "_")
+		if e.isIndexTerm(word) {
+			terms = append(terms, word)
+		}
+		if strings.Contains(word, "_") {
+			for _, part := range strings.Split(word, "_") {
+				if e.isIndexTerm(part) {
+					terms = append(terms, part)
+				}
+			}
+		}
+	}
+
+	return terms
+}
+
+// KeywordAnalyzer returns the analyzer chat keyword indexes are built with: Terms with the English
+// stop words. Bump the ID whenever Terms changes what it returns, so existing indexes are rebuilt.
+func KeywordAnalyzer() vector.KeywordAnalyzer {
+	return vector.KeywordAnalyzer{
+		ID:    "extractor-terms-en-v1",
+		Terms: NewExtractor().Terms,
+	}
+}
+
+// isIndexTerm reports whether a word is significant enough to index
+func (e *Extractor) isIndexTerm(word string) bool {
+	if word == "" {
+		return false
+	}
+	// Short words are noise unless they carry a digit, like error codes
+	if utf8.RuneCountInString(word) < 3 && !strings.ContainsAny(word, "0123456789") {
+		return false
+	}
+	return !e.stopWords.IsStopWord(word)
+}
+
 // calculateTermOverlap calculates overlap between query and sentence terms
 func (e *Extractor) calculateTermOverlap(queryTerms, sentenceTerms map[string]int) float64 {
 	if len(queryTerms) == 0 {
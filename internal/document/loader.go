@@ -2,6 +2,7 @@ package document
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,6 +26,18 @@ func NewLoader() *Loader {
 	}
 }
 
+// ErrUnsupportedFile is returned for files whose type the parser doesn't handle
+var ErrUnsupportedFile = errors.New("file not supported")
+
+// FileError records why a single file could not be loaded
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string { return e.Err.Error() }
+func (e *FileError) Unwrap() error { return e.Err }
+
 // LoadResult contains the results of loading documents
 type LoadResult struct {
 	Documents      []vector.Document
@@ -76,7 +89,7 @@ func (l *Loader) loadDirectory(ctx context.Context, dirPath string, chatID strin
 		}
 
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("error accessing %s: %w", path, err))
+			result.Errors = append(result.Errors, &FileError{Path: path, Err: fmt.Errorf("error accessing %s: %w", path, err)})
 			return nil // Continue walking
 		}
 
@@ -111,13 +124,13 @@ func (l *Loader) loadFile(ctx context.Context, filePath string, chatID string, r
 	// Check if file is supported
 	if !parsed.IsSupported {
 		result.FailureCount++
-		return fmt.Errorf("file not supported: %s", filePath)
+		return &FileError{Path: filePath, Err: fmt.Errorf("%w: %s", ErrUnsupportedFile, filePath)}
 	}
 
 	// Check for parsing errors
 	if parsed.Error != nil {
 		result.FailureCount++
-		return fmt.Errorf("failed to parse %s: %w", filePath, parsed.Error)
+		return &FileError{Path: filePath, Err: fmt.Errorf("failed to parse %s: %w", filePath, parsed.Error)}
 	}
 
 	// Calculate content hash for deduplication
@@ -177,6 +190,7 @@ func (l *Loader) GetDocumentChunks(docID string, filePath string, chatID string)
 			Content:    chunk.Content,
 			StartPos:   chunk.StartPos,
 			EndPos:     chunk.EndPos,
+			Offsets:    vector.ChunkOffsetsBytes,
 			FilePath:   filePath,
 			Embedding:  []float32{}, // Will be populated during embedding
 		}
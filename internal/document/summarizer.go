@@ -5,18 +5,18 @@ import (
 	"fmt"
 	"strings"
 
-	"rag-terminal/internal/nexa"
+	"rag-terminal/internal/llm"
 )
 
 // Summarizer generates concise summaries of document chunks
 type Summarizer struct {
-	nexaClient *nexa.Client
+	backend llm.Backend
 }
 
 // NewSummarizer creates a new summarizer
-func NewSummarizer(nexaClient *nexa.Client) *Summarizer {
+func NewSummarizer(backend llm.Backend) *Summarizer {
 	return &Summarizer{
-		nexaClient: nexaClient,
+		backend: backend,
 	}
 }
 
@@ -35,9 +35,9 @@ Text:
 
 Summary:`, targetLength, chunkContent)
 
-	req := nexa.ChatCompletionRequest{
+	req := llm.ChatCompletionRequest{
 		Model: model,
-		Messages: []nexa.ChatMessage{
+		Messages: []llm.ChatMessage{
 			{Role: "system", Content: "You are a text summarization assistant. Create concise, informative summaries that preserve key facts and concepts."},
 			{Role: "user", Content: prompt},
 		},
@@ -46,7 +46,7 @@ Summary:`, targetLength, chunkContent)
 		Stream:      false,
 	}
 
-	summary, err := s.nexaClient.ChatCompletionSync(ctx, req)
+	summary, err := s.backend.ChatCompletionSync(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate summary: %w", err)
 	}
@@ -63,9 +63,9 @@ Document:
 
 Summary:`, maxLength, documentContent)
 
-	req := nexa.ChatCompletionRequest{
+	req := llm.ChatCompletionRequest{
 		Model: model,
-		Messages: []nexa.ChatMessage{
+		Messages: []llm.ChatMessage{
 			{Role: "system", Content: "You are a document summarization assistant. Create high-level overviews that capture the main themes."},
 			{Role: "user", Content: prompt},
 		},
@@ -74,7 +74,7 @@ Summary:`, maxLength, documentContent)
 		Stream:      false,
 	}
 
-	summary, err := s.nexaClient.ChatCompletionSync(ctx, req)
+	summary, err := s.backend.ChatCompletionSync(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate document summary: %w", err)
 	}
@@ -82,6 +82,43 @@ Summary:`, maxLength, documentContent)
 	return strings.TrimSpace(summary), nil
 }
 
+// SummarizeConversation folds new conversation turns into a running summary of a chat.
+// previousSummary may be empty for the first summary; transcript holds the new turns.
+func (s *Summarizer) SummarizeConversation(ctx context.Context, model string, previousSummary string, transcript string, maxLength int) (string, error) {
+	previous := previousSummary
+	if previous == "" {
+		previous = "(none yet)"
+	}
+
+	prompt := fmt.Sprintf(`Update the summary of an ongoing conversation with the new messages below, in %d characters or less. Keep the goals, decisions, open questions and names of files, functions and other identifiers. Drop small talk and details that were superseded.
+
+Current summary:
+%s
+
+New messages:
+%s
+
+Updated summary:`, maxLength, previous, transcript)
+
+	req := llm.ChatCompletionRequest{
+		Model: model,
+		Messages: []llm.ChatMessage{
+			{Role: "system", Content: "You are a conversation summarization assistant. Keep a concise running record of what a conversation is about and what was decided."},
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.3,
+		MaxTokens:   maxLength / 2,
+		Stream:      false,
+	}
+
+	summary, err := s.backend.ChatCompletionSync(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate conversation summary: %w", err)
+	}
+
+	return strings.TrimSpace(summary), nil
+}
+
 // ExtractKeyPoints extracts bullet points of key information from text
 func (s *Summarizer) ExtractKeyPoints(ctx context.Context, model string, content string) ([]string, error) {
 	prompt := fmt.Sprintf(`Extract the key points from this text as a bullet list. Be concise.
@@ -91,9 +128,9 @@ Text:
 
 Key points:`, content)
 
-	req := nexa.ChatCompletionRequest{
+	req := llm.ChatCompletionRequest{
 		Model: model,
-		Messages: []nexa.ChatMessage{
+		Messages: []llm.ChatMessage{
 			{Role: "system", Content: "You are an information extraction assistant. Extract key facts and points as concise bullets."},
 			{Role: "user", Content: prompt},
 		},
@@ -102,7 +139,7 @@ Key points:`, content)
 		Stream:      false,
 	}
 
-	response, err := s.nexaClient.ChatCompletionSync(ctx, req)
+	response, err := s.backend.ChatCompletionSync(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract key points: %w", err)
 	}
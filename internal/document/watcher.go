@@ -0,0 +1,219 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/models"
+	"rag-terminal/internal/vector"
+)
+
+// WatchChanges describes how the files under a chat's watched paths differ from its stored documents
+type WatchChanges struct {
+	Changed []ChangedDocument // Stored documents whose content hash no longer matches the file
+	Added   []vector.Document // Files under a watched root that aren't stored yet
+	Removed []vector.Document // Stored documents whose file no longer exists
+}
+
+// ChangedDocument pairs a stored document with its freshly loaded version
+type ChangedDocument struct {
+	Existing vector.Document
+	Current  vector.Document
+}
+
+// Count returns the total number of files that need work
+func (c *WatchChanges) Count() int {
+	return len(c.Changed) + len(c.Added) + len(c.Removed)
+}
+
+// WatchInterval returns how often watched paths should be polled
+func (dm *DocumentManager) WatchInterval() time.Duration {
+	return time.Duration(dm.config.WatchIntervalSeconds) * time.Second
+}
+
+// addWatchPaths records the absolute root paths of a load in the chat metadata.
+// Returns true if the chat was modified and needs saving.
+func (dm *DocumentManager) addWatchPaths(chat *vector.Chat, paths []PathDetectionResult) bool {
+	known := make(map[string]bool, len(chat.WatchPaths))
+	for _, p := range chat.WatchPaths {
+		known[p] = true
+	}
+
+	changed := false
+	for _, pathResult := range paths {
+		resolved, err := filepath.Abs(pathResult.Path)
+		if err != nil {
+			logging.Error("Failed to resolve watch path %s: %v", pathResult.Path, err)
+			continue
+		}
+		if known[resolved] {
+			continue
+		}
+		known[resolved] = true
+		chat.WatchPaths = append(chat.WatchPaths, resolved)
+		changed = true
+	}
+
+	return changed
+}
+
+// DetectChanges rescans the chat's watched paths and diffs content hashes against the stored documents.
+// Nothing is written; pass the result to ApplyChanges to re-embed.
+func (dm *DocumentManager) DetectChanges(ctx context.Context, chat *vector.Chat) (*WatchChanges, error) {
+	session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	docs, err := session.GetDocuments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	stored := make(map[string]vector.Document, len(docs))
+	hashes := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		stored[absPath(doc.FilePath)] = doc
+		hashes[doc.ContentHash] = true
+	}
+
+	changes := &WatchChanges{}
+	seen := make(map[string]bool)
+	loader := NewLoader()
+
+	for _, root := range chat.WatchPaths {
+		if _, err := os.Stat(root); err != nil {
+			// A vanished root is handled below through its documents
+			logging.Debug("Watched path %s is not accessible: %v", root, err)
+			continue
+		}
+
+		loadResult, err := loader.LoadPath(ctx, root, chat.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logging.Error("Failed to scan watched path %s: %v", root, err)
+			continue
+		}
+
+		for _, doc := range loadResult.Documents {
+			path := absPath(doc.FilePath)
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			existing, ok := stored[path]
+			if !ok {
+				// Copies of already stored content would only be skipped as duplicates again
+				if !hashes[doc.ContentHash] {
+					changes.Added = append(changes.Added, doc)
+				}
+			} else if existing.ContentHash != doc.ContentHash {
+				changes.Changed = append(changes.Changed, ChangedDocument{Existing: existing, Current: doc})
+			}
+		}
+	}
+
+	// Only drop documents that belong to a watched root and whose file is really gone;
+	// a file that merely failed to parse this time keeps its old chunks
+	for path, doc := range stored {
+		if seen[path] || !underWatchedPath(path, chat.WatchPaths) {
+			continue
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			changes.Removed = append(changes.Removed, doc)
+		}
+	}
+
+	if changes.Count() > 0 {
+		logging.Info("Watch detected changes in chat %s: changed=%d, added=%d, removed=%d",
+			chat.ID, len(changes.Changed), len(changes.Added), len(changes.Removed))
+	}
+
+	return changes, nil
+}
+
+// ApplyChanges re-embeds changed and added files and deletes removed ones, reporting progress like LoadMultipleDocuments
+func (dm *DocumentManager) ApplyChanges(ctx context.Context, chat *vector.Chat, embedModel string, changes *WatchChanges) (<-chan models.Event, <-chan error) {
+	events := make(chan models.Event, 10)
+	errorChan := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errorChan)
+
+		session, err := dm.vectorStore.OpenChat(ctx, chat.ID)
+		if err != nil {
+			errorChan <- fmt.Errorf("failed to open chat: %w", err)
+			return
+		}
+		defer session.Close()
+
+		total := changes.Count()
+		done := 0
+		events <- models.StageEvent(models.StageEmbedding)
+		events <- models.ProgressEvent(0, total)
+
+		loader := NewLoader()
+
+		for _, change := range changes.Changed {
+			if err := dm.replaceDocument(ctx, chat, embedModel, loader, &change.Existing, change.Current); err != nil {
+				errorChan <- err
+				return
+			}
+			done++
+			events <- models.ProgressEvent(done, total)
+		}
+
+		for _, doc := range changes.Added {
+			if err := dm.ProcessDocument(ctx, chat, embedModel, doc, loader, nil); err != nil {
+				errorChan <- err
+				return
+			}
+			done++
+			events <- models.ProgressEvent(done, total)
+		}
+
+		for _, doc := range changes.Removed {
+			if err := session.DeleteDocument(ctx, doc.ID); err != nil {
+				errorChan <- fmt.Errorf("failed to delete document %s: %w", doc.FileName, err)
+				return
+			}
+			done++
+			events <- models.ProgressEvent(done, total)
+		}
+
+		if err := dm.syncFileCount(ctx, chat); err != nil {
+			errorChan <- err
+		}
+	}()
+
+	return events, errorChan
+}
+
+// absPath resolves a document path for comparison, falling back to the cleaned path
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// underWatchedPath reports whether path is one of the roots or lies inside one
+func underWatchedPath(path string, roots []string) bool {
+	for _, root := range roots {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,594 @@
+package export
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/vector"
+)
+
+// ArchiveVersion is increased when the archive layout changes incompatibly
+const ArchiveVersion = 1
+
+// Archive entries, in the order they are written
+const (
+	archiveManifest  = "manifest.json"
+	archiveChat      = "chat.json"
+	archiveProfile   = "profile.json"
+	archiveSummary   = "summary.json" // Only written when the chat has a summary
+	archiveDocuments = "documents.json"
+	archiveChunks    = "chunks.jsonl"   // One DocumentChunk per line, embeddings included
+	archiveMessages  = "messages.jsonl" // One context message per line, embeddings included
+)
+
+// reembedBatchSize is the number of texts sent per embedding request when re-embedding on import
+const reembedBatchSize = 32
+
+// ErrEmbeddingMismatch is returned when an archive was embedded with another model or dimension
+// than the importing side uses, and re-embedding wasn't requested
+var ErrEmbeddingMismatch = errors.New("archive embeddings don't match the embedding model")
+
+// Manifest describes an archive. It is the first entry, so an import can be checked
+// before any data is read.
+type Manifest struct {
+	Version             int       `json:"version"`
+	CreatedAt           time.Time `json:"created_at"`
+	ChatName            string    `json:"chat_name"`
+	EmbeddingModel      string    `json:"embedding_model"`
+	EmbeddingDimensions int       `json:"embedding_dimensions"`
+	Documents           int       `json:"documents"`
+	Chunks              int       `json:"chunks"`
+	ContextMessages     int       `json:"context_messages"`
+}
+
+// WriteArchive writes a chat with its documents, chunk embeddings, context messages and
+// profile and summary as a tar.gz, so it can be imported elsewhere without re-embedding.
+// embedModel and dimensions name the embedding model the chat was built with.
+func WriteArchive(ctx context.Context, store vector.VectorStore, chatID, embedModel string, dimensions int, w io.Writer) (*Manifest, error) {
+	chat, err := store.GetChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	session, err := store.OpenChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	docs, err := session.GetDocuments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	profile, err := session.GetUserProfile(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	summary, err := session.GetSummary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation summary: %w", err)
+	}
+
+	// Tar headers need the entry size up front, so the large entries are spooled to disk first
+	chunksFile, chunkCount, err := spoolChunks(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	defer removeSpool(chunksFile)
+
+	messagesFile, messageCount, err := spoolContextMessages(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	defer removeSpool(messagesFile)
+
+	manifest := &Manifest{
+		Version:             ArchiveVersion,
+		CreatedAt:           time.Now(),
+		ChatName:            chat.Name,
+		EmbeddingModel:      embedModel,
+		EmbeddingDimensions: dimensions,
+		Documents:           len(docs),
+		Chunks:              chunkCount,
+		ContextMessages:     messageCount,
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeJSONEntry(tw, archiveManifest, manifest); err != nil {
+		return nil, err
+	}
+	if err := writeJSONEntry(tw, archiveChat, chat); err != nil {
+		return nil, err
+	}
+	if err := writeJSONEntry(tw, archiveProfile, profile); err != nil {
+		return nil, err
+	}
+	if summary != nil {
+		if err := writeJSONEntry(tw, archiveSummary, summary); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeJSONEntry(tw, archiveDocuments, docs); err != nil {
+		return nil, err
+	}
+	if err := writeFileEntry(tw, archiveChunks, chunksFile); err != nil {
+		return nil, err
+	}
+	if err := writeFileEntry(tw, archiveMessages, messagesFile); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// spoolChunks writes all chunks as JSON lines into a temporary file
+func spoolChunks(ctx context.Context, session *vector.ChatSession) (*os.File, int, error) {
+	f, err := os.CreateTemp("", "rag-terminal-chunks-*.jsonl")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	out := bufio.NewWriter(f)
+	encoder := json.NewEncoder(out)
+	count := 0
+	err = session.ForEachDocumentChunk(ctx, func(chunk *vector.DocumentChunk) error {
+		count++
+		return encoder.Encode(chunk)
+	})
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		removeSpool(f)
+		return nil, 0, fmt.Errorf("failed to write chunks: %w", err)
+	}
+
+	return f, count, nil
+}
+
+// spoolContextMessages writes the retrieval Q&A messages as JSON lines into a temporary file
+func spoolContextMessages(ctx context.Context, session *vector.ChatSession) (*os.File, int, error) {
+	messages, err := session.GetMessages(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "rag-terminal-messages-*.jsonl")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	out := bufio.NewWriter(f)
+	encoder := json.NewEncoder(out)
+	count := 0
+	for _, msg := range messages {
+		if msg.Role != "context" {
+			continue
+		}
+		if err = encoder.Encode(msg); err != nil {
+			break
+		}
+		count++
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		removeSpool(f)
+		return nil, 0, fmt.Errorf("failed to write context messages: %w", err)
+	}
+
+	return f, count, nil
+}
+
+func removeSpool(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+func writeJSONEntry(tw *tar.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeFileEntry(tw *tar.Writer, name string, f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	header := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// ImportOptions describes the embedding setup of the importing side
+type ImportOptions struct {
+	EmbedModel string
+
+	// Dimensions is the embedding size used when re-embedding. Archives built with EmbedModel
+	// keep the dimensions recorded in their manifest unless Reembed is set.
+	Dimensions int
+
+	// Reembed regenerates chunk and context message embeddings with EmbedModel at Dimensions when
+	// the archive was built with another model or size. Archives of another model are refused without it.
+	Reembed bool
+
+	// Backend generates the new embeddings; required with Reembed
+	Backend llm.Backend
+
+	// Name replaces the chat name from the archive when set
+	Name string
+}
+
+// ImportArchive creates a new chat from an archive written by WriteArchive.
+// Watch paths are dropped since they point into the exporting machine's file system.
+func ImportArchive(ctx context.Context, store vector.VectorStore, r io.Reader, opts ImportOptions) (chat *vector.Chat, manifest *Manifest, err error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if header.Name != archiveManifest {
+		return nil, nil, fmt.Errorf("not a chat archive: first entry is %s, expected %s", header.Name, archiveManifest)
+	}
+	manifest = &Manifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if manifest.Version > ArchiveVersion {
+		return nil, nil, fmt.Errorf("archive version %d is newer than supported version %d", manifest.Version, ArchiveVersion)
+	}
+
+	// The archive's vectors are usable as they are if they come from the same model
+	reembed := manifest.EmbeddingModel != opts.EmbedModel ||
+		(opts.Reembed && opts.Dimensions > 0 && manifest.EmbeddingDimensions != opts.Dimensions)
+	if reembed && !opts.Reembed {
+		return nil, manifest, fmt.Errorf("%w: archive uses %s (%d dimensions), the import uses %s",
+			ErrEmbeddingMismatch, manifest.EmbeddingModel, manifest.EmbeddingDimensions, opts.EmbedModel)
+	}
+	if reembed && opts.Backend == nil {
+		return nil, manifest, fmt.Errorf("re-embedding requires a backend")
+	}
+
+	dimensions := manifest.EmbeddingDimensions
+	if reembed {
+		dimensions = opts.Dimensions
+	}
+
+	imp := &importer{store: store, opts: opts, reembed: reembed, dimensions: dimensions}
+	defer func() {
+		if err != nil {
+			imp.abort(ctx)
+			chat = nil
+		}
+	}()
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, manifest, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if header.Name != archiveChat && imp.session == nil {
+			return nil, manifest, fmt.Errorf("invalid archive: %s precedes %s", header.Name, archiveChat)
+		}
+
+		switch header.Name {
+		case archiveChat:
+			err = imp.createChat(ctx, tr)
+		case archiveProfile:
+			err = imp.storeProfile(ctx, tr)
+		case archiveSummary:
+			err = imp.storeSummary(ctx, tr)
+		case archiveDocuments:
+			err = imp.storeDocuments(ctx, tr)
+		case archiveChunks:
+			err = imp.storeChunks(ctx, tr)
+		case archiveMessages:
+			err = imp.storeMessages(ctx, tr)
+		default:
+			logging.Debug("Skipping unknown archive entry %s", header.Name)
+		}
+		if err != nil {
+			return nil, manifest, err
+		}
+	}
+
+	if imp.session == nil {
+		return nil, manifest, fmt.Errorf("invalid archive: %s missing", archiveChat)
+	}
+	imp.session.Close()
+
+	logging.Info("Imported chat %s (%s): %d documents, %d chunks, %d context messages, re-embedded: %v",
+		imp.chat.Name, imp.chat.ID, manifest.Documents, manifest.Chunks, manifest.ContextMessages, reembed)
+	return imp.chat, manifest, nil
+}
+
+// importer holds the chat being created by ImportArchive
+type importer struct {
+	store      vector.VectorStore
+	opts       ImportOptions
+	reembed    bool
+	dimensions int // Of the imported embeddings, the archive's unless re-embedded
+	chat       *vector.Chat
+	session    *vector.ChatSession
+}
+
+// createChat stores the chat metadata under a new ID, so an import never overwrites a local chat
+func (imp *importer) createChat(ctx context.Context, r io.Reader) error {
+	if imp.session != nil {
+		return fmt.Errorf("invalid archive: duplicate %s", archiveChat)
+	}
+
+	var chat vector.Chat
+	if err := json.NewDecoder(r).Decode(&chat); err != nil {
+		return fmt.Errorf("failed to read chat: %w", err)
+	}
+
+	chat.ID = imp.newChatID(ctx)
+	chat.WatchEnabled = false
+	chat.WatchPaths = nil
+	chat.EmbedModel = imp.opts.EmbedModel // Either the archive's model or the one the chat is re-embedded with
+	chat.EmbeddingDimensions = imp.dimensions
+	if imp.opts.Name != "" {
+		chat.Name = imp.opts.Name
+	}
+
+	if err := imp.store.StoreChat(ctx, &chat); err != nil {
+		return fmt.Errorf("failed to create chat: %w", err)
+	}
+	imp.chat = &chat
+
+	session, err := imp.store.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	imp.session = session
+	return nil
+}
+
+func (imp *importer) newChatID(ctx context.Context) string {
+	now := time.Now().Unix()
+	for {
+		id := fmt.Sprintf("chat-%d", now)
+		if _, err := imp.store.GetChat(ctx, id); err != nil {
+			return id
+		}
+		now++
+	}
+}
+
+func (imp *importer) storeProfile(ctx context.Context, r io.Reader) error {
+	var profile vector.UserProfile
+	if err := json.NewDecoder(r).Decode(&profile); err != nil {
+		return fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	profile.ChatID = imp.chat.ID
+	if err := imp.session.StoreUserProfile(ctx, &profile); err != nil {
+		return fmt.Errorf("failed to store profile: %w", err)
+	}
+	return nil
+}
+
+func (imp *importer) storeSummary(ctx context.Context, r io.Reader) error {
+	var summary vector.ConversationSummary
+	if err := json.NewDecoder(r).Decode(&summary); err != nil {
+		return fmt.Errorf("failed to read summary: %w", err)
+	}
+
+	if err := imp.session.StoreSummary(ctx, &summary); err != nil {
+		return fmt.Errorf("failed to store summary: %w", err)
+	}
+	return nil
+}
+
+func (imp *importer) storeDocuments(ctx context.Context, r io.Reader) error {
+	var docs []vector.Document
+	if err := json.NewDecoder(r).Decode(&docs); err != nil {
+		return fmt.Errorf("failed to read documents: %w", err)
+	}
+
+	for i := range docs {
+		docs[i].ChatID = imp.chat.ID
+		if err := imp.session.StoreDocument(ctx, &docs[i]); err != nil {
+			return fmt.Errorf("failed to store document %s: %w", docs[i].FileName, err)
+		}
+	}
+	return nil
+}
+
+func (imp *importer) storeChunks(ctx context.Context, r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	var batch []*vector.DocumentChunk
+
+	flush := func() error {
+		if imp.reembed {
+			texts := make([]string, len(batch))
+			for i, chunk := range batch {
+				texts[i] = chunk.Content
+			}
+			embeddings, err := imp.embed(ctx, texts)
+			if err != nil {
+				return err
+			}
+			for i, chunk := range batch {
+				chunk.Embedding = embeddings[i]
+			}
+		} else {
+			for _, chunk := range batch {
+				if err := imp.checkDimensions(archiveChunks, "chunk "+chunk.ID, chunk.Embedding); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, chunk := range batch {
+			if err := imp.session.StoreDocumentChunk(ctx, chunk); err != nil {
+				return fmt.Errorf("failed to store chunk %s: %w", chunk.ID, err)
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		chunk := &vector.DocumentChunk{}
+		if err := decoder.Decode(chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read chunks: %w", err)
+		}
+		chunk.ChatID = imp.chat.ID
+
+		batch = append(batch, chunk)
+		if len(batch) >= reembedBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+func (imp *importer) storeMessages(ctx context.Context, r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	var batch []vector.Message
+
+	flush := func() error {
+		if imp.reembed {
+			texts := make([]string, len(batch))
+			for i, msg := range batch {
+				texts[i] = msg.Content
+			}
+			embeddings, err := imp.embed(ctx, texts)
+			if err != nil {
+				return err
+			}
+			for i := range batch {
+				batch[i].Embedding = embeddings[i]
+			}
+		} else {
+			for _, msg := range batch {
+				if err := imp.checkDimensions(archiveMessages, "message "+msg.ID, msg.Embedding); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, msg := range batch {
+			if err := imp.session.StoreMessage(ctx, msg.ID, msg.Role, msg.Content, msg.Embedding, msg.Timestamp); err != nil {
+				return fmt.Errorf("failed to store message %s: %w", msg.ID, err)
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		var msg vector.Message
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read context messages: %w", err)
+		}
+
+		batch = append(batch, msg)
+		if len(batch) >= reembedBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// checkDimensions rejects an archived embedding that doesn't match the manifest's dimensions,
+// since the index can't compare vectors of different lengths
+func (imp *importer) checkDimensions(entry, record string, embedding []float32) error {
+	if len(embedding) == 0 || len(embedding) == imp.dimensions {
+		return nil
+	}
+	return fmt.Errorf("invalid archive: %s in %s has %d dimensions, the manifest records %d",
+		record, entry, len(embedding), imp.dimensions)
+}
+
+func (imp *importer) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	embeddings, err := imp.opts.Backend.GenerateEmbeddings(ctx, imp.opts.EmbedModel, texts, &imp.opts.Dimensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-embed: %w", err)
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("failed to re-embed: got %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	return embeddings, nil
+}
+
+// abort removes a partially imported chat
+func (imp *importer) abort(ctx context.Context) {
+	if imp.session != nil {
+		imp.session.Close()
+		imp.session = nil
+	}
+	if imp.chat != nil {
+		if err := imp.store.DeleteChat(ctx, imp.chat.ID); err != nil {
+			logging.Error("Failed to remove partially imported chat %s: %v", imp.chat.ID, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"rag-terminal/internal/vector"
+)
+
+// writeTestArchive writes an archive of a chat with one document whose chunks carry the given embeddings
+func writeTestArchive(t *testing.T, store *vector.BadgerStore, embeddings map[string][]float32) *bytes.Buffer {
+	t.Helper()
+	ctx := context.Background()
+
+	chat := &vector.Chat{ID: "chat-1", Name: "source", EmbedModel: "embed", EmbeddingDimensions: 4}
+	if err := store.StoreChat(ctx, chat); err != nil {
+		t.Fatal(err)
+	}
+	session, err := store.OpenChat(ctx, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	doc := &vector.Document{ID: "doc-1", ChatID: chat.ID, FileName: "notes.txt"}
+	if err := session.StoreDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	for id, embedding := range embeddings {
+		chunk := &vector.DocumentChunk{ID: id, DocumentID: doc.ID, ChatID: chat.ID, Content: "content of " + id, Embedding: embedding}
+		if err := session.StoreDocumentChunk(ctx, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := WriteArchive(ctx, store, chat.ID, "embed", 4, &buf); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	return &buf
+}
+
+func TestImportArchive(t *testing.T) {
+	ctx := context.Background()
+	store, err := vector.NewBadgerStore(t.TempDir(), vector.KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	archive := writeTestArchive(t, store, map[string][]float32{
+		"chunk-1": {1, 0, 0, 0},
+		"chunk-2": {0, 1, 0, 0},
+	})
+
+	chat, manifest, err := ImportArchive(ctx, store, archive, ImportOptions{EmbedModel: "embed", Dimensions: 8, Name: "copy"})
+	if err != nil {
+		t.Fatalf("ImportArchive failed: %v", err)
+	}
+	if manifest.Chunks != 2 {
+		t.Errorf("manifest records %d chunks, want 2", manifest.Chunks)
+	}
+	if chat.EmbeddingDimensions != 4 {
+		t.Errorf("imported chat has %d dimensions, want the archive's 4", chat.EmbeddingDimensions)
+	}
+}
+
+func TestImportArchiveRejectsMismatchedEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	store, err := vector.NewBadgerStore(t.TempDir(), vector.KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	archive := writeTestArchive(t, store, map[string][]float32{
+		"chunk-1":   {1, 0, 0, 0},
+		"chunk-bad": {0, 1, 0},
+	})
+
+	_, _, err = ImportArchive(ctx, store, archive, ImportOptions{EmbedModel: "embed", Dimensions: 4, Name: "copy"})
+	if err == nil {
+		t.Fatal("ImportArchive accepted a chunk with the wrong number of dimensions")
+	}
+	if !strings.Contains(err.Error(), "chunk-bad") || !strings.Contains(err.Error(), archiveChunks) {
+		t.Errorf("error %q doesn't name the chunk and entry", err)
+	}
+
+	// The partial import is removed, leaving only the source chat
+	chats, err := store.ListChats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chats) != 1 {
+		t.Errorf("%d chats after the failed import, want 1", len(chats))
+	}
+}
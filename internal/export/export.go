@@ -0,0 +1,247 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"rag-terminal/internal/vector"
+)
+
+// BundleVersion is increased when the JSON bundle layout changes incompatibly
+const BundleVersion = 1
+
+// Format is an export file format
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+	FormatHTML     Format = "html"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatMarkdown, FormatJSON, FormatHTML}
+
+// ParseFormat accepts a format name or its file extension
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "json":
+		return FormatJSON, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (use markdown, json or html)", name)
+	}
+}
+
+// Extension returns the file extension of the format, including the dot
+func (f Format) Extension() string {
+	switch f {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	default:
+		return ".json"
+	}
+}
+
+// Bundle is everything stored for a chat. Embeddings are left out; they are derived from
+// the content and only meaningful for the embedding model that produced them.
+type Bundle struct {
+	Version    int                  `json:"version"`
+	ExportedAt time.Time            `json:"exported_at"`
+	Chat       vector.Chat          `json:"chat"`
+	Usage      vector.ChatUsage     `json:"usage"`
+	Messages   []Message            `json:"messages"`
+	Documents  []vector.Document    `json:"documents"`
+	Facts      []vector.ProfileFact `json:"facts"`
+
+	Summary *vector.ConversationSummary `json:"summary,omitempty"` // Rolling summary, if one was made
+}
+
+// Message is a stored message without its embedding. Role "context" marks the Q&A
+// pairs kept for retrieval; transcripts skip them.
+type Message struct {
+	ID        string             `json:"id"`
+	Role      string             `json:"role"`
+	Content   string             `json:"content"`
+	Timestamp time.Time          `json:"timestamp"`
+	Usage     *vector.TokenUsage `json:"usage,omitempty"`
+	Sources   []vector.Source    `json:"sources,omitempty"`
+}
+
+// Load collects the chat metadata, messages, documents, facts and summary of a chat
+func Load(ctx context.Context, store vector.VectorStore, chatID string) (*Bundle, error) {
+	chat, err := store.GetChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	session, err := store.OpenChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	messages, err := session.GetMessages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	docs, err := session.GetDocuments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	profile, err := session.GetUserProfile(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	summary, err := session.GetSummary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation summary: %w", err)
+	}
+
+	bundle := &Bundle{
+		Version:    BundleVersion,
+		ExportedAt: time.Now(),
+		Chat:       *chat,
+		Usage:      chat.Usage,
+		Messages:   make([]Message, len(messages)),
+		Documents:  docs,
+		Facts:      []vector.ProfileFact{},
+		Summary:    summary,
+	}
+
+	for i, msg := range messages {
+		bundle.Messages[i] = Message{
+			ID:        msg.ID,
+			Role:      msg.Role,
+			Content:   msg.Content,
+			Timestamp: msg.Timestamp,
+			Usage:     msg.Usage,
+			Sources:   msg.Sources,
+		}
+	}
+
+	if bundle.Documents == nil {
+		bundle.Documents = []vector.Document{}
+	}
+	sort.Slice(bundle.Documents, func(i, j int) bool {
+		return bundle.Documents[i].FilePath < bundle.Documents[j].FilePath
+	})
+
+	if profile != nil {
+		for _, fact := range profile.Facts {
+			bundle.Facts = append(bundle.Facts, fact)
+		}
+		sort.Slice(bundle.Facts, func(i, j int) bool {
+			return bundle.Facts[i].Key < bundle.Facts[j].Key
+		})
+	}
+
+	return bundle, nil
+}
+
+// Transcript returns the user and assistant messages in order
+func (b *Bundle) Transcript() []Message {
+	var transcript []Message
+	for _, msg := range b.Messages {
+		if msg.Role == "user" || msg.Role == "assistant" {
+			transcript = append(transcript, msg)
+		}
+	}
+	return transcript
+}
+
+// Write renders the bundle in the given format
+func Write(w io.Writer, b *Bundle, format Format) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, b)
+	case FormatJSON:
+		return writeJSON(w, b)
+	case FormatHTML:
+		return writeHTML(w, b)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func writeJSON(w io.Writer, b *Bundle) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(b); err != nil {
+		return fmt.Errorf("failed to encode chat: %w", err)
+	}
+	return nil
+}
+
+// ToFile exports a chat into dir and returns the path of the written file
+func ToFile(ctx context.Context, store vector.VectorStore, chatID string, format Format, dir string) (string, error) {
+	bundle, err := Load(ctx, store, chatID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	path := filepath.Join(dir, FileName(&bundle.Chat, format, bundle.ExportedAt))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	if err := Write(f, bundle, format); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return path, nil
+}
+
+// FileName builds a file name from the chat name and the export time
+func FileName(chat *vector.Chat, format Format, at time.Time) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			return r
+		case r == ' ', r == '.':
+			return '-'
+		default:
+			return -1
+		}
+	}, chat.Name)
+	if name == "" {
+		name = "chat"
+	}
+	return fmt.Sprintf("%s-%s%s", name, at.Format("20060102-150405"), format.Extension())
+}
+
+// DefaultDir returns the directory exports from the terminal UI are written to
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".rag-terminal", "exports"), nil
+}
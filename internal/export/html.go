@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown renders message content. Raw HTML in messages is not passed through,
+// so a transcript can't inject markup into the page.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// htmlMessage is a transcript entry with its content rendered to HTML
+type htmlMessage struct {
+	Message
+	HTML template.HTML
+}
+
+var htmlTemplate = template.Must(template.New("chat").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Chat.Name}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; color: #222; line-height: 1.5; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1.5rem; }
+.meta { color: #666; font-size: 0.9rem; }
+.message { border-radius: 8px; padding: 0.75rem 1rem; margin: 1rem 0; }
+.user { background: #eef4ff; }
+.assistant { background: #f6f6f6; }
+.speaker { font-weight: 600; }
+.time, .usage { color: #888; font-size: 0.8rem; }
+pre { background: #272822; color: #f8f8f2; padding: 0.75rem; border-radius: 6px; overflow-x: auto; }
+code { font-family: "SFMono-Regular", Consolas, monospace; font-size: 0.9em; }
+blockquote { border-left: 3px solid #ccc; margin: 0; padding-left: 1rem; color: #555; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: 0.25rem 0.5rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Chat.Name}}</h1>
+<p class="meta">Created {{.Chat.CreatedAt.Format "2006-01-02 15:04"}} · Exported {{.ExportedAt.Format "2006-01-02 15:04"}} ·
+Temperature {{printf "%.1f" .Chat.Temperature}} · TopK {{.Chat.TopK}} · Context window {{.Chat.ContextWindow}}{{if .Usage.Responses}} ·
+{{.Usage.PromptTokens}} prompt + {{.Usage.CompletionTokens}} completion tokens{{end}}</p>
+{{if .Chat.SystemPrompt}}<blockquote>{{.Chat.SystemPrompt}}</blockquote>{{end}}
+</header>
+{{with .Summary}}{{if .Content}}<h2>Summary</h2>
+<p>{{.Content}}</p>
+{{end}}{{end}}<main>
+{{range .Transcript}}<section class="message {{.Role}}">
+<div><span class="speaker">{{if eq .Role "user"}}You{{else}}Assistant{{end}}</span> <span class="time">{{.Timestamp.Format "2006-01-02 15:04"}}</span></div>
+{{.HTML}}
+{{with .Usage}}<div class="usage">{{.PromptTokens}} prompt + {{.CompletionTokens}} completion tokens{{if not .Reported}} (estimated){{end}}</div>{{end}}
+</section>
+{{end}}</main>
+{{if .Documents}}<h2>Documents</h2>
+<ul>
+{{range .Documents}}<li><code>{{.FilePath}}</code> ({{.ChunkCount}} chunks)</li>
+{{end}}</ul>
+{{end}}{{if .Facts}}<h2>Facts</h2>
+<ul>
+{{range .Facts}}<li><strong>{{.Key}}</strong>: {{.Value}} <span class="meta">({{.Source}}, confidence {{printf "%.2f" .Confidence}})</span></li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+// writeHTML writes a self-contained page: styles are inline and nothing is loaded from elsewhere
+func writeHTML(w io.Writer, b *Bundle) error {
+	var transcript []htmlMessage
+	for _, msg := range b.Transcript() {
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(msg.Content), &buf); err != nil {
+			return fmt.Errorf("failed to render message %s: %w", msg.ID, err)
+		}
+		transcript = append(transcript, htmlMessage{Message: msg, HTML: template.HTML(buf.String())})
+	}
+
+	data := struct {
+		*Bundle
+		Transcript []htmlMessage
+	}{b, transcript}
+
+	if err := htmlTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render html: %w", err)
+	}
+	return nil
+}
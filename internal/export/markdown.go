@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeMarkdown writes the summary and a readable transcript followed by the documents and facts of the chat
+func writeMarkdown(w io.Writer, b *Bundle) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "# %s\n\n", b.Chat.Name)
+	fmt.Fprintf(out, "- Created: %s\n", b.Chat.CreatedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(out, "- Exported: %s\n", b.ExportedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(out, "- Temperature: %.1f | TopK: %d | Context window: %d | Max tokens: %d\n",
+		b.Chat.Temperature, b.Chat.TopK, b.Chat.ContextWindow, b.Chat.MaxTokens)
+	if b.Usage.Responses > 0 {
+		fmt.Fprintf(out, "- Tokens: %d prompt + %d completion over %d responses\n",
+			b.Usage.PromptTokens, b.Usage.CompletionTokens, b.Usage.Responses)
+	}
+	if b.Chat.SystemPrompt != "" {
+		fmt.Fprintf(out, "\n**System prompt:**\n\n%s\n", quote(b.Chat.SystemPrompt))
+	}
+
+	if b.Summary != nil && b.Summary.Content != "" {
+		fmt.Fprintf(out, "\n## Summary\n\n%s\n", b.Summary.Content)
+	}
+
+	fmt.Fprint(out, "\n## Conversation\n")
+	for _, msg := range b.Transcript() {
+		speaker := "You"
+		if msg.Role == "assistant" {
+			speaker = "Assistant"
+		}
+		fmt.Fprintf(out, "\n### %s · %s\n\n%s\n", speaker, msg.Timestamp.Format("2006-01-02 15:04"), msg.Content)
+	}
+
+	if len(b.Documents) > 0 {
+		fmt.Fprint(out, "\n## Documents\n\n")
+		for _, doc := range b.Documents {
+			fmt.Fprintf(out, "- `%s` (%d chunks)\n", doc.FilePath, doc.ChunkCount)
+		}
+	}
+
+	if len(b.Facts) > 0 {
+		fmt.Fprint(out, "\n## Facts\n\n")
+		for _, fact := range b.Facts {
+			fmt.Fprintf(out, "- **%s**: %s (%s, confidence %.2f)\n", fact.Key, fact.Value, fact.Source, fact.Confidence)
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write markdown: %w", err)
+	}
+	return nil
+}
+
+// quote formats text as a Markdown block quote
+func quote(text string) string {
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
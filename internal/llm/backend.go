@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// Backend is a local inference runtime serving chat, embedding and reranking models
+type Backend interface {
+	// ChatCompletion streams the response tokens. The error channel receives at most one error
+	// and is closed together with the token channel when the response is complete.
+	ChatCompletion(ctx context.Context, req ChatCompletionRequest) (<-chan string, <-chan error, error)
+
+	// ChatCompletionSync returns the complete response
+	ChatCompletionSync(ctx context.Context, req ChatCompletionRequest) (string, error)
+
+	// GenerateEmbeddings returns one embedding per text, in input order
+	GenerateEmbeddings(ctx context.Context, model string, texts []string, dimensions *int) ([][]float32, error)
+
+	// Rerank returns one relevance score per document, in input order
+	Rerank(ctx context.Context, req RerankingRequest) ([]float64, error)
+
+	// GetModels lists the installed models
+	GetModels() ([]Model, error)
+
+	// Ping checks that the server is reachable
+	Ping(ctx context.Context) error
+}
+
+// Model types reported by GetModels
+const (
+	ModelTypeTextGeneration = "text-generation"
+	ModelTypeEmbeddings     = "embeddings"
+	ModelTypeReranking      = "reranking"
+)
+
+type Model struct {
+	Name      string
+	Type      string
+	Location  string
+	IsRunning bool
+}
+
+type ChatCompletionRequest struct {
+	Model             string        `json:"model"`
+	Messages          []ChatMessage `json:"messages"`
+	Stream            bool          `json:"stream"`
+	Temperature       float64       `json:"temperature,omitempty"`
+	MaxTokens         int           `json:"max_tokens,omitempty"`
+	TopP              float64       `json:"top_p,omitempty"`
+	TopK              int           `json:"top_k,omitempty"`
+	Nctx              int           `json:"nctx,omitempty"`
+	MinP              float64       `json:"min_p,omitempty"`
+	RepetitionPenalty float64       `json:"repetition_penalty,omitempty"`
+	PresencePenalty   float64       `json:"presence_penalty,omitempty"`
+	FrequencyPenalty  float64       `json:"frequency_penalty,omitempty"`
+	Stop              interface{}   `json:"stop,omitempty"` // string or []string
+
+	// OnUsage receives the token counts the server reported for this completion. It is called
+	// before the stream channels close, or before ChatCompletionSync returns, and not at all
+	// when the server doesn't report usage.
+	OnUsage func(Usage) `json:"-"`
+}
+
+// Usage is the token count of one completion as reported by the server
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type RerankingRequest struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	BatchSize       int      `json:"batch_size,omitempty"`
+	Normalize       bool     `json:"normalize,omitempty"`
+	NormalizeMethod string   `json:"normalize_method,omitempty"`
+}
+
+// GuessModelType infers the model type from its name and family, for runtimes that
+// don't report what a model is for
+func GuessModelType(name, family string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "rerank"):
+		return ModelTypeReranking
+	case strings.Contains(lower, "embed"),
+		strings.Contains(lower, "minilm"),
+		strings.Contains(lower, "bge-"),
+		strings.Contains(lower, "e5-"),
+		strings.Contains(strings.ToLower(family), "bert"):
+		return ModelTypeEmbeddings
+	default:
+		return ModelTypeTextGeneration
+	}
+}
+
+// StopSequences normalizes the Stop field of a request to a list
+func StopSequences(stop interface{}) []string {
+	switch s := stop.(type) {
+	case string:
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	case []string:
+		return s
+	case []interface{}:
+		var result []string
+		for _, v := range s {
+			if str, ok := v.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
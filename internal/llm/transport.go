@@ -0,0 +1,249 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rag-terminal/internal/logging"
+)
+
+// Operation names the kind of backend request, selecting its retry policy
+type Operation string
+
+const (
+	OpChat       Operation = "chat"
+	OpEmbeddings Operation = "embeddings"
+	OpRerank     Operation = "rerank"
+	OpModels     Operation = "models"
+)
+
+// RetryPolicy controls how often a failed request is attempted. Backoff doubles after
+// every attempt, starting at InitialBackoff and capped at MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts    int // 1 disables retries
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// TransportOptions configures the HTTP transport shared by all backends
+type TransportOptions struct {
+	// ConnectTimeout bounds establishing the connection
+	ConnectTimeout time.Duration
+
+	// ReadTimeout bounds every wait for data: the response headers and each read of the body.
+	// Streams may run longer than ReadTimeout as long as tokens keep arriving.
+	ReadTimeout time.Duration
+
+	// Retry holds the policy per operation; operations without one are attempted once
+	Retry map[Operation]RetryPolicy
+}
+
+// DefaultTransportOptions returns the timeouts used when none are configured
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		ConnectTimeout: 10 * time.Second,
+		ReadTimeout:    5 * time.Minute,
+	}
+}
+
+// Transport sends JSON requests to a backend server, applying timeouts and retries.
+// Every request gets an ID that is sent as X-Request-ID and prefixes its log lines.
+type Transport struct {
+	baseURL    string
+	headers    map[string]string
+	opts       TransportOptions
+	httpClient *http.Client
+}
+
+// NewTransport creates a transport for baseURL. headers are added to every request.
+func NewTransport(baseURL string, headers map[string]string, opts TransportOptions) *Transport {
+	defaults := DefaultTransportOptions()
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = defaults.ReadTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: opts.ConnectTimeout}).DialContext
+	transport.ResponseHeaderTimeout = opts.ReadTimeout
+
+	return &Transport{
+		baseURL:    baseURL,
+		headers:    headers,
+		opts:       opts,
+		httpClient: &http.Client{Transport: transport},
+	}
+}
+
+// Do sends the request, retrying transient failures according to the policy for op.
+// The caller must close the response body.
+func (t *Transport) Do(ctx context.Context, op Operation, method, endpoint string, body interface{}) (*http.Response, error) {
+	var jsonData []byte
+	if body != nil {
+		var err error
+		jsonData, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	policy, ok := t.opts.Retry[op]
+	if !ok || policy.MaxAttempts < 1 {
+		policy = RetryPolicy{MaxAttempts: 1}
+	}
+
+	requestID := uuid.New().String()[:8]
+	backoff := policy.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		resp, err := t.send(ctx, requestID, method, endpoint, jsonData)
+
+		retryable := false
+		if err != nil {
+			retryable = ctx.Err() == nil && isTransientError(err)
+		} else if isTransientStatus(resp.StatusCode) {
+			retryable = true
+		}
+
+		if !retryable || attempt >= policy.MaxAttempts {
+			if err != nil {
+				logging.Error("[%s] %s %s failed after %d attempt(s): %v", requestID, method, endpoint, attempt, err)
+				return nil, fmt.Errorf("request %s failed: %w", requestID, err)
+			}
+			logging.Debug("[%s] %s %s -> %d in %v", requestID, method, endpoint, resp.StatusCode, time.Since(start))
+			return resp, nil
+		}
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = fmt.Sprintf("status %d", resp.StatusCode)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		logging.Info("[%s] %s %s attempt %d/%d failed (%s), retrying in %v", requestID, method, endpoint, attempt, policy.MaxAttempts, reason, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request %s cancelled while retrying: %w", requestID, ctx.Err())
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// send makes one attempt. The response body cancels the request when no data arrives within ReadTimeout.
+func (t *Transport) send(ctx context.Context, requestID, method, endpoint string, jsonData []byte) (*http.Response, error) {
+	var reqBody io.Reader
+	if jsonData != nil {
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(reqCtx, method, t.baseURL+endpoint, reqBody)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if jsonData != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-Request-ID", requestID)
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	resp.Body = newIdleTimeoutBody(resp.Body, t.opts.ReadTimeout, cancel)
+	return resp, nil
+}
+
+// isTransientError reports whether a failed attempt may succeed when repeated:
+// refused or reset connections and timeouts
+func isTransientError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// isTransientStatus reports whether the server signaled a temporary condition
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// idleTimeoutBody cancels its request when no read completes within the timeout
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	cancel   context.CancelFunc
+	timedOut atomic.Bool
+	once     sync.Once
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutBody {
+	b := &idleTimeoutBody{
+		ReadCloser: body,
+		timeout:    timeout,
+		cancel:     cancel,
+	}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.timedOut.Store(true)
+		cancel()
+	})
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.timedOut.Load() {
+		return n, fmt.Errorf("no data received within %v: %w", b.timeout, err)
+	}
+	b.timer.Reset(b.timeout)
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.timer.Stop()
+		b.cancel()
+	})
+	return err
+}
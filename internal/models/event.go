@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+
+	"rag-terminal/internal/vector"
+)
+
+// EventType tells which fields of an Event are set
+type EventType int
+
+const (
+	EventToken    EventType = iota // Token: the next piece of the answer
+	EventProgress                  // Done, Total: items processed so far, e.g. embedded files
+	EventSources                   // Sources: document excerpts the answer was given to cite
+	EventStage                     // Stage: the step that just started
+	EventUsage                     // Usage: token counts of the finished answer
+	EventWarning                   // Message: a problem that didn't stop the operation
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventToken:
+		return "token"
+	case EventProgress:
+		return "progress"
+	case EventSources:
+		return "sources"
+	case EventStage:
+		return "stage"
+	case EventUsage:
+		return "usage"
+	case EventWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Stage is a step of answering a message or loading documents
+type Stage string
+
+const (
+	StageRewriting  Stage = "rewriting" // Turning the message into standalone search queries
+	StageEmbedding  Stage = "embedding"
+	StageRetrieving Stage = "retrieving"
+	StageReranking  Stage = "reranking"
+	StageGenerating Stage = "generating"
+)
+
+// Event is one item of the stream produced by the pipeline and the document loaders
+type Event struct {
+	Type    EventType
+	Token   string
+	Done    int
+	Total   int
+	Sources []vector.Source
+	Stage   Stage
+	Usage   *vector.TokenUsage
+	Message string
+}
+
+func TokenEvent(token string) Event {
+	return Event{Type: EventToken, Token: token}
+}
+
+func ProgressEvent(done, total int) Event {
+	return Event{Type: EventProgress, Done: done, Total: total}
+}
+
+func SourcesEvent(sources []vector.Source) Event {
+	return Event{Type: EventSources, Sources: sources}
+}
+
+func StageEvent(stage Stage) Event {
+	return Event{Type: EventStage, Stage: stage}
+}
+
+func UsageEvent(usage vector.TokenUsage) Event {
+	return Event{Type: EventUsage, Usage: &usage}
+}
+
+func WarningEvent(format string, args ...any) Event {
+	return Event{Type: EventWarning, Message: fmt.Sprintf(format, args...)}
+}
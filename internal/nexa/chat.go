@@ -7,27 +7,18 @@ import (
 	"fmt"
 	"io"
 	"strings"
+
+	"rag-terminal/internal/llm"
 )
 
-type ChatCompletionRequest struct {
-	Model              string        `json:"model"`
-	Messages           []ChatMessage `json:"messages"`
-	Stream             bool          `json:"stream"`
-	Temperature        float64       `json:"temperature,omitempty"`
-	MaxTokens          int           `json:"max_tokens,omitempty"`
-	TopP               float64       `json:"top_p,omitempty"`
-	TopK               int           `json:"top_k,omitempty"`
-	Nctx               int           `json:"nctx,omitempty"`
-	MinP               float64       `json:"min_p,omitempty"`
-	RepetitionPenalty  float64       `json:"repetition_penalty,omitempty"`
-	PresencePenalty    float64       `json:"presence_penalty,omitempty"`
-	FrequencyPenalty   float64       `json:"frequency_penalty,omitempty"`
-	Stop               interface{}   `json:"stop,omitempty"` // string or []string
+// chatRequest asks streaming responses to end with a usage chunk
+type chatRequest struct {
+	llm.ChatCompletionRequest
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
 }
 
-type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type ChatCompletionResponse struct {
@@ -36,22 +27,21 @@ type ChatCompletionResponse struct {
 	Created int64  `json:"created"`
 	Model   string `json:"model"`
 	Choices []struct {
-		Index        int         `json:"index"`
-		Message      ChatMessage `json:"message,omitempty"`
-		Delta        ChatMessage `json:"delta,omitempty"`
-		FinishReason string      `json:"finish_reason"`
+		Index        int             `json:"index"`
+		Message      llm.ChatMessage `json:"message,omitempty"`
+		Delta        llm.ChatMessage `json:"delta,omitempty"`
+		FinishReason string          `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage,omitempty"`
+	Usage *llm.Usage `json:"usage,omitempty"`
 }
 
-func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest) (<-chan string, <-chan error, error) {
+func (c *Client) ChatCompletion(ctx context.Context, req llm.ChatCompletionRequest) (<-chan string, <-chan error, error) {
 	req.Stream = true
 
-	resp, err := c.doRequest(ctx, "POST", "/v1/chat/completions", req)
+	resp, err := c.doRequest(ctx, llm.OpChat, "POST", "/v1/chat/completions", chatRequest{
+		ChatCompletionRequest: req,
+		StreamOptions:         &streamOptions{IncludeUsage: true},
+	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to make chat completion request: %w", err)
 	}
@@ -70,6 +60,15 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 		defer close(streamChan)
 		defer close(errChan)
 
+		// Usage arrives with the last choice or in a chunk of its own after it;
+		// report it before the channels close
+		var usage *llm.Usage
+		defer func() {
+			if usage != nil && req.OnUsage != nil {
+				req.OnUsage(*usage)
+			}
+		}()
+
 		reader := bufio.NewReader(resp.Body)
 		for {
 			line, err := reader.ReadString('\n')
@@ -81,25 +80,25 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 			}
 
 			line = strings.TrimSpace(line)
-			if line == "" {
+			if !strings.HasPrefix(line, "data:") {
 				continue
 			}
 
-			if line == "data:[DONE]" {
+			jsonData := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if jsonData == "[DONE]" {
 				return
 			}
 
-			if !strings.HasPrefix(line, "data:") {
-				continue
-			}
-
-			jsonData := strings.TrimPrefix(line, "data:")
 			var streamResp ChatCompletionResponse
 			if err := json.Unmarshal([]byte(jsonData), &streamResp); err != nil {
 				errChan <- fmt.Errorf("failed to decode stream response: %w", err)
 				return
 			}
 
+			if streamResp.Usage != nil {
+				usage = streamResp.Usage
+			}
+
 			if len(streamResp.Choices) > 0 {
 				delta := streamResp.Choices[0].Delta.Content
 				if delta != "" {
@@ -110,7 +109,8 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 					}
 				}
 
-				if streamResp.Choices[0].FinishReason != "" {
+				// Keep reading after the last choice only while a usage chunk may still follow
+				if streamResp.Choices[0].FinishReason != "" && usage != nil {
 					return
 				}
 			}
@@ -120,10 +120,10 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 	return streamChan, errChan, nil
 }
 
-func (c *Client) ChatCompletionSync(ctx context.Context, req ChatCompletionRequest) (string, error) {
+func (c *Client) ChatCompletionSync(ctx context.Context, req llm.ChatCompletionRequest) (string, error) {
 	req.Stream = false
 
-	resp, err := c.doRequest(ctx, "POST", "/v1/chat/completions", req)
+	resp, err := c.doRequest(ctx, llm.OpChat, "POST", "/v1/chat/completions", req)
 	if err != nil {
 		return "", fmt.Errorf("failed to make chat completion request: %w", err)
 	}
@@ -143,5 +143,9 @@ func (c *Client) ChatCompletionSync(ctx context.Context, req ChatCompletionReque
 		return "", fmt.Errorf("no choices returned in chat completion response")
 	}
 
+	if completionResp.Usage != nil && req.OnUsage != nil {
+		req.OnUsage(*completionResp.Usage)
+	}
+
 	return completionResp.Choices[0].Message.Content, nil
 }
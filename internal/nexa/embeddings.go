@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+
+	"rag-terminal/internal/llm"
 )
 
 type EmbeddingRequest struct {
@@ -36,7 +38,7 @@ func (c *Client) GenerateEmbeddings(ctx context.Context, model string, texts []s
 		Dimensions: dimensions,
 	}
 
-	resp, err := c.doRequest(ctx, "POST", "/v1/embeddings", req)
+	resp, err := c.doRequest(ctx, llm.OpEmbeddings, "POST", "/v1/embeddings", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make embeddings request: %w", err)
 	}
@@ -0,0 +1,219 @@
+package nexa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"rag-terminal/internal/llm"
+)
+
+// modelListTimeout bounds model discovery, which runs before the UI starts
+const modelListTimeout = 10 * time.Second
+
+// modelsResponse is the /v1/models listing. Besides the OpenAI fields, Nexa reports
+// the model type, where it is stored and whether it is loaded; older servers omit them.
+type modelsResponse struct {
+	Data []struct {
+		ID        string `json:"id"`
+		Type      string `json:"type"`
+		ModelType string `json:"model_type"`
+		Location  string `json:"location"`
+		Path      string `json:"path"`
+		Loaded    bool   `json:"loaded"`
+		Running   bool   `json:"running"`
+	} `json:"data"`
+}
+
+// GetModels lists installed models from the server's models endpoint. Types the server
+// doesn't report are taken from `nexa list`, and if the server can't be reached the
+// CLI listing is used on its own.
+func (c *Client) GetModels() ([]llm.Model, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), modelListTimeout)
+	defer cancel()
+
+	models, serverErr := c.listServerModels(ctx)
+	if serverErr != nil {
+		cliModels, cliErr := listCLIModels(ctx)
+		if cliErr != nil {
+			return nil, fmt.Errorf("failed to list models from server (%v) and CLI: %w", serverErr, cliErr)
+		}
+		return cliModels, nil
+	}
+
+	// Only consult the CLI when the server left some types out
+	var cliTypes map[string]string
+	for i := range models {
+		if models[i].Type != "" {
+			continue
+		}
+
+		if cliTypes == nil {
+			cliTypes = make(map[string]string)
+			if cliModels, err := listCLIModels(ctx); err == nil {
+				for _, m := range cliModels {
+					cliTypes[m.Name] = m.Type
+				}
+			}
+		}
+
+		if modelType, ok := cliTypes[models[i].Name]; ok {
+			models[i].Type = modelType
+		} else {
+			models[i].Type = llm.GuessModelType(models[i].Name, "")
+		}
+	}
+
+	return models, nil
+}
+
+// listServerModels queries /v1/models. Models without a reported type are returned with an empty Type.
+func (c *Client) listServerModels(ctx context.Context) ([]llm.Model, error) {
+	resp, err := c.doRequest(ctx, llm.OpModels, "GET", "/v1/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make models request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("models API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var modelsResp modelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode models response: %w", err)
+	}
+
+	models := make([]llm.Model, 0, len(modelsResp.Data))
+	for _, entry := range modelsResp.Data {
+		if entry.ID == "" {
+			continue
+		}
+
+		modelType := entry.Type
+		if modelType == "" {
+			modelType = entry.ModelType
+		}
+		location := entry.Location
+		if location == "" {
+			location = entry.Path
+		}
+
+		models = append(models, llm.Model{
+			Name:      entry.ID,
+			Type:      mapModelType(modelType),
+			Location:  location,
+			IsRunning: entry.Loaded || entry.Running,
+		})
+	}
+
+	return models, nil
+}
+
+// listCLIModels runs `nexa list --verbose` and parses its table
+func listCLIModels(ctx context.Context) ([]llm.Model, error) {
+	cmd := exec.CommandContext(ctx, "nexa", "list", "--verbose")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute nexa list: %w", err)
+	}
+
+	return parseModelList(string(output)), nil
+}
+
+// mapModelType maps Nexa model types to our internal types. Unknown types are kept as is,
+// so they don't show up in any model selection list.
+func mapModelType(nexaType string) string {
+	switch strings.ToLower(nexaType) {
+	case "":
+		return ""
+	case "llm", llm.ModelTypeTextGeneration:
+		return llm.ModelTypeTextGeneration
+	case "embedder", llm.ModelTypeEmbeddings:
+		return llm.ModelTypeEmbeddings
+	case "reranker", llm.ModelTypeReranking:
+		return llm.ModelTypeReranking
+	default:
+		return nexaType
+	}
+}
+
+// ansiEscape matches terminal color sequences in CLI output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// parseModelList reads the model table printed by `nexa list`. Columns are located by the
+// NAME and TYPE headers, so added or reordered columns and ASCII (|, +) borders are handled.
+// Tables without a TYPE column get types guessed from the model names.
+func parseModelList(output string) []llm.Model {
+	var models []llm.Model
+	nameCol, typeCol := -1, -1
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(ansiEscape.ReplaceAllString(line, ""))
+
+		// Only rows with cell separators belong to the table; borders and log output are skipped
+		if !strings.HasPrefix(line, "│") && !strings.HasPrefix(line, "|") {
+			continue
+		}
+
+		cells := splitRow(line)
+
+		if nameCol < 0 {
+			for i, cell := range cells {
+				switch strings.ToUpper(cell) {
+				case "NAME":
+					nameCol = i
+				case "TYPE":
+					typeCol = i
+				}
+			}
+			if nameCol < 0 {
+				typeCol = -1
+			}
+			continue
+		}
+
+		if nameCol >= len(cells) || typeCol >= len(cells) {
+			continue
+		}
+
+		name := cells[nameCol]
+		if name == "" {
+			continue
+		}
+
+		modelType := llm.GuessModelType(name, "")
+		if typeCol >= 0 {
+			if cells[typeCol] == "" {
+				continue
+			}
+			modelType = mapModelType(cells[typeCol])
+		}
+
+		models = append(models, llm.Model{
+			Name: name,
+			Type: modelType,
+		})
+	}
+
+	return models
+}
+
+// splitRow returns the trimmed cells of a table row
+func splitRow(line string) []string {
+	line = strings.ReplaceAll(line, "│", "|")
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
@@ -0,0 +1,139 @@
+package nexa
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rag-terminal/internal/llm"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the model list tests")
+
+// formatModels renders parsed models one per line as the golden files store them
+func formatModels(models []llm.Model) string {
+	var b strings.Builder
+	for _, m := range models {
+		fmt.Fprintf(&b, "%s\t%s\n", m.Name, m.Type)
+	}
+	return b.String()
+}
+
+func TestParseModelList(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+	}{
+		{"box-drawn table", "box"},
+		{"plain ASCII table with log output", "ascii"},
+		{"reordered and added columns", "reordered"},
+		{"missing TYPE column", "missing_type"},
+		{"ANSI-colored table", "ansi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", tt.fixture+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := formatModels(parseModelList(string(input)))
+
+			golden := filepath.Join("testdata", tt.fixture+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("parseModelList(%s) =\n%s\nwant\n%s", tt.fixture, got, want)
+			}
+		})
+	}
+}
+
+func TestParseModelListWithoutTable(t *testing.T) {
+	if models := parseModelList("No models found.\n"); len(models) != 0 {
+		t.Errorf("parseModelList returned %v for output without a table", models)
+	}
+}
+
+func TestGetModelsFromServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[
+			{"id":"NexaAI/Qwen3-4B-GGUF","type":"llm","location":"/models/qwen","loaded":true},
+			{"id":"NexaAI/embeddinggemma-300m-npu","model_type":"embedder","path":"/models/gemma"},
+			{"id":"NexaAI/jina-v2-rerank-npu","type":"reranker","running":true},
+			{"id":""}
+		]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, llm.DefaultTransportOptions())
+	models, err := client.GetModels()
+	if err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	want := []llm.Model{
+		{Name: "NexaAI/Qwen3-4B-GGUF", Type: llm.ModelTypeTextGeneration, Location: "/models/qwen", IsRunning: true},
+		{Name: "NexaAI/embeddinggemma-300m-npu", Type: llm.ModelTypeEmbeddings, Location: "/models/gemma"},
+		{Name: "NexaAI/jina-v2-rerank-npu", Type: llm.ModelTypeReranking, IsRunning: true},
+	}
+	if !reflect.DeepEqual(models, want) {
+		t.Errorf("GetModels() = %+v, want %+v", models, want)
+	}
+}
+
+func TestGetModelsGuessesMissingTypes(t *testing.T) {
+	// Keep the CLI fallback from finding a local nexa binary
+	t.Setenv("PATH", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"nomic-embed-text"},{"id":"llama3.2"}]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, llm.DefaultTransportOptions())
+	models, err := client.GetModels()
+	if err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	want := []llm.Model{
+		{Name: "nomic-embed-text", Type: llm.ModelTypeEmbeddings},
+		{Name: "llama3.2", Type: llm.ModelTypeTextGeneration},
+	}
+	if !reflect.DeepEqual(models, want) {
+		t.Errorf("GetModels() = %+v, want %+v", models, want)
+	}
+}
+
+func TestGetModelsServerError(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, llm.DefaultTransportOptions())
+	if _, err := client.GetModels(); err == nil {
+		t.Error("GetModels succeeded without server and CLI")
+	}
+}
@@ -5,23 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-)
 
-type RerankingRequest struct {
-	Model           string   `json:"model"`
-	Query           string   `json:"query"`
-	Documents       []string `json:"documents"`
-	BatchSize       int      `json:"batch_size,omitempty"`
-	Normalize       bool     `json:"normalize,omitempty"`
-	NormalizeMethod string   `json:"normalize_method,omitempty"`
-}
+	"rag-terminal/internal/llm"
+)
 
 type RerankingResponse struct {
 	Result []float64 `json:"result"`
 	Model  string    `json:"model"`
 }
 
-func (c *Client) Rerank(ctx context.Context, req RerankingRequest) ([]float64, error) {
+func (c *Client) Rerank(ctx context.Context, req llm.RerankingRequest) ([]float64, error) {
 	if len(req.Documents) == 0 {
 		return nil, fmt.Errorf("no documents provided for reranking")
 	}
@@ -34,7 +27,7 @@ func (c *Client) Rerank(ctx context.Context, req RerankingRequest) ([]float64, e
 		req.NormalizeMethod = "softmax"
 	}
 
-	resp, err := c.doRequest(ctx, "POST", "/v1/reranking", req)
+	resp, err := c.doRequest(ctx, llm.OpRerank, "POST", "/v1/reranking", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make reranking request: %w", err)
 	}
@@ -0,0 +1,162 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"rag-terminal/internal/llm"
+)
+
+type chatRequest struct {
+	Model    string            `json:"model"`
+	Messages []llm.ChatMessage `json:"messages"`
+	Stream   bool              `json:"stream"`
+	Options  chatOptions       `json:"options"`
+}
+
+// chatOptions are the sampling parameters in Ollama's naming
+type chatOptions struct {
+	Temperature      float64  `json:"temperature,omitempty"`
+	NumPredict       int      `json:"num_predict,omitempty"`
+	TopP             float64  `json:"top_p,omitempty"`
+	TopK             int      `json:"top_k,omitempty"`
+	NumCtx           int      `json:"num_ctx,omitempty"`
+	MinP             float64  `json:"min_p,omitempty"`
+	RepeatPenalty    float64  `json:"repeat_penalty,omitempty"`
+	PresencePenalty  float64  `json:"presence_penalty,omitempty"`
+	FrequencyPenalty float64  `json:"frequency_penalty,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+}
+
+// chatResponse is one line of a streamed response, or the whole response when not streaming.
+// Token counts are set on the final line.
+type chatResponse struct {
+	Message         llm.ChatMessage `json:"message"`
+	Done            bool            `json:"done"`
+	Error           string          `json:"error,omitempty"`
+	PromptEvalCount int             `json:"prompt_eval_count"`
+	EvalCount       int             `json:"eval_count"`
+}
+
+// reportUsage passes the token counts of a final response to the request's usage callback
+func reportUsage(req llm.ChatCompletionRequest, resp chatResponse) {
+	if req.OnUsage == nil || (resp.PromptEvalCount == 0 && resp.EvalCount == 0) {
+		return
+	}
+	req.OnUsage(llm.Usage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	})
+}
+
+func newChatRequest(req llm.ChatCompletionRequest, stream bool) chatRequest {
+	return chatRequest{
+		Model:    req.Model,
+		Messages: req.Messages,
+		Stream:   stream,
+		Options: chatOptions{
+			Temperature:      req.Temperature,
+			NumPredict:       req.MaxTokens,
+			TopP:             req.TopP,
+			TopK:             req.TopK,
+			NumCtx:           req.Nctx,
+			MinP:             req.MinP,
+			RepeatPenalty:    req.RepetitionPenalty,
+			PresencePenalty:  req.PresencePenalty,
+			FrequencyPenalty: req.FrequencyPenalty,
+			Stop:             llm.StopSequences(req.Stop),
+		},
+	}
+}
+
+func (c *Client) ChatCompletion(ctx context.Context, req llm.ChatCompletionRequest) (<-chan string, <-chan error, error) {
+	resp, err := c.doRequest(ctx, llm.OpChat, "POST", "/api/chat", newChatRequest(req, true))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to make chat request: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("chat API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	streamChan := make(chan string, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer resp.Body.Close()
+		defer close(streamChan)
+		defer close(errChan)
+
+		// Ollama streams newline-delimited JSON objects
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+
+			var streamResp chatResponse
+			if err := json.Unmarshal(line, &streamResp); err != nil {
+				errChan <- fmt.Errorf("failed to decode stream response: %w", err)
+				return
+			}
+
+			if streamResp.Error != "" {
+				errChan <- fmt.Errorf("chat API error: %s", streamResp.Error)
+				return
+			}
+
+			if streamResp.Message.Content != "" {
+				select {
+				case streamChan <- streamResp.Message.Content:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if streamResp.Done {
+				reportUsage(req, streamResp)
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			errChan <- fmt.Errorf("error reading stream: %w", err)
+		}
+	}()
+
+	return streamChan, errChan, nil
+}
+
+func (c *Client) ChatCompletionSync(ctx context.Context, req llm.ChatCompletionRequest) (string, error) {
+	resp, err := c.doRequest(ctx, llm.OpChat, "POST", "/api/chat", newChatRequest(req, false))
+	if err != nil {
+		return "", fmt.Errorf("failed to make chat request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("chat API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("failed to decode chat response: %w", err)
+	}
+
+	if chatResp.Error != "" {
+		return "", fmt.Errorf("chat API error: %s", chatResp.Error)
+	}
+
+	reportUsage(req, chatResp)
+
+	return chatResp.Message.Content, nil
+}
@@ -0,0 +1,94 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"rag-terminal/internal/llm"
+)
+
+var _ llm.Backend = (*Client)(nil)
+
+// DefaultBaseURL is used when no base URL is configured
+const DefaultBaseURL = "http://127.0.0.1:11434"
+
+// Client talks to an Ollama server through its native API
+type Client struct {
+	transport *llm.Transport
+}
+
+func NewClient(baseURL string, opts llm.TransportOptions) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		transport: llm.NewTransport(baseURL, nil, opts),
+	}
+}
+
+type tagsResponse struct {
+	Models []struct {
+		Name    string `json:"name"`
+		Model   string `json:"model"`
+		Details struct {
+			Family   string   `json:"family"`
+			Families []string `json:"families"`
+		} `json:"details"`
+	} `json:"models"`
+}
+
+// GetModels lists local models from /api/tags. Ollama doesn't report what a model is for,
+// so the type is guessed from its name and family.
+func (c *Client) GetModels() ([]llm.Model, error) {
+	resp, err := c.doRequest(context.Background(), llm.OpModels, "GET", "/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("tags API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tags tagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags response: %w", err)
+	}
+
+	models := make([]llm.Model, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, llm.Model{
+			Name: m.Name,
+			Type: llm.GuessModelType(m.Name, m.Details.Family),
+		})
+	}
+
+	return models, nil
+}
+
+// Rerank is not offered by Ollama; callers fall back to their non-model ranking
+func (c *Client) Rerank(ctx context.Context, req llm.RerankingRequest) ([]float64, error) {
+	return nil, fmt.Errorf("reranking is not supported by the Ollama backend")
+}
+
+// Ping checks that the server answers HTTP requests
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, llm.OpModels, "GET", "/api/tags", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, op llm.Operation, method, endpoint string, body interface{}) (*http.Response, error) {
+	return c.transport.Do(ctx, op, method, endpoint, body)
+}
@@ -0,0 +1,55 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"rag-terminal/internal/llm"
+)
+
+type embedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions *int     `json:"dimensions,omitempty"`
+}
+
+type embedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (c *Client) GenerateEmbeddings(ctx context.Context, model string, texts []string, dimensions *int) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided for embedding")
+	}
+
+	req := embedRequest{
+		Model:      model,
+		Input:      texts,
+		Dimensions: dimensions,
+	}
+
+	resp, err := c.doRequest(ctx, llm.OpEmbeddings, "POST", "/api/embed", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make embed request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("embed API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var embedResp embedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embed response: %w", err)
+	}
+
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embed API returned %d embeddings for %d texts", len(embedResp.Embeddings), len(texts))
+	}
+
+	return embedResp.Embeddings, nil
+}
@@ -0,0 +1,175 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"rag-terminal/internal/llm"
+)
+
+// chatRequest carries the standard OpenAI fields plus the sampling extensions
+// llama.cpp and vLLM accept. Nexa's nctx has no equivalent; the server decides the context size.
+type chatRequest struct {
+	Model            string            `json:"model"`
+	Messages         []llm.ChatMessage `json:"messages"`
+	Stream           bool              `json:"stream"`
+	Temperature      float64           `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"max_tokens,omitempty"`
+	TopP             float64           `json:"top_p,omitempty"`
+	TopK             int               `json:"top_k,omitempty"`
+	MinP             float64           `json:"min_p,omitempty"`
+	RepeatPenalty    float64           `json:"repeat_penalty,omitempty"`
+	PresencePenalty  float64           `json:"presence_penalty,omitempty"`
+	FrequencyPenalty float64           `json:"frequency_penalty,omitempty"`
+	Stop             []string          `json:"stop,omitempty"`
+	StreamOptions    *streamOptions    `json:"stream_options,omitempty"`
+}
+
+// streamOptions asks streaming responses to end with a usage chunk
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      llm.ChatMessage `json:"message"`
+		Delta        llm.ChatMessage `json:"delta"`
+		FinishReason string          `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *llm.Usage `json:"usage,omitempty"`
+}
+
+func newChatRequest(req llm.ChatCompletionRequest, stream bool) chatRequest {
+	var options *streamOptions
+	if stream {
+		options = &streamOptions{IncludeUsage: true}
+	}
+
+	return chatRequest{
+		Model:            req.Model,
+		Messages:         req.Messages,
+		Stream:           stream,
+		Temperature:      req.Temperature,
+		MaxTokens:        req.MaxTokens,
+		TopP:             req.TopP,
+		TopK:             req.TopK,
+		MinP:             req.MinP,
+		RepeatPenalty:    req.RepetitionPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		Stop:             llm.StopSequences(req.Stop),
+		StreamOptions:    options,
+	}
+}
+
+func (c *Client) ChatCompletion(ctx context.Context, req llm.ChatCompletionRequest) (<-chan string, <-chan error, error) {
+	resp, err := c.doRequest(ctx, llm.OpChat, "POST", "/chat/completions", newChatRequest(req, true))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to make chat completion request: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("chat completion API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	streamChan := make(chan string, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer resp.Body.Close()
+		defer close(streamChan)
+		defer close(errChan)
+
+		// Usage arrives with the last choice or in a chunk of its own after it;
+		// report it before the channels close
+		var usage *llm.Usage
+		defer func() {
+			if usage != nil && req.OnUsage != nil {
+				req.OnUsage(*usage)
+			}
+		}()
+
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					errChan <- fmt.Errorf("error reading stream: %w", err)
+				}
+				return
+			}
+
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+
+			jsonData := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if jsonData == "[DONE]" {
+				return
+			}
+
+			var streamResp chatResponse
+			if err := json.Unmarshal([]byte(jsonData), &streamResp); err != nil {
+				errChan <- fmt.Errorf("failed to decode stream response: %w", err)
+				return
+			}
+
+			if streamResp.Usage != nil {
+				usage = streamResp.Usage
+			}
+
+			if len(streamResp.Choices) > 0 {
+				delta := streamResp.Choices[0].Delta.Content
+				if delta != "" {
+					select {
+					case streamChan <- delta:
+					case <-ctx.Done():
+						return
+					}
+				}
+
+				// Keep reading after the last choice only while a usage chunk may still follow
+				if streamResp.Choices[0].FinishReason != "" && usage != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return streamChan, errChan, nil
+}
+
+func (c *Client) ChatCompletionSync(ctx context.Context, req llm.ChatCompletionRequest) (string, error) {
+	resp, err := c.doRequest(ctx, llm.OpChat, "POST", "/chat/completions", newChatRequest(req, false))
+	if err != nil {
+		return "", fmt.Errorf("failed to make chat completion request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("chat completion API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var completionResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completionResp); err != nil {
+		return "", fmt.Errorf("failed to decode chat completion response: %w", err)
+	}
+
+	if len(completionResp.Choices) == 0 {
+		return "", fmt.Errorf("no choices returned in chat completion response")
+	}
+
+	if completionResp.Usage != nil && req.OnUsage != nil {
+		req.OnUsage(*completionResp.Usage)
+	}
+
+	return completionResp.Choices[0].Message.Content, nil
+}
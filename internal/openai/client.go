@@ -0,0 +1,94 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"rag-terminal/internal/llm"
+)
+
+var _ llm.Backend = (*Client)(nil)
+
+// DefaultBaseURL is used when no base URL is configured
+const DefaultBaseURL = "http://127.0.0.1:8080/v1"
+
+// Client talks to any server implementing the OpenAI API, such as llama.cpp's llama-server,
+// vLLM or LM Studio
+type Client struct {
+	transport *llm.Transport
+}
+
+// NewClient creates a client for baseURL, which includes the /v1 prefix.
+// apiKey is sent as a bearer token when set.
+func NewClient(baseURL, apiKey string, opts llm.TransportOptions) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	var headers map[string]string
+	if apiKey != "" {
+		headers = map[string]string{"Authorization": "Bearer " + apiKey}
+	}
+
+	return &Client{
+		transport: llm.NewTransport(baseURL, headers, opts),
+	}
+}
+
+type modelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// GetModels lists the served models from /models. The API doesn't report what a model
+// is for, so the type is guessed from its name.
+func (c *Client) GetModels() ([]llm.Model, error) {
+	resp, err := c.doRequest(context.Background(), llm.OpModels, "GET", "/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("models API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var modelsResp modelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode models response: %w", err)
+	}
+
+	models := make([]llm.Model, 0, len(modelsResp.Data))
+	for _, m := range modelsResp.Data {
+		models = append(models, llm.Model{
+			Name:      m.ID,
+			Type:      llm.GuessModelType(m.ID, ""),
+			IsRunning: true,
+		})
+	}
+
+	return models, nil
+}
+
+// Ping checks that the server answers HTTP requests
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, llm.OpModels, "GET", "/models", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, op llm.Operation, method, endpoint string, body interface{}) (*http.Response, error) {
+	return c.transport.Do(ctx, op, method, endpoint, body)
+}
@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"rag-terminal/internal/llm"
+)
+
+// rerankRequest is the Jina/Cohere style request served at /rerank by llama.cpp and vLLM
+type rerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (c *Client) Rerank(ctx context.Context, req llm.RerankingRequest) ([]float64, error) {
+	if len(req.Documents) == 0 {
+		return nil, fmt.Errorf("no documents provided for reranking")
+	}
+
+	resp, err := c.doRequest(ctx, llm.OpRerank, "POST", "/rerank", rerankRequest{
+		Model:     req.Model,
+		Query:     req.Query,
+		Documents: req.Documents,
+		TopN:      len(req.Documents),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make reranking request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("reranking API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var rerankResp rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to decode reranking response: %w", err)
+	}
+
+	// Results come sorted by relevance; put the scores back in document order
+	scores := make([]float64, len(req.Documents))
+	for _, result := range rerankResp.Results {
+		if result.Index < 0 || result.Index >= len(scores) {
+			return nil, fmt.Errorf("invalid reranking index %d", result.Index)
+		}
+		scores[result.Index] = result.RelevanceScore
+	}
+
+	return scores, nil
+}
@@ -2,20 +2,21 @@ package rag
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"rag-terminal/internal/config"
 	"rag-terminal/internal/document"
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/logging"
 	"rag-terminal/internal/models"
-	"rag-terminal/internal/nexa"
 	"rag-terminal/internal/vector"
 )
 
 // basePipeline coordinates different pipeline implementations and helper components
 // following the Single Responsibility Principle by delegating specific tasks to focused components
 type basePipeline struct {
-	nexaClient       *nexa.Client
+	backend          llm.Backend
 	vectorStore      vector.VectorStore
 	config           *config.Config
 	documentManager  *document.DocumentManager
@@ -25,13 +26,19 @@ type basePipeline struct {
 
 	// Component helpers - each handles a specific responsibility
 	promptBuilder     *PromptBuilder
+	tokenizers        *tokenizerCache
 	messageProcessor  *MessageProcessor
+	reranker          *Reranker
+	queryRewriter     *QueryRewriter
 	responseProcessor *ResponseProcessor
 	documentProcessor *DocumentProcessor
+	reembedder        *Reembedder
+	summarizer        *ConversationSummarizer
 }
 
-// NewPipeline creates a new pipeline that delegates between simple and RAG modes
-func NewPipeline(nexaClient *nexa.Client, vectorStore vector.VectorStore) *basePipeline {
+// NewPipeline creates a new pipeline that delegates between simple and RAG modes.
+// rerankModel is the optional dedicated reranking model; pass "" to rerank with the LLM.
+func NewPipeline(backend llm.Backend, vectorStore vector.VectorStore, rerankModel string) *basePipeline {
 	// Load config, fallback to default if loading fails
 	cfg, err := config.Load()
 	if err != nil {
@@ -39,20 +46,35 @@ func NewPipeline(nexaClient *nexa.Client, vectorStore vector.VectorStore) *baseP
 		cfg = config.DefaultConfig()
 	}
 
-	profileExtractor := NewProfileExtractor(nexaClient, vectorStore)
+	profileExtractor := NewProfileExtractor(backend, vectorStore)
+	messageProcessor := NewMessageProcessor(vectorStore, backend, cfg)
+
+	// Weigh keyword results as configured; the analyzer is fixed when the store is created
+	if badgerStore, ok := vectorStore.(*vector.BadgerStore); ok {
+		badgerStore.SetFusionWeights(vector.FusionWeights{
+			Vector:  cfg.Retrieval.VectorWeight,
+			Keyword: cfg.Retrieval.KeywordWeight,
+			K:       cfg.Retrieval.RRFConstant,
+		})
+	}
 
 	base := &basePipeline{
-		nexaClient:       nexaClient,
+		backend:          backend,
 		vectorStore:      vectorStore,
 		config:           cfg,
-		documentManager:  document.NewDocumentManager(nexaClient, vectorStore, cfg),
+		documentManager:  document.NewDocumentManager(backend, vectorStore, cfg),
 		profileExtractor: profileExtractor,
 
 		// Initialize component helpers with appropriate dependencies
 		promptBuilder:     NewPromptBuilder(vectorStore, cfg),
-		messageProcessor:  NewMessageProcessor(vectorStore, nexaClient, cfg),
+		tokenizers:        newTokenizerCache(backend, cfg.Tokenizers),
+		messageProcessor:  messageProcessor,
+		reranker:          NewReranker(backend, messageProcessor, rerankModel),
+		queryRewriter:     NewQueryRewriter(backend),
 		responseProcessor: NewResponseProcessor(profileExtractor),
-		documentProcessor: NewDocumentProcessor(vectorStore, nexaClient),
+		documentProcessor: NewDocumentProcessor(vectorStore, backend),
+		reembedder:        NewReembedder(vectorStore, backend),
+		summarizer:        NewConversationSummarizer(vectorStore, backend, cfg.SummaryIntervalTurns),
 	}
 
 	// Initialize both pipeline implementations with shared base
@@ -69,7 +91,7 @@ func (p *basePipeline) ProcessUserMessage(
 	chat *vector.Chat,
 	llmModel, embedModel string,
 	userMessage string,
-) (<-chan string, <-chan error, error) {
+) (<-chan models.Event, <-chan error) {
 	hasDocuments := chat.FileCount > 0
 
 	if !hasDocuments {
@@ -86,6 +108,11 @@ func (p *basePipeline) GetDocumentManager() *document.DocumentManager {
 	return p.documentManager
 }
 
+// GetReembedder returns the reembedder that moves chats to another embedding model
+func (p *basePipeline) GetReembedder() *Reembedder {
+	return p.reembedder
+}
+
 // ==== Message Processing Delegates ====
 
 // groupAndMergeChunkedMessages delegates to messageProcessor
@@ -94,13 +121,132 @@ func (p *basePipeline) groupAndMergeChunkedMessages(ctx context.Context, message
 }
 
 // retrieveAllChunks delegates to messageProcessor (kept for backward compatibility)
-func (p *basePipeline) retrieveAllChunks(ctx context.Context, baseID string) ([]vector.Message, error) {
-	return p.messageProcessor.retrieveAllChunks(ctx, baseID)
+func (p *basePipeline) retrieveAllChunks(ctx context.Context, chatID, baseID string) ([]vector.Message, error) {
+	return p.messageProcessor.retrieveAllChunks(ctx, chatID, baseID)
+}
+
+// rerankMessages delegates to reranker
+func (p *basePipeline) rerankMessages(ctx context.Context, llmModel, query string, messages []vector.Message, topK int) ([]vector.Message, error) {
+	return p.reranker.RerankMessages(ctx, llmModel, query, messages, topK)
+}
+
+// rerankChunks delegates to reranker
+func (p *basePipeline) rerankChunks(ctx context.Context, query string, chunks []vector.DocumentChunk, topK int) ([]vector.DocumentChunk, error) {
+	return p.reranker.RerankChunks(ctx, query, chunks, topK)
+}
+
+// ==== Retrieval Helpers ====
+
+// searchQueries returns the queries to retrieve with for userMessage, the main query first. That is
+// userMessage itself unless the chat rewrites queries into standalone ones or expands them into
+// several. history holds the chat's messages before userMessage. A failed LLM step is reported as
+// a warning and retrieval goes on with the queries it already has.
+func (p *basePipeline) searchQueries(
+	ctx context.Context,
+	chat *vector.Chat,
+	llmModel, userMessage string,
+	history []vector.Message,
+	events chan<- models.Event,
+) []string {
+	if !chat.UseQueryRewriting && !chat.UseMultiQuery {
+		return []string{userMessage}
+	}
+	events <- models.StageEvent(models.StageRewriting)
+
+	query := userMessage
+	if chat.UseQueryRewriting {
+		rewritten, err := p.queryRewriter.Rewrite(ctx, llmModel, userMessage, history)
+		if err != nil {
+			logging.Error("Query rewriting failed: %v", err)
+			events <- models.WarningEvent("Query rewriting failed, searching with the message as written: %v", err)
+		} else {
+			if rewritten != userMessage {
+				logging.Info("Rewrote query %q as %q", userMessage, rewritten)
+			}
+			query = rewritten
+		}
+	}
+
+	queries := []string{query}
+	if chat.UseMultiQuery {
+		expanded, err := p.queryRewriter.Expand(ctx, llmModel, query)
+		if err != nil {
+			logging.Error("Query expansion failed: %v", err)
+			events <- models.WarningEvent("Multi-query expansion failed, searching with a single query: %v", err)
+		} else {
+			logging.Debug("Expanded query into %d more queries: %q", len(expanded), expanded)
+			queries = append(queries, expanded...)
+		}
+	}
+
+	return queries
+}
+
+// embedQueries embeds the search queries in one request
+func (p *basePipeline) embedQueries(ctx context.Context, embedModel string, queries []string) ([][]float32, error) {
+	embeddings, err := p.backend.GenerateEmbeddings(ctx, embedModel, queries, &p.config.EmbeddingDimensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate user message embedding: %w", err)
+	}
+	if len(embeddings) != len(queries) {
+		return nil, fmt.Errorf("failed to generate user message embedding: expected %d, got %d", len(queries), len(embeddings))
+	}
+	return embeddings, nil
 }
 
-// rerankMessagesWithLLM delegates to messageProcessor
-func (p *basePipeline) rerankMessagesWithLLM(ctx context.Context, llmModel, query string, messages []vector.Message, topK int) ([]vector.Message, error) {
-	return p.messageProcessor.RerankMessagesWithLLM(ctx, llmModel, query, messages, topK)
+// searchSimilar retrieves similar Q&A pairs for each query and fuses the rankings
+func (p *basePipeline) searchSimilar(ctx context.Context, session *vector.ChatSession, embeddings [][]float32, topK int) ([]vector.Message, error) {
+	var rankings [][]vector.Message
+	for _, embedding := range embeddings {
+		messages, err := session.SearchSimilar(ctx, embedding, topK)
+		if err != nil {
+			return nil, err
+		}
+		rankings = append(rankings, messages)
+	}
+	if len(rankings) == 1 {
+		return rankings[0], nil
+	}
+	return fuseRankings(rankings, p.config.Retrieval.RRFConstant, func(msg vector.Message) string { return msg.ID }), nil
+}
+
+// searchContextAndChunks retrieves similar Q&A pairs and document chunks for each query and fuses
+// the rankings of each kind
+func (p *basePipeline) searchContextAndChunks(
+	ctx context.Context,
+	session *vector.ChatSession,
+	queries []string,
+	embeddings [][]float32,
+	topK int,
+) ([]vector.Message, []vector.DocumentChunk, error) {
+	var messageRankings [][]vector.Message
+	var chunkRankings [][]vector.DocumentChunk
+	for i, query := range queries {
+		messages, chunks, err := session.SearchSimilarContextAndChunks(ctx, query, embeddings[i], topK)
+		if err != nil {
+			return nil, nil, err
+		}
+		messageRankings = append(messageRankings, messages)
+		chunkRankings = append(chunkRankings, chunks)
+	}
+	if len(queries) == 1 {
+		return messageRankings[0], chunkRankings[0], nil
+	}
+
+	k := p.config.Retrieval.RRFConstant
+	messages := fuseRankings(messageRankings, k, func(msg vector.Message) string { return msg.ID })
+	chunks := fuseRankings(chunkRankings, k, func(chunk vector.DocumentChunk) string { return chunk.ID })
+	logging.Debug("Fused results of %d queries: %d context messages, %d chunks", len(queries), len(messages), len(chunks))
+	return messages, chunks, nil
+}
+
+// chatMessages assembles the messages of a completion request: the system prompt, the recent turns
+// and the prompt carrying the retrieved context and the user's message
+func chatMessages(systemPrompt string, turns []llm.ChatMessage, prompt string) []llm.ChatMessage {
+	messages := make([]llm.ChatMessage, 0, len(turns)+2)
+	messages = append(messages, llm.ChatMessage{Role: "system", Content: systemPrompt})
+	messages = append(messages, turns...)
+	return append(messages, llm.ChatMessage{Role: "user", Content: prompt})
 }
 
 // ==== Prompt Building Delegates ====
@@ -111,8 +257,8 @@ func (p *basePipeline) buildProfileContext(profile *vector.UserProfile) string {
 }
 
 // buildPromptWithContext delegates to promptBuilder
-func (p *basePipeline) buildPromptWithContext(ctx context.Context, chatID string, systemPrompt string, contextMessages []vector.Message, userMessage string) string {
-	return p.promptBuilder.BuildPromptWithContext(ctx, chatID, systemPrompt, contextMessages, userMessage)
+func (p *basePipeline) buildPromptWithContext(ctx context.Context, chat *vector.Chat, contextMessages []vector.Message, recent []vector.Message, userMessage string, tokenizer Tokenizer) (string, []llm.ChatMessage) {
+	return p.promptBuilder.BuildPromptWithContext(ctx, chat, contextMessages, recent, userMessage, tokenizer)
 }
 
 // buildPromptWithContextAndDocuments delegates to promptBuilder
@@ -121,8 +267,8 @@ func (p *basePipeline) buildPromptWithContextAndDocuments(systemPrompt string, c
 }
 
 // buildPromptWithContextAndDocumentsAndFileList delegates to promptBuilder
-func (p *basePipeline) buildPromptWithContextAndDocumentsAndFileList(ctx context.Context, chat *vector.Chat, contextMessages []vector.Message, contextChunks []vector.DocumentChunk, allDocs []vector.Document, userMessage string) string {
-	return p.promptBuilder.BuildPromptWithContextAndDocumentsAndFileList(ctx, chat, contextMessages, contextChunks, allDocs, userMessage)
+func (p *basePipeline) buildPromptWithContextAndDocumentsAndFileList(ctx context.Context, chat *vector.Chat, contextMessages []vector.Message, contextChunks []vector.DocumentChunk, allDocs []vector.Document, recent []vector.Message, userMessage string, tokenizer Tokenizer) (string, []llm.ChatMessage, []vector.Source) {
+	return p.promptBuilder.BuildPromptWithContextAndDocumentsAndFileList(ctx, chat, contextMessages, contextChunks, allDocs, recent, userMessage, tokenizer)
 }
 
 // ==== Response Processing Delegates ====
@@ -132,10 +278,28 @@ func (p *basePipeline) collectStreamedResponse(
 	ctx context.Context,
 	streamChan <-chan string,
 	errChan <-chan error,
-	responseChan chan<- string,
+	events chan<- models.Event,
 	onComplete func(fullResponse string) error,
 ) error {
-	return p.responseProcessor.CollectStreamedResponse(ctx, streamChan, errChan, responseChan, onComplete)
+	return p.responseProcessor.CollectStreamedResponse(ctx, streamChan, errChan, events, onComplete)
+}
+
+// streamEvents runs process in the background and returns the events it sends and its error.
+// The error channel is closed first, so a reader that drains the events can then read it.
+func streamEvents(process func(events chan<- models.Event) error) (<-chan models.Event, <-chan error) {
+	events := make(chan models.Event, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errChan)
+
+		if err := process(events); err != nil {
+			errChan <- err
+		}
+	}()
+
+	return events, errChan
 }
 
 // storeCompletionPair stores assistant message and Q&A pair with embedding
@@ -145,14 +309,18 @@ func (p *basePipeline) storeCompletionPair(
 	embedModel string,
 	userQuery string,
 	assistantResponse string,
+	usage vector.TokenUsage,
+	sources []vector.Source,
+	tokenizer Tokenizer,
 ) error {
 	// This method intentionally kept in basePipeline as it coordinates multiple components
 	// For now, we'll keep the original implementation as it's used by SimplePipeline/RAGPipeline
 	// A future refactoring could extract this further into a coordinator pattern
-	return storeCompletionPairImpl(ctx, p.vectorStore, p.nexaClient, p.config, chat, embedModel, userQuery, assistantResponse)
+	return storeCompletionPairImpl(ctx, p.vectorStore, p.backend, p.config, chat, embedModel, userQuery, assistantResponse, usage, sources, tokenizer)
 }
 
-// storeCompletionPairWithExtraction stores completion pair and asynchronously extracts facts
+// storeCompletionPairWithExtraction stores completion pair, asynchronously extracts facts and
+// updates the conversation summary when it is due
 func (p *basePipeline) storeCompletionPairWithExtraction(
 	ctx context.Context,
 	chat *vector.Chat,
@@ -160,12 +328,16 @@ func (p *basePipeline) storeCompletionPairWithExtraction(
 	embedModel string,
 	userQuery string,
 	assistantResponse string,
+	usage vector.TokenUsage,
+	sources []vector.Source,
+	tokenizer Tokenizer,
 ) error {
-	if err := p.storeCompletionPair(ctx, chat, embedModel, userQuery, assistantResponse); err != nil {
+	if err := p.storeCompletionPair(ctx, chat, embedModel, userQuery, assistantResponse, usage, sources, tokenizer); err != nil {
 		return err
 	}
 	// Start async fact extraction (non-blocking)
 	p.responseProcessor.StartAsyncFactExtraction(chat.ID, llmModel, userQuery, assistantResponse)
+	p.summarizer.StartAsyncUpdate(chat.ID, llmModel)
 	return nil
 }
 
@@ -178,73 +350,100 @@ func (p *basePipeline) processDocument(
 	embedModel string,
 	doc vector.Document,
 	loader *document.Loader,
-	responseChan chan<- string,
+	events chan<- models.Event,
 ) error {
-	return p.documentProcessor.ProcessDocument(ctx, chat, embedModel, doc, loader, responseChan)
+	return p.documentProcessor.ProcessDocument(ctx, chat, embedModel, doc, loader, events)
 }
 
 // chunkAndStoreQAPair chunks a Q&A pair if needed and stores with embeddings
-func (p *basePipeline) chunkAndStoreQAPair(ctx context.Context, chat *vector.Chat, embedModel string, qaText string) error {
+func (p *basePipeline) chunkAndStoreQAPair(ctx context.Context, chat *vector.Chat, embedModel string, qaText string, tokenizer Tokenizer) error {
 	// This method intentionally kept in basePipeline as it's called from storeCompletionPair
 	// For now, we'll keep the original implementation
-	return chunkAndStoreQAPairImpl(ctx, p.vectorStore, p.nexaClient, p.config, chat, embedModel, qaText)
+	return chunkAndStoreQAPairImpl(ctx, p.vectorStore, p.backend, p.config, chat, embedModel, qaText, tokenizer)
 }
 
 // storeCompletionPairImpl contains the original implementation logic
 func storeCompletionPairImpl(
 	ctx context.Context,
 	vectorStore vector.VectorStore,
-	nexaClient *nexa.Client,
+	backend llm.Backend,
 	cfg *config.Config,
 	chat *vector.Chat,
 	embedModel string,
 	userQuery string,
 	assistantResponse string,
+	usage vector.TokenUsage,
+	sources []vector.Source,
+	tokenizer Tokenizer,
 ) error {
+	session, err := vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
 	// Store assistant message WITHOUT embedding (for display purposes)
 	assistantMsg := models.NewMessage(chat.ID, "assistant", assistantResponse)
-	if err := vectorStore.StoreMessage(ctx, assistantMsg.ID, "assistant", assistantResponse, []float32{}, time.Now()); err != nil {
+	if err := session.StoreMessage(ctx, assistantMsg.ID, "assistant", assistantResponse, []float32{}, time.Now()); err != nil {
 		logging.Error("Failed to store assistant message: %v", err)
 		return err
 	}
 
+	// Usage is bookkeeping; losing it must not fail the response
+	if err := session.RecordUsage(ctx, assistantMsg.ID, usage); err != nil {
+		logging.Error("Failed to record token usage: %v", err)
+	}
+	if len(sources) > 0 {
+		if err := session.RecordSources(ctx, assistantMsg.ID, sources); err != nil {
+			logging.Error("Failed to record sources: %v", err)
+		}
+	}
+
 	// Create and store the Q&A pair with embedding (for retrieval purposes)
 	qaText := "Previously user asked: " + userQuery + "\nAssistant answered: " + assistantResponse
-	return chunkAndStoreQAPairImpl(ctx, vectorStore, nexaClient, cfg, chat, embedModel, qaText)
+	return chunkAndStoreQAPairImpl(ctx, vectorStore, backend, cfg, chat, embedModel, qaText, tokenizer)
 }
 
-// chunkAndStoreQAPairImpl contains the original implementation logic for Q&A pair chunking
+// chunkAndStoreQAPairImpl contains the original implementation logic for Q&A pair chunking.
+// Sizes are counted with the chat model's tokenizer.
 func chunkAndStoreQAPairImpl(
 	ctx context.Context,
 	vectorStore vector.VectorStore,
-	nexaClient *nexa.Client,
+	backend llm.Backend,
 	cfg *config.Config,
 	chat *vector.Chat,
 	embedModel string,
 	qaText string,
+	tokenizer Tokenizer,
 ) error {
+	session, err := vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
 	const maxTokensPerChunk = 300
-	estimatedTokens := EstimateTokens(qaText)
+	qaTokens := tokenizer.CountTokens(qaText)
 
 	// If small enough, store as single context message
-	if estimatedTokens <= maxTokensPerChunk {
-		qaEmbeddings, err := nexaClient.GenerateEmbeddings(ctx, embedModel, []string{qaText}, &cfg.EmbeddingDimensions)
+	if qaTokens <= maxTokensPerChunk {
+		qaEmbeddings, err := backend.GenerateEmbeddings(ctx, embedModel, []string{qaText}, &cfg.EmbeddingDimensions)
 		if err != nil {
 			return err
 		}
 
 		qaPairMsg := models.NewMessage(chat.ID, "context", qaText)
-		if err := vectorStore.StoreMessage(ctx, qaPairMsg.ID, "context", qaText, qaEmbeddings[0], time.Now()); err != nil {
+		if err := session.StoreMessage(ctx, qaPairMsg.ID, "context", qaText, qaEmbeddings[0], time.Now()); err != nil {
 			return err
 		}
 		return nil
 	}
 
-	logging.Info("Q&A pair is long (%d tokens estimated), chunking into smaller pieces", estimatedTokens)
+	logging.Info("Q&A pair is long (%d tokens), chunking into smaller pieces", qaTokens)
 
-	// Create chunker
+	// The chunker splits by characters, so size chunks by this text's characters per token
 	chunker := document.NewChunker()
-	chunker.ChunkSize = maxTokensPerChunk * CharsPerToken
+	chunker.ChunkSize = maxTokensPerChunk * len(qaText) / qaTokens
 	chunker.ChunkOverlap = 50
 
 	chunks := chunker.ChunkDocument(qaText)
@@ -267,19 +466,18 @@ func chunkAndStoreQAPairImpl(
 		}
 
 		const maxSafeTokens = 800
-		const maxSafeChars = maxSafeTokens * CharsPerToken
 
-		if len(chunkContent) > maxSafeChars {
-			logging.Info("Chunk %d exceeds safe size (%d chars), truncating to %d chars",
-				i, len(chunkContent), maxSafeChars)
-			chunkContent = chunkContent[:maxSafeChars] + "..."
+		if tokens := tokenizer.CountTokens(chunkContent); tokens > maxSafeTokens {
+			logging.Info("Chunk %d exceeds safe size (%d tokens), truncating to %d tokens",
+				i, tokens, maxSafeTokens)
+			chunkContent = TruncateToTokens(tokenizer, chunkContent, maxSafeTokens)
 		}
 
 		chunkContents = append(chunkContents, chunkContent)
 	}
 
 	// Generate embeddings for all chunks in batch
-	embeddings, err := nexaClient.GenerateEmbeddings(ctx, embedModel, chunkContents, &cfg.EmbeddingDimensions)
+	embeddings, err := backend.GenerateEmbeddings(ctx, embedModel, chunkContents, &cfg.EmbeddingDimensions)
 	if err != nil {
 		return err
 	}
@@ -287,7 +485,7 @@ func chunkAndStoreQAPairImpl(
 	// Store each chunk as separate context message with chunk ID
 	for i, chunkContent := range chunkContents {
 		chunkID := baseID + "-chunk-" + string(rune('0'+i))
-		if err := vectorStore.StoreMessage(ctx, chunkID, "context", chunkContent, embeddings[i], time.Now()); err != nil {
+		if err := session.StoreMessage(ctx, chunkID, "context", chunkContent, embeddings[i], time.Now()); err != nil {
 			return err
 		}
 	}
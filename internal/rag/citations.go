@@ -0,0 +1,79 @@
+package rag
+
+import (
+	"fmt"
+
+	"rag-terminal/internal/document"
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/vector"
+)
+
+// citationLocator resolves the line ranges of cited chunks. Chunks store byte offsets into the
+// parsed file content, so each cited file is parsed once and its lines are only trusted while
+// the content still matches the indexed version. Chunks indexed before offsets were recorded get
+// no range until their file is reloaded.
+type citationLocator struct {
+	parser   *document.Parser
+	cleaner  *document.Cleaner
+	docs     map[string]vector.Document // By document ID
+	contents map[string]*string         // Parsed content by file path; nil if unusable
+}
+
+func newCitationLocator(docs []vector.Document) *citationLocator {
+	l := &citationLocator{
+		parser:   document.NewParser(),
+		cleaner:  document.NewCleaner(),
+		docs:     make(map[string]vector.Document, len(docs)),
+		contents: make(map[string]*string),
+	}
+	for _, doc := range docs {
+		l.docs[doc.ID] = doc
+	}
+	return l
+}
+
+// locate returns the source of a chunk under the citation label id
+func (l *citationLocator) locate(chunk vector.DocumentChunk, id string) vector.Source {
+	source := vector.Source{
+		ID:       id,
+		FilePath: chunk.FilePath,
+		ChunkID:  chunk.ID,
+	}
+	if chunk.Offsets != vector.ChunkOffsetsBytes {
+		return source
+	}
+	if content := l.content(chunk); content != nil {
+		if start, end, ok := document.LineRange(*content, chunk.StartPos, chunk.EndPos); ok {
+			source.StartLine = start
+			source.EndLine = end
+		}
+	}
+	return source
+}
+
+// citationID returns the label of the n-th excerpt in a prompt, counting from zero
+func citationID(n int) string {
+	return fmt.Sprintf("S%d", n+1)
+}
+
+// content returns the parsed content of the chunk's file, or nil if it can't be read or has
+// changed since it was indexed, in which case the offsets no longer point at the chunk
+func (l *citationLocator) content(chunk vector.DocumentChunk) *string {
+	if content, ok := l.contents[chunk.FilePath]; ok {
+		return content
+	}
+
+	var content *string
+	parsed := l.parser.ParseFile(chunk.FilePath)
+	switch {
+	case parsed.Error != nil || !parsed.IsSupported:
+		logging.Debug("Cannot resolve lines of %s: file is unreadable", chunk.FilePath)
+	case l.docs[chunk.DocumentID].ContentHash != l.cleaner.CalculateHash(parsed.Content):
+		logging.Debug("Cannot resolve lines of %s: file changed since it was indexed", chunk.FilePath)
+	default:
+		content = &parsed.Content
+	}
+
+	l.contents[chunk.FilePath] = content
+	return content
+}
@@ -0,0 +1,189 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-terminal/internal/document"
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/vector"
+)
+
+const (
+	summaryMaxChars     = 2000 // Target length of a conversation summary
+	summaryMessageChars = 1500 // Longer messages are cut in the transcript given to the summarizer
+)
+
+// ConversationSummarizer keeps the rolling summary of each chat current. After an answer it checks
+// whether enough turns came after the summary and folds them into it with the LLM.
+type ConversationSummarizer struct {
+	vectorStore vector.VectorStore
+	summarizer  *document.Summarizer
+	interval    int // Turns between regenerations
+
+	mu      sync.Mutex
+	running map[string]bool // Chats being summarized, by chat ID
+}
+
+// NewConversationSummarizer creates a summarizer that regenerates a chat's summary every interval turns
+func NewConversationSummarizer(vectorStore vector.VectorStore, backend llm.Backend, interval int) *ConversationSummarizer {
+	return &ConversationSummarizer{
+		vectorStore: vectorStore,
+		summarizer:  document.NewSummarizer(backend),
+		interval:    interval,
+		running:     make(map[string]bool),
+	}
+}
+
+// StartAsyncUpdate updates the chat's summary in the background if it is due
+func (cs *ConversationSummarizer) StartAsyncUpdate(chatID, llmModel string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		if err := cs.Update(ctx, chatID, llmModel); err != nil {
+			logging.Error("Conversation summary update failed (non-blocking): %v", err)
+		}
+	}()
+}
+
+// Update folds the turns that came after the chat's summary into it once there are at least
+// interval of them. Nothing is done while another update of the chat is running.
+func (cs *ConversationSummarizer) Update(ctx context.Context, chatID, llmModel string) error {
+	if !cs.start(chatID) {
+		return nil
+	}
+	defer cs.finish(chatID)
+
+	summary, pending, err := cs.pendingTurns(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	turns := 0
+	for _, msg := range pending {
+		if msg.Role == "user" {
+			turns++
+		}
+	}
+	if turns < cs.interval {
+		return nil
+	}
+
+	var transcript strings.Builder
+	for _, msg := range pending {
+		transcript.WriteString(fmt.Sprintf("[%s]: %s\n\n", msg.Role, truncateRunes(msg.Content, summaryMessageChars)))
+	}
+
+	previous := ""
+	if summary != nil {
+		previous = summary.Content
+	}
+
+	// The chat is not held open while the LLM works
+	content, err := cs.summarizer.SummarizeConversation(ctx, llmModel, previous, transcript.String(), summaryMaxChars)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return fmt.Errorf("failed to generate conversation summary: LLM returned an empty summary")
+	}
+
+	session, err := cs.vectorStore.OpenChat(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	// An edit made meanwhile wins; its turns are folded in by the next update
+	current, err := session.GetSummary(ctx)
+	if err != nil {
+		return err
+	}
+	if !sameSummary(current, summary) {
+		logging.Info("Summary of chat %s changed while it was regenerated, keeping the new version", chatID)
+		return nil
+	}
+
+	updated := &vector.ConversationSummary{
+		Content:      content,
+		Turns:        turns,
+		CoveredUntil: pending[len(pending)-1].Timestamp,
+	}
+	if summary != nil {
+		updated.Turns += summary.Turns
+	}
+	if err := session.StoreSummary(ctx, updated); err != nil {
+		return err
+	}
+
+	logging.Info("Updated conversation summary of chat %s, now covering %d turns", chatID, updated.Turns)
+	return nil
+}
+
+// pendingTurns returns the chat's summary and the user and assistant messages after it, up to the
+// last answer; a question still being answered waits for the next update
+func (cs *ConversationSummarizer) pendingTurns(ctx context.Context, chatID string) (*vector.ConversationSummary, []vector.Message, error) {
+	session, err := cs.vectorStore.OpenChat(ctx, chatID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	summary, err := session.GetSummary(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages, err := session.GetMessages(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pending []vector.Message
+	lastAnswer := -1
+	for _, msg := range messages {
+		if msg.Role != "user" && msg.Role != "assistant" {
+			continue
+		}
+		if summary != nil && !msg.Timestamp.After(summary.CoveredUntil) {
+			continue
+		}
+		pending = append(pending, msg)
+		if msg.Role == "assistant" {
+			lastAnswer = len(pending) - 1
+		}
+	}
+
+	return summary, pending[:lastAnswer+1], nil
+}
+
+// sameSummary reports whether two reads of a summary found the same version
+func sameSummary(a, b *vector.ConversationSummary) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+// start marks a chat as being summarized, returning false if it already is
+func (cs *ConversationSummarizer) start(chatID string) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.running[chatID] {
+		return false
+	}
+	cs.running[chatID] = true
+	return true
+}
+
+func (cs *ConversationSummarizer) finish(chatID string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	delete(cs.running, chatID)
+}
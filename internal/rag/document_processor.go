@@ -5,22 +5,23 @@ import (
 	"fmt"
 
 	"rag-terminal/internal/document"
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/logging"
-	"rag-terminal/internal/nexa"
+	"rag-terminal/internal/models"
 	"rag-terminal/internal/vector"
 )
 
 // DocumentProcessor handles single document processing: chunking, embedding, and storage
 type DocumentProcessor struct {
 	vectorStore vector.VectorStore
-	nexaClient  *nexa.Client
+	backend     llm.Backend
 }
 
 // NewDocumentProcessor creates a new document processor
-func NewDocumentProcessor(vectorStore vector.VectorStore, nexaClient *nexa.Client) *DocumentProcessor {
+func NewDocumentProcessor(vectorStore vector.VectorStore, backend llm.Backend) *DocumentProcessor {
 	return &DocumentProcessor{
 		vectorStore: vectorStore,
-		nexaClient:  nexaClient,
+		backend:     backend,
 	}
 }
 
@@ -32,27 +33,28 @@ func (dp *DocumentProcessor) ProcessDocument(
 	embedModel string,
 	doc vector.Document,
 	loader *document.Loader,
-	responseChan chan<- string,
+	events chan<- models.Event,
 ) error {
-	badgerStore, ok := dp.vectorStore.(*vector.BadgerStore)
-	if !ok {
-		return fmt.Errorf("vector store is not BadgerStore type")
+	session, err := dp.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
 	}
+	defer session.Close()
 
 	logging.Debug("Processing document: %s (size=%d, hash=%s)", doc.FileName, doc.FileSize, doc.ContentHash)
 
 	// Check if document with same content hash already exists
-	existingDoc, err := badgerStore.FindDocumentByHash(ctx, doc.ContentHash)
+	existingDoc, err := session.FindDocumentByHash(ctx, doc.ContentHash)
 	if err == nil && existingDoc != nil {
 		logging.Info("Document %s already exists (duplicate of %s), skipping", doc.FileName, existingDoc.FileName)
-		if responseChan != nil {
-			responseChan <- fmt.Sprintf("Skipped %s (duplicate of %s)\n", doc.FileName, existingDoc.FileName)
+		if events != nil {
+			events <- models.WarningEvent("Skipped %s (duplicate of %s)", doc.FileName, existingDoc.FileName)
 		}
 		return nil // Not an error, just skipped
 	}
 
 	// Store document metadata
-	if err := badgerStore.StoreDocument(ctx, &doc); err != nil {
+	if err := session.StoreDocument(ctx, &doc); err != nil {
 		logging.Error("Failed to store document metadata for %s: %v", doc.FileName, err)
 		return fmt.Errorf("failed to store document %s: %w", doc.FileName, err)
 	}
@@ -74,7 +76,7 @@ func (dp *DocumentProcessor) ProcessDocument(
 
 	// Generate embeddings for all chunks in batch
 	logging.Debug("Generating embeddings for %d chunks of %s with dimensions=%d", len(chunks), doc.FileName, 384) // Default dimension
-	embeddings, err := dp.nexaClient.GenerateEmbeddings(ctx, embedModel, chunkContents, nil)
+	embeddings, err := dp.backend.GenerateEmbeddings(ctx, embedModel, chunkContents, nil)
 	if err != nil {
 		logging.Error("Failed to generate embeddings for %s: %v", doc.FileName, err)
 		return fmt.Errorf("failed to generate embeddings for %s: %w", doc.FileName, err)
@@ -84,7 +86,7 @@ func (dp *DocumentProcessor) ProcessDocument(
 	// Store chunks with embeddings
 	for i, chunk := range chunks {
 		chunk.Embedding = embeddings[i]
-		if err := badgerStore.StoreDocumentChunk(ctx, &chunk); err != nil {
+		if err := session.StoreDocumentChunk(ctx, &chunk); err != nil {
 			logging.Error("Failed to store chunk %d of %s: %v", i, doc.FileName, err)
 			return fmt.Errorf("failed to store chunk %d of %s: %w", i, doc.FileName, err)
 		}
@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"time"
+
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/vector"
+)
+
+// recentTurns returns the last n complete turns of history (all chat messages, oldest first):
+// a user message directly followed by its answer. Unanswered messages, e.g. of a failed request,
+// are left out, so user and assistant messages alternate as chat templates expect.
+func recentTurns(history []vector.Message, n int) []vector.Message {
+	var dialog []vector.Message
+	for _, msg := range history {
+		if msg.Role == "user" || msg.Role == "assistant" {
+			dialog = append(dialog, msg)
+		}
+	}
+
+	var turns []vector.Message
+	for i := len(dialog) - 1; i > 0 && len(turns) < 2*n; i-- {
+		if dialog[i].Role == "assistant" && dialog[i-1].Role == "user" {
+			turns = append(turns, dialog[i], dialog[i-1])
+			i--
+		}
+	}
+
+	// Collected newest first
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return turns
+}
+
+// fitRecentTurns converts turns from recentTurns into chat messages, keeping the newest turns that
+// fit into maxTokens. The newest turn is truncated rather than dropped, since follow-ups like
+// "fix that" refer to it. Returns the messages oldest first, the tokens they take and the time of
+// the oldest question kept, which is zero if no turn fits.
+func fitRecentTurns(turns []vector.Message, tokenizer Tokenizer, maxTokens int) ([]llm.ChatMessage, int, time.Time) {
+	var kept []llm.ChatMessage
+	var since time.Time
+	used := 0
+
+	for i := len(turns) - 2; i >= 0; i -= 2 {
+		question, answer := turns[i].Content, turns[i+1].Content
+		remaining := maxTokens - used
+		cost := tokenizer.CountTokens(question) + tokenizer.CountTokens(answer)
+
+		if cost > remaining {
+			if len(kept) > 0 {
+				break
+			}
+			// Give the question up to half of the budget and the answer the rest
+			question = TruncateToTokens(tokenizer, question, remaining/2)
+			answer = TruncateToTokens(tokenizer, answer, remaining-tokenizer.CountTokens(question))
+			if question == "" || answer == "" {
+				break
+			}
+			cost = tokenizer.CountTokens(question) + tokenizer.CountTokens(answer)
+		}
+
+		kept = append([]llm.ChatMessage{
+			{Role: "user", Content: question},
+			{Role: "assistant", Content: answer},
+		}, kept...)
+		since = turns[i].Timestamp
+		used += cost
+	}
+
+	return kept, used, since
+}
+
+// withoutCoveredContext drops retrieved Q&A pairs of turns that are already in the prompt verbatim.
+// A pair is stored right after its answer, so pairs from since on belong to the kept turns.
+func withoutCoveredContext(contextMessages []vector.Message, since time.Time) []vector.Message {
+	if since.IsZero() {
+		return contextMessages
+	}
+
+	var result []vector.Message
+	for _, msg := range contextMessages {
+		if msg.Timestamp.Before(since) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
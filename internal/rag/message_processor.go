@@ -8,22 +8,22 @@ import (
 	"strings"
 
 	"rag-terminal/internal/config"
-	"rag-terminal/internal/nexa"
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/vector"
 )
 
 // MessageProcessor handles message operations including merging, retrieval, and reranking
 type MessageProcessor struct {
 	vectorStore vector.VectorStore
-	nexaClient  *nexa.Client
+	backend     llm.Backend
 	config      *config.Config
 }
 
 // NewMessageProcessor creates a new message processor
-func NewMessageProcessor(vectorStore vector.VectorStore, nexaClient *nexa.Client, cfg *config.Config) *MessageProcessor {
+func NewMessageProcessor(vectorStore vector.VectorStore, backend llm.Backend, cfg *config.Config) *MessageProcessor {
 	return &MessageProcessor{
 		vectorStore: vectorStore,
-		nexaClient:  nexaClient,
+		backend:     backend,
 		config:      cfg,
 	}
 }
@@ -52,7 +52,7 @@ func (mp *MessageProcessor) GroupAndMergeChunkedMessages(ctx context.Context, me
 	mergedMessages := []vector.Message{}
 	for baseID, chunks := range chunkGroups {
 		// Retrieve all chunks from database for this base ID
-		allChunks, err := mp.retrieveAllChunks(ctx, baseID)
+		allChunks, err := mp.retrieveAllChunks(ctx, chunks[0].ChatID, baseID)
 		if err != nil || len(allChunks) == 0 {
 			// Fallback: use what we have
 			allChunks = chunks
@@ -98,14 +98,15 @@ func (mp *MessageProcessor) GroupAndMergeChunkedMessages(ctx context.Context, me
 }
 
 // retrieveAllChunks retrieves all chunks for a given base message ID
-func (mp *MessageProcessor) retrieveAllChunks(ctx context.Context, baseID string) ([]vector.Message, error) {
-	badgerStore, ok := mp.vectorStore.(*vector.BadgerStore)
-	if !ok {
-		return nil, fmt.Errorf("vector store is not BadgerStore")
+func (mp *MessageProcessor) retrieveAllChunks(ctx context.Context, chatID, baseID string) ([]vector.Message, error) {
+	session, err := mp.vectorStore.OpenChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat: %w", err)
 	}
+	defer session.Close()
 
 	// Search for all messages with IDs matching baseID-chunk-*
-	allMessages, err := badgerStore.GetAllMessages(ctx)
+	allMessages, err := session.GetAllMessages(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -142,9 +143,9 @@ func (mp *MessageProcessor) RerankMessagesWithLLM(ctx context.Context, llmModel,
 	promptBuilder.WriteString("Higher scores mean more relevant to the query.")
 
 	// Call LLM for scoring
-	req := nexa.ChatCompletionRequest{
+	req := llm.ChatCompletionRequest{
 		Model: llmModel,
-		Messages: []nexa.ChatMessage{
+		Messages: []llm.ChatMessage{
 			{Role: "user", Content: promptBuilder.String()},
 		},
 		Temperature: 0.1, // Low temperature for consistent scoring
@@ -153,7 +154,7 @@ func (mp *MessageProcessor) RerankMessagesWithLLM(ctx context.Context, llmModel,
 	}
 
 	// Use non-streaming API
-	response, err := mp.nexaClient.ChatCompletionSync(ctx, req)
+	response, err := mp.backend.ChatCompletionSync(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get LLM reranking scores: %w", err)
 	}
@@ -4,13 +4,18 @@ import (
 	"context"
 
 	"rag-terminal/internal/document"
+	"rag-terminal/internal/models"
 	"rag-terminal/internal/vector"
 )
 
 // Pipeline defines the interface for processing user messages with optional context
 type Pipeline interface {
-	ProcessUserMessage(ctx context.Context, chat *vector.Chat, llmModel, embedModel string, userMessage string) (<-chan string, <-chan error, error)
+	// ProcessUserMessage answers userMessage in the background. The events report the stages as they
+	// start, the sources the model may cite, the answer tokens and finally the token usage. A failure
+	// is sent on the error channel; both channels are closed when processing ends.
+	ProcessUserMessage(ctx context.Context, chat *vector.Chat, llmModel, embedModel string, userMessage string) (<-chan models.Event, <-chan error)
 	GetDocumentManager() *document.DocumentManager
+	GetReembedder() *Reembedder
 }
 
 // ChatParams holds chat completion parameters
@@ -9,14 +9,14 @@ import (
 	"strings"
 	"time"
 
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/logging"
-	"rag-terminal/internal/nexa"
 	"rag-terminal/internal/vector"
 )
 
 // ProfileExtractor manages LLM-based fact extraction from conversations
 type ProfileExtractor struct {
-	llmClient   *nexa.Client
+	llmClient   llm.Backend
 	vectorStore vector.VectorStore
 }
 
@@ -51,7 +51,7 @@ type ValidatedFacts struct {
 }
 
 // NewProfileExtractor creates a new fact extractor
-func NewProfileExtractor(llmClient *nexa.Client, vectorStore vector.VectorStore) *ProfileExtractor {
+func NewProfileExtractor(llmClient llm.Backend, vectorStore vector.VectorStore) *ProfileExtractor {
 	return &ProfileExtractor{
 		llmClient:   llmClient,
 		vectorStore: vectorStore,
@@ -78,9 +78,16 @@ func (pe *ProfileExtractor) ExtractFacts(ctx context.Context, chatID string, llm
 		return fmt.Errorf("LLM extraction failed: %w", err)
 	}
 
-	// 3. Process each extracted fact
+	// 3. Process each extracted fact. The chat gets its own session, so extraction running
+	// after the response is safe even if the user has switched to another chat meanwhile.
+	session, err := pe.vectorStore.OpenChat(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
 	for _, fact := range extractedFacts {
-		if err := pe.processFact(ctx, chatID, fact); err != nil {
+		if err := pe.processFact(ctx, session, chatID, fact); err != nil {
 			// Log but don't fail - extraction is best-effort
 			logging.Error("Failed to process fact %s: %v", fact.Key, err)
 		}
@@ -144,7 +151,7 @@ func (pe *ProfileExtractor) callLLMForExtraction(ctx context.Context, llmModel s
 	}
 
 	// Call LLM for fact extraction with the currently selected model
-	resp, err := pe.callLLMWithRetry(ctx, llmModel, prompt)
+	resp, err := pe.callLLM(ctx, llmModel, prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -168,37 +175,24 @@ func (pe *ProfileExtractor) callLLMForExtraction(ctx context.Context, llmModel s
 	return validatedFacts.Facts, nil
 }
 
-// callLLMWithRetry calls the LLM with automatic retry on failure
-func (pe *ProfileExtractor) callLLMWithRetry(ctx context.Context, model string, prompt string) (string, error) {
-	maxRetries := 2
-	var lastErr error
-
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			logging.Debug("Retrying fact extraction (attempt %d/%d)", attempt+1, maxRetries+1)
-		}
-
-		resp, err := pe.llmClient.ChatCompletionSync(ctx, nexa.ChatCompletionRequest{
-			Model: model,
-			Messages: []nexa.ChatMessage{
-				{Role: "user", Content: prompt},
-			},
-			Temperature: 0.05, // Deterministic extraction
-			MaxTokens:   1000,
-		})
-
-		if err != nil {
-			lastErr = err
-			continue
+// callLLM asks the LLM for the facts. Transient failures are retried by the backend client.
+func (pe *ProfileExtractor) callLLM(ctx context.Context, model string, prompt string) (string, error) {
+	resp, err := pe.llmClient.ChatCompletionSync(ctx, llm.ChatCompletionRequest{
+		Model: model,
+		Messages: []llm.ChatMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.05, // Deterministic extraction
+		MaxTokens:   1000,
+	})
+	if err != nil {
+		return "", &ExtractionError{
+			Stage:       "llm_call",
+			OriginalErr: fmt.Errorf("LLM call failed: %w", err),
 		}
-
-		return resp, nil
 	}
 
-	return "", &ExtractionError{
-		Stage:       "llm_call",
-		OriginalErr: fmt.Errorf("LLM call failed after %d attempts: %w", maxRetries+1, lastErr),
-	}
+	return resp, nil
 }
 
 // parseAndValidateResponse extracts JSON and validates facts with robust error handling
@@ -429,7 +423,7 @@ func truncateForLogging(s string, maxLen int) string {
 }
 
 // processFact validates and stores an extracted fact to the user's profile with conflict resolution
-func (pe *ProfileExtractor) processFact(ctx context.Context, chatID string, newFact ExtractedFact) error {
+func (pe *ProfileExtractor) processFact(ctx context.Context, session *vector.ChatSession, chatID string, newFact ExtractedFact) error {
 	// Validate fact
 	if newFact.Key == "" || newFact.Value == "" {
 		return fmt.Errorf("invalid fact: key and value are required")
@@ -442,7 +436,7 @@ func (pe *ProfileExtractor) processFact(ctx context.Context, chatID string, newF
 	}
 
 	// Get existing fact if any
-	existing, err := pe.vectorStore.GetProfileFact(ctx, chatID, newFact.Key)
+	existing, err := session.GetProfileFact(ctx, chatID, newFact.Key)
 	if err != nil {
 		logging.Error("Failed to retrieve existing fact %s: %v", newFact.Key, err)
 		return err
@@ -460,7 +454,7 @@ func (pe *ProfileExtractor) processFact(ctx context.Context, chatID string, newF
 			LastSeen:   time.Now(),
 		}
 
-		if err := pe.vectorStore.UpsertProfileFact(ctx, chatID, profileFact); err != nil {
+		if err := session.UpsertProfileFact(ctx, chatID, profileFact); err != nil {
 			return fmt.Errorf("failed to upsert profile fact: %w", err)
 		}
 
@@ -469,11 +463,11 @@ func (pe *ProfileExtractor) processFact(ctx context.Context, chatID string, newF
 	}
 
 	// Existing fact found - apply conflict resolution
-	return pe.resolveConflict(ctx, chatID, existing, newFact)
+	return pe.resolveConflict(ctx, session, chatID, existing, newFact)
 }
 
 // resolveConflict handles conflicts between existing and new facts
-func (pe *ProfileExtractor) resolveConflict(ctx context.Context, chatID string,
+func (pe *ProfileExtractor) resolveConflict(ctx context.Context, session *vector.ChatSession, chatID string,
 	existing *vector.ProfileFact, newFact ExtractedFact) error {
 
 	// Case 1: Same value - just update LastSeen and boost confidence
@@ -482,7 +476,7 @@ func (pe *ProfileExtractor) resolveConflict(ctx context.Context, chatID string,
 		// Boost confidence slightly (up to 1.0 max)
 		existing.Confidence = math.Min(1.0, existing.Confidence+0.05)
 
-		if err := pe.vectorStore.UpsertProfileFact(ctx, chatID, *existing); err != nil {
+		if err := session.UpsertProfileFact(ctx, chatID, *existing); err != nil {
 			return fmt.Errorf("failed to update existing fact: %w", err)
 		}
 
@@ -515,7 +509,7 @@ func (pe *ProfileExtractor) resolveConflict(ctx context.Context, chatID string,
 			LastSeen:   time.Now(),
 		}
 
-		if err := pe.vectorStore.UpsertProfileFact(ctx, chatID, updatedFact); err != nil {
+		if err := session.UpsertProfileFact(ctx, chatID, updatedFact); err != nil {
 			return fmt.Errorf("failed to upsert updated fact: %w", err)
 		}
 
@@ -527,7 +521,7 @@ func (pe *ProfileExtractor) resolveConflict(ctx context.Context, chatID string,
 	logging.Info("Existing fact retained for %s: '%s' wins over '%s'",
 		existing.Key, existing.Value, newFact.Value)
 
-	if err := pe.vectorStore.UpsertProfileFact(ctx, chatID, *existing); err != nil {
+	if err := session.UpsertProfileFact(ctx, chatID, *existing); err != nil {
 		return fmt.Errorf("failed to update existing fact: %w", err)
 	}
 
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"rag-terminal/internal/config"
 	"rag-terminal/internal/document"
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/logging"
 	"rag-terminal/internal/vector"
 )
@@ -89,12 +91,91 @@ func (pb *PromptBuilder) buildProfileContext(profile *vector.UserProfile) string
 	return sb.String()
 }
 
-// BuildPromptWithContext builds a simple prompt with user profile and conversation context
-func (pb *PromptBuilder) BuildPromptWithContext(ctx context.Context, chatID string, systemPrompt string, contextMessages []vector.Message, userMessage string) string {
+// userProfile loads the user profile of a chat
+func (pb *PromptBuilder) userProfile(ctx context.Context, chatID string) (*vector.UserProfile, error) {
+	session, err := pb.vectorStore.OpenChat(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	return session.GetUserProfile(ctx, chatID)
+}
+
+// conversationSummary loads the rolling conversation summary of a chat, nil if there is none yet
+func (pb *PromptBuilder) conversationSummary(ctx context.Context, chatID string) (*vector.ConversationSummary, error) {
+	session, err := pb.vectorStore.OpenChat(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	return session.GetSummary(ctx)
+}
+
+// buildSummaryContext formats the conversation summary of a chat in at most maxTokens.
+// Returns "" if there is no summary.
+func (pb *PromptBuilder) buildSummaryContext(ctx context.Context, chatID string, tokenizer Tokenizer, maxTokens int) string {
+	summary, err := pb.conversationSummary(ctx, chatID)
+	if err != nil {
+		logging.Debug("Failed to retrieve conversation summary: %v", err)
+		return ""
+	}
+	if summary == nil || strings.TrimSpace(summary.Content) == "" {
+		return ""
+	}
+
+	header := "# Conversation Summary\n"
+	footer := "\n\n"
+	available := maxTokens - tokenizer.CountTokens(header+footer)
+	content := TruncateToTokens(tokenizer, strings.TrimSpace(summary.Content), available)
+	if content == "" {
+		return ""
+	}
+	return header + content + footer
+}
+
+// fitHistory splits the history budget: the recent turns sent verbatim come first, then the
+// conversation summary takes at most half of the budget. Returns the turns as chat messages, the
+// formatted summary, the tokens left for retrieved conversation and the time of the oldest turn kept.
+func (pb *PromptBuilder) fitHistory(ctx context.Context, chatID string, recent []vector.Message, budget *TokenBudget) ([]llm.ChatMessage, string, int, time.Time) {
+	turns, used, since := fitRecentTurns(recent, budget.Tokenizer, budget.HistoryBudget)
+	if len(turns) > 0 {
+		logging.Debug("Sending %d recent turns verbatim (%d tokens)", len(turns)/2, used)
+	}
+
+	summaryContext := pb.buildSummaryContext(ctx, chatID, budget.Tokenizer, min(budget.HistoryBudget/2, budget.HistoryBudget-used))
+	used += budget.Tokenizer.CountTokens(summaryContext)
+
+	return turns, summaryContext, budget.HistoryBudget - used, since
+}
+
+// budgetFor calculates the token budget of a prompt for chat
+func (pb *PromptBuilder) budgetFor(chat *vector.Chat, isCodeFile bool, tokenizer Tokenizer) *TokenBudget {
+	contextWindow := chat.ContextWindow
+	if contextWindow <= 0 {
+		contextWindow = 4096 // Fallback to default
+	}
+
+	maxTokens := chat.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 2048 // Fallback to default
+	}
+
+	return CalculateTokenBudgetForType(contextWindow, maxTokens, pb.config, isCodeFile, tokenizer)
+}
+
+// BuildPromptWithContext builds a simple prompt with user profile, conversation summary and
+// conversation context. The last turns of recent (see recentTurns) that fit into the history budget,
+// counted with tokenizer, are returned as chat messages to send before the prompt; retrieved
+// context repeating them is left out.
+func (pb *PromptBuilder) BuildPromptWithContext(ctx context.Context, chat *vector.Chat, contextMessages []vector.Message, recent []vector.Message, userMessage string, tokenizer Tokenizer) (string, []llm.ChatMessage) {
 	var builder strings.Builder
 
+	turns, summaryContext, _, since := pb.fitHistory(ctx, chat.ID, recent, pb.budgetFor(chat, false, tokenizer))
+
 	// Add user profile context if available
-	profile, err := pb.vectorStore.GetUserProfile(ctx, chatID)
+	profile, err := pb.userProfile(ctx, chat.ID)
 	if err != nil {
 		logging.Debug("Failed to retrieve user profile: %v", err)
 	} else if profile != nil && len(profile.Facts) > 0 {
@@ -106,9 +187,12 @@ func (pb *PromptBuilder) BuildPromptWithContext(ctx context.Context, chatID stri
 		}
 	}
 
+	// Add the overall thread of the conversation
+	builder.WriteString(summaryContext)
+
 	// Filter out messages with identical content to current message (to avoid treating first message as context)
 	var relevantContext []vector.Message
-	for _, msg := range contextMessages {
+	for _, msg := range withoutCoveredContext(contextMessages, since) {
 		if msg.Content != userMessage {
 			relevantContext = append(relevantContext, msg)
 		}
@@ -126,7 +210,7 @@ func (pb *PromptBuilder) BuildPromptWithContext(ctx context.Context, chatID stri
 	builder.WriteString("User's question or message to you: ")
 	builder.WriteString(userMessage)
 
-	return builder.String()
+	return builder.String(), turns
 }
 
 // BuildPromptWithDocuments builds a prompt with document context (no file list)
@@ -170,20 +254,14 @@ func (pb *PromptBuilder) BuildPromptWithDocuments(systemPrompt string, contextMe
 	return builder.String()
 }
 
-// BuildPromptWithContextAndDocumentsAndFileList builds a comprehensive prompt with file list, excerpts, and history
-func (pb *PromptBuilder) BuildPromptWithContextAndDocumentsAndFileList(ctx context.Context, chat *vector.Chat, contextMessages []vector.Message, contextChunks []vector.DocumentChunk, allDocs []vector.Document, userMessage string) string {
+// BuildPromptWithContextAndDocumentsAndFileList builds a comprehensive prompt with file list, excerpts, and history.
+// Budgets are counted with the tokenizer of the model the prompt is for. Each excerpt is labelled with
+// a citation ID the model is asked to cite; the returned sources map those IDs to the excerpts that fit.
+// The last turns of recent that fit into the history budget are returned as chat messages to send
+// before the prompt, as in BuildPromptWithContext.
+func (pb *PromptBuilder) BuildPromptWithContextAndDocumentsAndFileList(ctx context.Context, chat *vector.Chat, contextMessages []vector.Message, contextChunks []vector.DocumentChunk, allDocs []vector.Document, recent []vector.Message, userMessage string, tokenizer Tokenizer) (string, []llm.ChatMessage, []vector.Source) {
 	var builder strings.Builder
-
-	// Calculate token budgets
-	contextWindow := chat.ContextWindow
-	if contextWindow <= 0 {
-		contextWindow = 4096 // Fallback to default
-	}
-
-	maxTokens := chat.MaxTokens
-	if maxTokens <= 0 {
-		maxTokens = 2048 // Fallback to default
-	}
+	var sources []vector.Source
 
 	// Detect if we're working with code files
 	isCodeFile := false
@@ -196,10 +274,10 @@ func (pb *PromptBuilder) BuildPromptWithContextAndDocumentsAndFileList(ctx conte
 	}
 
 	// Use appropriate budget configuration
-	budget := CalculateTokenBudgetForType(contextWindow, maxTokens, pb.config, isCodeFile)
+	budget := pb.budgetFor(chat, isCodeFile, tokenizer)
 
 	// Add user profile context if available
-	profile, err := pb.vectorStore.GetUserProfile(ctx, chat.ID)
+	profile, err := pb.userProfile(ctx, chat.ID)
 	if err != nil {
 		logging.Debug("Failed to retrieve user profile: %v", err)
 	} else if profile != nil && len(profile.Facts) > 0 {
@@ -223,15 +301,17 @@ func (pb *PromptBuilder) BuildPromptWithContextAndDocumentsAndFileList(ctx conte
 	// Layer 1: Document overview (uses FileListBudget)
 	if len(allDocs) > 0 {
 		builder.WriteString("# Available Documents\n")
-		fileListChars := budget.FileListBudget * CharsPerToken
+		fileListTokensRemaining := budget.FileListBudget
 
 		var fileListBuilder strings.Builder
 		for i, doc := range allDocs {
 			line := fmt.Sprintf("%d. %s (%d chunks)\n", i+1, doc.FileName, doc.ChunkCount)
-			if fileListBuilder.Len()+len(line) > fileListChars {
+			lineTokens := budget.Tokenizer.CountTokens(line)
+			if lineTokens > fileListTokensRemaining {
 				break // Stop if we exceed budget
 			}
 			fileListBuilder.WriteString(line)
+			fileListTokensRemaining -= lineTokens
 		}
 		builder.WriteString(fileListBuilder.String())
 		builder.WriteString("\n")
@@ -241,39 +321,46 @@ func (pb *PromptBuilder) BuildPromptWithContextAndDocumentsAndFileList(ctx conte
 	if len(contextChunks) > 0 {
 		builder.WriteString("# Relevant Information\n\n")
 
-		excerptCharsRemaining := budget.ExcerptsBudget * CharsPerToken
+		excerptTokensRemaining := budget.ExcerptsBudget
 		extractor := document.NewExtractor()
+		locator := newCitationLocator(allDocs)
 
 		for _, chunk := range contextChunks {
-			if excerptCharsRemaining <= 0 {
-				break // Budget exhausted
+			if excerptTokensRemaining < 15 {
+				break // Not enough space for meaningful excerpt
 			}
 
-			// Calculate max excerpt size for this chunk
-			maxExcerptSize := 500
-			if excerptCharsRemaining < maxExcerptSize {
-				maxExcerptSize = excerptCharsRemaining
-			}
+			// The extractor works in characters; the result is measured in tokens afterwards
+			excerpt := extractor.ExtractRelevantExcerptWithPath(chunk.Content, userMessage, 500, chunk.FilePath)
 
-			if maxExcerptSize < 50 {
-				break // Not enough space for meaningful excerpt
-			}
+			// The label shows the file name only; the source keeps the full path
+			source := locator.locate(chunk, citationID(len(sources)))
+			label := source
+			label.FilePath = filepath.Base(chunk.FilePath)
 
-			excerpt := extractor.ExtractRelevantExcerptWithPath(chunk.Content, userMessage, maxExcerptSize, chunk.FilePath)
-			fileName := filepath.Base(chunk.FilePath)
+			header := fmt.Sprintf("[%s] %s\n", source.ID, label.Location())
+			available := excerptTokensRemaining - budget.Tokenizer.CountTokens(header+"\n\n")
+			excerpt = TruncateToTokens(budget.Tokenizer, excerpt, available)
+			if excerpt == "" {
+				break // Budget exhausted
+			}
 
-			chunkText := fmt.Sprintf("[%s]\n%s\n\n", fileName, excerpt)
+			chunkText := header + excerpt + "\n\n"
 			builder.WriteString(chunkText)
+			sources = append(sources, source)
 
-			excerptCharsRemaining -= len(chunkText)
+			excerptTokensRemaining -= budget.Tokenizer.CountTokens(chunkText)
 		}
 		builder.WriteString("---\n\n")
 	}
 
-	// Layer 3: Conversation history (uses HistoryBudget)
+	// Layer 3: Conversation summary and history (share HistoryBudget with the recent turns sent as messages)
+	turns, summaryContext, historyTokensRemaining, since := pb.fitHistory(ctx, chat.ID, recent, budget)
+	builder.WriteString(summaryContext)
+
 	// Filter out messages with identical content to current message (to avoid treating first message as context)
 	var relevantContext []vector.Message
-	for _, msg := range contextMessages {
+	for _, msg := range withoutCoveredContext(contextMessages, since) {
 		if msg.Content != userMessage {
 			relevantContext = append(relevantContext, msg)
 		}
@@ -282,40 +369,40 @@ func (pb *PromptBuilder) BuildPromptWithContextAndDocumentsAndFileList(ctx conte
 	if len(relevantContext) > 0 {
 		builder.WriteString("# Previous Conversation History\n")
 
-		historyCharsRemaining := budget.HistoryBudget * CharsPerToken
-
 		for _, msg := range relevantContext {
-			if historyCharsRemaining <= 0 {
-				break // Budget exhausted
-			}
+			label := fmt.Sprintf("[%s]: ", msg.Role)
+			maxContentTokens := historyTokensRemaining - budget.Tokenizer.CountTokens(label+"\n")
 
-			content := msg.Content
-			maxContentSize := historyCharsRemaining - 20 // Reserve space for role label and formatting
-
-			if maxContentSize < 20 {
+			if maxContentTokens < 5 {
 				break // Not enough space
 			}
 
-			if len(content) > maxContentSize {
-				content = content[:maxContentSize-3] + "..."
-			}
+			content := TruncateToTokens(budget.Tokenizer, msg.Content, maxContentTokens)
 
-			msgText := fmt.Sprintf("[%s]: %s\n", msg.Role, content)
+			msgText := label + content + "\n"
 			builder.WriteString(msgText)
-			historyCharsRemaining -= len(msgText)
+			historyTokensRemaining -= budget.Tokenizer.CountTokens(msgText)
 		}
 		builder.WriteString("\n---\n\n")
 	}
 
 	// Instruction to use context
-	if len(allDocs) > 0 || len(contextChunks) > 0 || len(relevantContext) > 0 {
-		builder.WriteString("Use the above information to help answer the user's question.\n\n")
-		builder.WriteString("---\n\n")
+	if len(allDocs) > 0 || len(contextChunks) > 0 || len(relevantContext) > 0 || summaryContext != "" {
+		builder.WriteString("Use the above information to help answer the user's question.\n")
+		if len(sources) > 0 {
+			builder.WriteString("Cite the excerpts you rely on by their labels in square brackets, e.g. [S1]. Only cite labels listed above.\n")
+		}
+		builder.WriteString("\n---\n\n")
 	}
 
 	// Current query (full detail - not budget-limited as it's essential)
 	builder.WriteString("# User's question or message to you: ")
 	builder.WriteString(userMessage)
 
-	return builder.String()
+	prompt := builder.String()
+	if promptTokens := budget.Tokenizer.CountTokens(chat.SystemPrompt + prompt); promptTokens > budget.AvailableInput {
+		logging.Info("Prompt takes %d tokens, exceeding the input budget of %d", promptTokens, budget.AvailableInput)
+	}
+
+	return prompt, turns, sources
 }
@@ -0,0 +1,190 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/vector"
+)
+
+const (
+	rewriteHistoryTurns = 3   // Earlier question/answer turns shown to the LLM when rewriting
+	rewriteMessageChars = 600 // Longer history messages are cut, answers can be long
+	maxSubQueries       = 3   // Sub-queries generated per message in multi-query mode
+)
+
+// QueryRewriter prepares retrieval queries with the LLM. Follow-ups like "and how does it handle
+// errors?" embed poorly on their own, so they are rewritten into standalone queries using the
+// recent conversation, and can be expanded into several queries whose results are fused.
+type QueryRewriter struct {
+	backend llm.Backend
+}
+
+// NewQueryRewriter creates a new query rewriter
+func NewQueryRewriter(backend llm.Backend) *QueryRewriter {
+	return &QueryRewriter{
+		backend: backend,
+	}
+}
+
+// Rewrite returns message as a standalone search query, resolving references to the last turns of
+// history (all chat messages before message, oldest first). A message without earlier turns is
+// returned unchanged without asking the LLM.
+func (qr *QueryRewriter) Rewrite(ctx context.Context, llmModel, message string, history []vector.Message) (string, error) {
+	turns := recentTurns(history, rewriteHistoryTurns)
+	if len(turns) == 0 {
+		return message, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Rewrite the user's last message as a standalone search query for finding relevant documents ")
+	prompt.WriteString("and earlier conversation. Replace references like \"it\" or \"that function\" with what they ")
+	prompt.WriteString("refer to in the conversation. Keep file names, function names and other identifiers exactly as written. ")
+	prompt.WriteString("If the message already stands on its own, return it unchanged.\n\n")
+	prompt.WriteString("Conversation:\n")
+	for _, msg := range turns {
+		prompt.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, truncateRunes(msg.Content, rewriteMessageChars)))
+	}
+	prompt.WriteString(fmt.Sprintf("\nLast message: %s\n\n", message))
+	prompt.WriteString("Respond ONLY with the search query on a single line.")
+
+	response, err := qr.complete(ctx, llmModel, prompt.String(), 200)
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite query: %w", err)
+	}
+
+	query := firstLine(response)
+	if query == "" {
+		return "", fmt.Errorf("failed to rewrite query: LLM returned an empty query")
+	}
+	return query, nil
+}
+
+// Expand returns further queries to retrieve with alongside query: sub-queries for its different
+// aspects, then a hypothetical answer (HyDE), whose embedding tends to lie closer to the passages
+// that answer the question than the question's own.
+func (qr *QueryRewriter) Expand(ctx context.Context, llmModel, query string) ([]string, error) {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Write up to %d short search queries that each look for a different aspect of the ", maxSubQueries))
+	prompt.WriteString("question below. Keep file names, function names and other identifiers exactly as written.\n\n")
+	prompt.WriteString(fmt.Sprintf("Question: %s\n\n", query))
+	prompt.WriteString("Respond ONLY with the queries, one per line, without numbering.")
+
+	response, err := qr.complete(ctx, llmModel, prompt.String(), 300)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate sub-queries: %w", err)
+	}
+	queries := parseSubQueries(response, query)
+
+	prompt.Reset()
+	prompt.WriteString("Write a short passage of at most three sentences that answers the question below, the way ")
+	prompt.WriteString("documentation or source code would. It is only used to search for similar text, ")
+	prompt.WriteString("so plausible details are fine.\n\n")
+	prompt.WriteString(fmt.Sprintf("Question: %s\n\n", query))
+	prompt.WriteString("Respond ONLY with the passage.")
+
+	response, err = qr.complete(ctx, llmModel, prompt.String(), 200)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate hypothetical answer: %w", err)
+	}
+	if passage := strings.TrimSpace(response); passage != "" {
+		queries = append(queries, passage)
+	}
+
+	return queries, nil
+}
+
+// complete asks the LLM for a short, near-deterministic answer
+func (qr *QueryRewriter) complete(ctx context.Context, llmModel, prompt string, maxTokens int) (string, error) {
+	return qr.backend.ChatCompletionSync(ctx, llm.ChatCompletionRequest{
+		Model: llmModel,
+		Messages: []llm.ChatMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.1, // Low temperature keeps queries close to the question
+		MaxTokens:   maxTokens,
+		Stream:      false,
+	})
+}
+
+// listMarker matches bullets and numbering models put in front of list items despite being told not to
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s*`)
+
+// parseSubQueries returns the distinct queries of a one-per-line response, leaving out query itself
+func parseSubQueries(response, query string) []string {
+	seen := map[string]bool{strings.ToLower(query): true}
+	var queries []string
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.Trim(listMarker.ReplaceAllString(strings.TrimSpace(line), ""), "\"'` ")
+		if line == "" || seen[strings.ToLower(line)] {
+			continue
+		}
+		seen[strings.ToLower(line)] = true
+		queries = append(queries, line)
+		if len(queries) == maxSubQueries {
+			break
+		}
+	}
+	return queries
+}
+
+// firstLine returns the first non-empty line of response without surrounding quotes
+func firstLine(response string) string {
+	for _, line := range strings.Split(response, "\n") {
+		if line = strings.Trim(strings.TrimSpace(line), "\"'`"); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncateRunes cuts s to at most n runes, marking the cut
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+// fuseRankings merges the results of several queries by reciprocal rank fusion with rank constant k,
+// so items found by more than one query come first. As many items are kept as the longest ranking
+// holds, leaving later steps the same number of candidates as a single query would.
+func fuseRankings[T any](rankings [][]T, k int, id func(T) string) []T {
+	scores := make(map[string]float64)
+	items := make(map[string]T)
+	var order []string
+	limit := 0
+
+	for _, ranking := range rankings {
+		if len(ranking) > limit {
+			limit = len(ranking)
+		}
+		for rank, item := range ranking {
+			key := id(item)
+			if _, ok := scores[key]; !ok {
+				order = append(order, key)
+				items[key] = item
+			}
+			scores[key] += 1 / float64(k+rank+1)
+		}
+	}
+
+	// Stable sort keeps the first query's order for ties
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	result := make([]T, len(order))
+	for i, key := range order {
+		result[i] = items[key]
+	}
+	return result
+}
@@ -6,9 +6,9 @@ import (
 	"time"
 
 	"rag-terminal/internal/document"
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/logging"
 	"rag-terminal/internal/models"
-	"rag-terminal/internal/nexa"
 	"rag-terminal/internal/vector"
 )
 
@@ -23,43 +23,66 @@ func (p *RAGPipeline) ProcessUserMessage(
 	chat *vector.Chat,
 	llmModel, embedModel string,
 	userMessage string,
-) (<-chan string, <-chan error, error) {
-	// Step 1: Generate embedding for user message (for retrieval purposes)
-	embeddings, err := p.nexaClient.GenerateEmbeddings(ctx, embedModel, []string{userMessage}, &p.config.EmbeddingDimensions)
+) (<-chan models.Event, <-chan error) {
+	return streamEvents(func(events chan<- models.Event) error {
+		return p.answer(ctx, chat, llmModel, embedModel, userMessage, events)
+	})
+}
+
+// answer runs retrieval and generation, reporting each stage and the cited sources on events
+func (p *RAGPipeline) answer(
+	ctx context.Context,
+	chat *vector.Chat,
+	llmModel, embedModel string,
+	userMessage string,
+	events chan<- models.Event,
+) error {
+	session, err := p.vectorStore.OpenChat(ctx, chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	// The conversation so far, for query rewriting and the recent turns sent with the prompt
+	history, err := session.GetMessages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chat history: %w", err)
+	}
+	recent := recentTurns(history, p.config.GetRecentTurns())
+
+	// Step 1: Optionally rewrite the message into standalone search queries, using the turns before it
+	queries := p.searchQueries(ctx, chat, llmModel, userMessage, history, events)
+	searchQuery := queries[0]
+
+	// Step 2: Generate embeddings for the search queries (for retrieval purposes)
+	events <- models.StageEvent(models.StageEmbedding)
+	queryEmbeddings, err := p.embedQueries(ctx, embedModel, queries)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate user message embedding: %w", err)
+		return err
 	}
-	userEmbedding := embeddings[0]
 
-	// Step 2: Store user message WITHOUT embedding (will be embedded as Q&A pair later)
+	// Step 3: Store user message WITHOUT embedding (will be embedded as Q&A pair later)
 	userMsg := models.NewMessage(chat.ID, "user", userMessage)
-	if err := p.vectorStore.StoreMessage(ctx, userMsg.ID, "user", userMessage, []float32{}, time.Now()); err != nil {
-		return nil, nil, fmt.Errorf("failed to store user message: %w", err)
+	if err := session.StoreMessage(ctx, userMsg.ID, "user", userMessage, []float32{}, time.Now()); err != nil {
+		return fmt.Errorf("failed to store user message: %w", err)
 	}
 
-	// Step 3: Search for similar content (Q&A pairs and document chunks only)
+	// Step 4: Search for similar content (Q&A pairs and document chunks only)
+	events <- models.StageEvent(models.StageRetrieving)
 	retrievalTopK := chat.TopK * 2
 	if !chat.UseReranking {
 		retrievalTopK = chat.TopK
 	}
 
-	var contextMessages []vector.Message
-	var contextChunks []vector.DocumentChunk
-
-	badgerStore, ok := p.vectorStore.(*vector.BadgerStore)
-	if !ok {
-		return nil, nil, fmt.Errorf("vector store is not BadgerStore")
-	}
-
 	// Search for similar Q&A pairs and document chunks (not individual user/assistant messages)
-	contextMessages, contextChunks, err = badgerStore.SearchSimilarContextAndChunks(ctx, userEmbedding, retrievalTopK)
+	contextMessages, contextChunks, err := p.searchContextAndChunks(ctx, session, queries, queryEmbeddings, retrievalTopK)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to search similar content: %w", err)
+		return fmt.Errorf("failed to search similar content: %w", err)
 	}
 
 	// Check if user mentioned specific filenames - prioritize chunks from those files
-	allDocs, _ := badgerStore.GetDocuments(ctx)
-	mentionedFiles := p.documentManager.FindMentionedFiles(userMessage, allDocs)
+	allDocs, _ := session.GetDocuments(ctx)
+	mentionedFiles := p.documentManager.FindMentionedFiles(searchQuery, allDocs)
 	userMentionedFile := len(mentionedFiles) > 0
 
 	if userMentionedFile {
@@ -67,7 +90,7 @@ func (p *RAGPipeline) ProcessUserMessage(
 		filteredChunks := p.documentManager.FilterChunksByFiles(contextChunks, mentionedFiles)
 		if len(filteredChunks) == 0 {
 			logging.Info("No similar chunks from %v, fetching all chunks from files", mentionedFiles)
-			filteredChunks = p.documentManager.GetAllChunksFromFiles(ctx, mentionedFiles)
+			filteredChunks = p.documentManager.GetAllChunksFromFiles(ctx, chat, mentionedFiles)
 		}
 		contextChunks = filteredChunks
 		logging.Debug("After filename filtering: %d chunks from %d files", len(contextChunks), len(mentionedFiles))
@@ -84,12 +107,19 @@ func (p *RAGPipeline) ProcessUserMessage(
 		}
 	}
 
-	// Step 4: Optional LLM-based reranking (only for messages for now)
+	// Step 5: Optional reranking of context messages and document chunks
+	// (chunks are left alone if smart prioritization for code was already applied)
+	appliedSmartPrioritization := userMentionedFile && len(contextChunks) > 0 && document.IsCodeFile(contextChunks[0].FilePath)
+
+	if chat.UseReranking && (len(contextMessages) > 0 || (!appliedSmartPrioritization && len(contextChunks) > 0)) {
+		events <- models.StageEvent(models.StageReranking)
+	}
 	if chat.UseReranking && len(contextMessages) > 0 {
-		reranked, err := p.rerankMessagesWithLLM(ctx, llmModel, userMessage, contextMessages, chat.TopK/2)
+		reranked, err := p.rerankMessages(ctx, llmModel, searchQuery, contextMessages, chat.TopK/2)
 		if err == nil {
 			contextMessages = reranked
 		} else {
+			events <- models.WarningEvent("Reranking of conversation history failed, using similarity order: %v", err)
 			if len(contextMessages) > chat.TopK/2 {
 				contextMessages = contextMessages[:chat.TopK/2]
 			}
@@ -100,28 +130,36 @@ func (p *RAGPipeline) ProcessUserMessage(
 		}
 	}
 
-	// Limit document chunks (skip if we already applied smart prioritization for code)
-	appliedSmartPrioritization := userMentionedFile && len(contextChunks) > 0 && document.IsCodeFile(contextChunks[0].FilePath)
-
-	if !appliedSmartPrioritization && len(contextChunks) > chat.TopK/2 {
-		contextChunks = contextChunks[:chat.TopK/2]
+	// Limit document chunks
+	if !appliedSmartPrioritization {
+		if chat.UseReranking {
+			reranked, err := p.rerankChunks(ctx, searchQuery, contextChunks, chat.TopK/2)
+			if err != nil {
+				logging.Error("Chunk reranking failed, keeping retrieval order: %v", err)
+				events <- models.WarningEvent("Reranking of document excerpts failed, using similarity order: %v", err)
+			}
+			contextChunks = reranked
+		} else if len(contextChunks) > chat.TopK/2 {
+			contextChunks = contextChunks[:chat.TopK/2]
+		}
 	}
 
-	// Step 5: Build prompt with context
+	// Step 6: Build prompt with context and the recent turns, counting budgets with the model's tokenizer
+	tokenizer := p.tokenizers.forModel(llmModel)
+
 	var prompt string
+	var turns []llm.ChatMessage
+	var sources []vector.Source
 	if len(contextChunks) > 0 {
-		prompt = p.buildPromptWithContextAndDocumentsAndFileList(ctx, chat, contextMessages, contextChunks, allDocs, userMessage)
+		prompt, turns, sources = p.buildPromptWithContextAndDocumentsAndFileList(ctx, chat, contextMessages, contextChunks, allDocs, recent, userMessage, tokenizer)
 	} else {
-		prompt = p.buildPromptWithContext(ctx, chat.ID, chat.SystemPrompt, contextMessages, userMessage)
+		prompt, turns = p.buildPromptWithContext(ctx, chat, contextMessages, recent, userMessage, tokenizer)
 	}
 
-	// Step 6: Call chat completion
-	req := nexa.ChatCompletionRequest{
-		Model: llmModel,
-		Messages: []nexa.ChatMessage{
-			{Role: "system", Content: chat.SystemPrompt},
-			{Role: "user", Content: prompt},
-		},
+	// Step 7: Call chat completion
+	req := llm.ChatCompletionRequest{
+		Model:       llmModel,
+		Messages:    chatMessages(chat.SystemPrompt, turns, prompt),
 		Temperature: chat.Temperature,
 		MaxTokens:   chat.MaxTokens,
 		TopK:        chat.TopK,
@@ -129,28 +167,28 @@ func (p *RAGPipeline) ProcessUserMessage(
 		Stream:      true,
 	}
 
-	streamChan, errChan, err := p.nexaClient.ChatCompletion(ctx, req)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to start chat completion: %w", err)
+	// Set by the backend before the stream closes, so it is visible to onComplete
+	var reported *llm.Usage
+	req.OnUsage = func(usage llm.Usage) {
+		reported = &usage
 	}
 
-	// Step 7: Collect response and store assistant message
-	responseChan := make(chan string, 10)
-	finalErrChan := make(chan error, 1)
-
-	go func() {
-		defer close(responseChan)
-		defer close(finalErrChan)
-
-		// Use helper to collect stream and store completion pair with fact extraction
-		err := p.collectStreamedResponse(ctx, streamChan, errChan, responseChan, func(fullResponse string) error {
-			return p.storeCompletionPairWithExtraction(ctx, chat, llmModel, embedModel, userMessage, fullResponse)
-		})
+	if len(sources) > 0 {
+		events <- models.SourcesEvent(sources)
+	}
+	events <- models.StageEvent(models.StageGenerating)
+	streamChan, errChan, err := p.backend.ChatCompletion(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to start chat completion: %w", err)
+	}
 
-		if err != nil {
-			finalErrChan <- err
+	// Step 8: Collect response and store completion pair with fact extraction
+	return p.collectStreamedResponse(ctx, streamChan, errChan, events, func(fullResponse string) error {
+		usage := responseUsage(tokenizer, reported, req.Messages, fullResponse)
+		if err := p.storeCompletionPairWithExtraction(ctx, chat, llmModel, embedModel, userMessage, fullResponse, usage, sources, tokenizer); err != nil {
+			return err
 		}
-	}()
-
-	return responseChan, finalErrChan, nil
+		events <- models.UsageEvent(usage)
+		return nil
+	})
 }
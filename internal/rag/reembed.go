@@ -0,0 +1,169 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/vector"
+)
+
+// reembedBatchSize is the number of texts sent per embedding request and stored per transaction
+const reembedBatchSize = 32
+
+// Reembedder moves a chat to another embedding model by regenerating all of its vectors.
+// Progress is checkpointed in the chat database, so an interrupted run resumes where it stopped.
+type Reembedder struct {
+	vectorStore vector.VectorStore
+	backend     llm.Backend
+
+	mu      sync.Mutex
+	running map[string]bool // Chats being re-embedded, by chat ID
+}
+
+// NewReembedder creates a new reembedder
+func NewReembedder(vectorStore vector.VectorStore, backend llm.Backend) *Reembedder {
+	return &Reembedder{
+		vectorStore: vectorStore,
+		backend:     backend,
+		running:     make(map[string]bool),
+	}
+}
+
+// ReembedChat embeds every document chunk and context message of a chat again with embedModel,
+// rebuilds the HNSW index from the new vectors and records the model on the chat.
+// A checkpoint left by an interrupted run for the same model is resumed, skipping the records it
+// re-embedded that haven't been replaced since; one for another model is discarded. progress, if set, is called after each batch with the number of vectors done
+// and the total.
+func (r *Reembedder) ReembedChat(ctx context.Context, chatID, embedModel string, dimensions int, progress func(done, total int)) error {
+	badgerStore, ok := r.vectorStore.(*vector.BadgerStore)
+	if !ok {
+		return fmt.Errorf("vector store is not BadgerStore type")
+	}
+
+	if !r.start(chatID) {
+		return fmt.Errorf("chat %s is already being re-embedded", chatID)
+	}
+	defer r.finish(chatID)
+
+	session, err := r.vectorStore.OpenChat(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to open chat: %w", err)
+	}
+	defer session.Close()
+
+	texts, err := session.EmbeddedTexts(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Skip what an interrupted run already embedded
+	pending := texts
+	checkpoint, err := session.GetReembedCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+	if checkpoint != nil && checkpoint.EmbedModel == embedModel && checkpoint.Dimensions == dimensions {
+		pending = make([]vector.EmbeddedText, 0, len(texts))
+		for _, text := range texts {
+			if !text.Reembedded {
+				pending = append(pending, text)
+			}
+		}
+		logging.Info("Resuming re-embedding of chat %s at %d/%d", chatID, len(texts)-len(pending), len(texts))
+	} else {
+		if checkpoint != nil {
+			logging.Info("Discarding re-embedding checkpoint of chat %s for %s", chatID, checkpoint.EmbedModel)
+			if err := session.ClearReembedCheckpoint(ctx); err != nil {
+				return err
+			}
+		}
+		logging.Info("Re-embedding %d vectors of chat %s with %s (%d dimensions)", len(texts), chatID, embedModel, dimensions)
+	}
+
+	done := len(texts) - len(pending)
+	if progress != nil {
+		progress(done, len(texts))
+	}
+
+	for start := 0; start < len(pending); start += reembedBatchSize {
+		end := start + reembedBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+
+		contents := make([]string, len(batch))
+		for i, text := range batch {
+			contents[i] = text.Content
+		}
+
+		embeddings, err := r.backend.GenerateEmbeddings(ctx, embedModel, contents, &dimensions)
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		if len(embeddings) != len(batch) {
+			return fmt.Errorf("failed to generate embeddings: expected %d, got %d", len(batch), len(embeddings))
+		}
+
+		updates := make(map[string][]float32, len(batch))
+		for i, text := range batch {
+			updates[text.ID] = embeddings[i]
+		}
+		err = session.UpdateEmbeddings(ctx, updates, vector.ReembedCheckpoint{
+			EmbedModel: embedModel,
+			Dimensions: dimensions,
+		})
+		if err != nil {
+			return err
+		}
+
+		done += len(batch)
+		if progress != nil {
+			progress(done, len(texts))
+		}
+	}
+
+	if err := session.RebuildIndex(ctx); err != nil {
+		return err
+	}
+
+	// Record the model before dropping the checkpoint; until then the chat shows as mismatched
+	chat, err := r.vectorStore.GetChat(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to get chat: %w", err)
+	}
+	chat.EmbedModel = embedModel
+	chat.EmbeddingDimensions = dimensions
+
+	if err := badgerStore.UpdateChat(ctx, chat); err != nil {
+		return fmt.Errorf("failed to record embedding model: %w", err)
+	}
+	if err := session.ClearReembedCheckpoint(ctx); err != nil {
+		return err
+	}
+
+	logging.Info("Re-embedded chat %s with %s", chatID, embedModel)
+	return nil
+}
+
+// start marks a chat as being re-embedded, returning false if it already is
+func (r *Reembedder) start(chatID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running[chatID] {
+		return false
+	}
+	r.running[chatID] = true
+	return true
+}
+
+func (r *Reembedder) finish(chatID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.running, chatID)
+}
@@ -0,0 +1,141 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/vector"
+)
+
+// recordingEmbedder embeds texts as fixed vectors and records them. It fails once it has
+// served failAfter requests, if failAfter is positive.
+type recordingEmbedder struct {
+	failAfter int
+	requests  int
+	embedded  []string
+}
+
+func (e *recordingEmbedder) ChatCompletion(ctx context.Context, req llm.ChatCompletionRequest) (<-chan string, <-chan error, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
+func (e *recordingEmbedder) ChatCompletionSync(ctx context.Context, req llm.ChatCompletionRequest) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (e *recordingEmbedder) GenerateEmbeddings(ctx context.Context, model string, texts []string, dimensions *int) ([][]float32, error) {
+	if e.failAfter > 0 && e.requests >= e.failAfter {
+		return nil, errors.New("embedding server went away")
+	}
+	e.requests++
+	e.embedded = append(e.embedded, texts...)
+
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		embeddings[i] = []float32{0, 1, float32(i)}
+	}
+	return embeddings, nil
+}
+
+func (e *recordingEmbedder) Rerank(ctx context.Context, req llm.RerankingRequest) ([]float64, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *recordingEmbedder) GetModels() ([]llm.Model, error) { return nil, nil }
+
+func (e *recordingEmbedder) Ping(ctx context.Context) error { return nil }
+
+func TestReembedResumeIncludesRecordsStoredMeanwhile(t *testing.T) {
+	ctx := context.Background()
+	store, err := vector.NewBadgerStore(t.TempDir(), vector.KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	chat := &vector.Chat{ID: "chat-1", Name: "test", EmbedModel: "old", EmbeddingDimensions: 3}
+	if err := store.StoreChat(ctx, chat); err != nil {
+		t.Fatal(err)
+	}
+	session, err := store.OpenChat(ctx, chat.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	storeChunk := func(n int) {
+		t.Helper()
+		chunk := &vector.DocumentChunk{
+			ID:         fmt.Sprintf("chunk-%02d", n),
+			DocumentID: "doc-1",
+			ChatID:     chat.ID,
+			Content:    fmt.Sprintf("text %02d", n),
+			Embedding:  []float32{1, 0, float32(n)},
+		}
+		if err := session.StoreDocumentChunk(ctx, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for n := 10; n < 10+reembedBatchSize+8; n++ {
+		storeChunk(n)
+	}
+
+	// The first run stores one batch, then the backend fails
+	interrupted := &recordingEmbedder{failAfter: 1}
+	if err := NewReembedder(store, interrupted).ReembedChat(ctx, chat.ID, "new", 3, nil); err == nil {
+		t.Fatal("interrupted re-embedding reported success")
+	}
+	if len(interrupted.embedded) != reembedBatchSize {
+		t.Fatalf("interrupted run embedded %d texts, want %d", len(interrupted.embedded), reembedBatchSize)
+	}
+
+	// Meanwhile a record sorting before the finished batch is added, and one of the batch is rewritten
+	storeChunk(0)
+	rewritten := &vector.DocumentChunk{ID: "chunk-10", DocumentID: "doc-1", ChatID: chat.ID, Content: "text 10", Embedding: []float32{1, 0, 99}}
+	if err := session.StoreDocumentChunk(ctx, rewritten); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := &recordingEmbedder{}
+	var lastDone, lastTotal int
+	err = NewReembedder(store, resumed).ReembedChat(ctx, chat.ID, "new", 3, func(done, total int) {
+		lastDone, lastTotal = done, total
+	})
+	if err != nil {
+		t.Fatalf("resumed re-embedding failed: %v", err)
+	}
+
+	want := []string{"text 00", "text 10"}
+	for n := 10 + reembedBatchSize; n < 10+reembedBatchSize+8; n++ {
+		want = append(want, fmt.Sprintf("text %02d", n))
+	}
+	got := append([]string(nil), resumed.embedded...)
+	sort.Strings(got)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("resumed run embedded %v, want %v", got, want)
+	}
+	if lastDone != lastTotal || lastTotal != reembedBatchSize+9 {
+		t.Errorf("final progress %d/%d, want %d/%d", lastDone, lastTotal, reembedBatchSize+9, reembedBatchSize+9)
+	}
+
+	checkpoint, err := session.GetReembedCheckpoint(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint != nil {
+		t.Error("checkpoint left after a completed re-embedding")
+	}
+	texts, err := session.EmbeddedTexts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range texts {
+		if text.Reembedded {
+			t.Errorf("%s still marked as re-embedded after completion", text.ID)
+		}
+	}
+}
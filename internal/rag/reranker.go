@@ -0,0 +1,132 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/vector"
+)
+
+// Reranker reorders retrieved document chunks and context messages by relevance to the query.
+// With a dedicated reranking model it scores both through the backend's reranking endpoint;
+// without one, messages fall back to LLM scoring and chunks keep their retrieval order.
+type Reranker struct {
+	backend          llm.Backend
+	messageProcessor *MessageProcessor
+	model            string // Optional reranking model, empty when none was selected
+}
+
+// NewReranker creates a new reranker
+func NewReranker(backend llm.Backend, messageProcessor *MessageProcessor, model string) *Reranker {
+	return &Reranker{
+		backend:          backend,
+		messageProcessor: messageProcessor,
+		model:            model,
+	}
+}
+
+// HasModel reports whether a dedicated reranking model is configured
+func (r *Reranker) HasModel() bool {
+	return r.model != ""
+}
+
+// RerankChunks returns the topK most relevant chunks. Without a reranking model the
+// retrieval order is kept, since asking the LLM to score whole chunks is too slow.
+func (r *Reranker) RerankChunks(ctx context.Context, query string, chunks []vector.DocumentChunk, topK int) ([]vector.DocumentChunk, error) {
+	if len(chunks) == 0 || !r.HasModel() {
+		return truncateChunks(chunks, topK), nil
+	}
+
+	documents := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		documents[i] = chunk.Content
+	}
+
+	order, err := r.rankWithModel(ctx, query, documents)
+	if err != nil {
+		return truncateChunks(chunks, topK), err
+	}
+
+	result := make([]vector.DocumentChunk, 0, len(order))
+	for _, i := range order {
+		result = append(result, chunks[i])
+	}
+	logging.Debug("Reranked %d chunks with %s", len(chunks), r.model)
+
+	return truncateChunks(result, topK), nil
+}
+
+// RerankMessages returns the topK most relevant context messages, using the reranking model
+// when available and LLM scoring otherwise or when the reranking endpoint fails.
+func (r *Reranker) RerankMessages(ctx context.Context, llmModel, query string, messages []vector.Message, topK int) ([]vector.Message, error) {
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	if r.HasModel() {
+		documents := make([]string, len(messages))
+		for i, msg := range messages {
+			documents[i] = msg.Content
+		}
+
+		order, err := r.rankWithModel(ctx, query, documents)
+		if err == nil {
+			result := make([]vector.Message, 0, len(order))
+			for _, i := range order {
+				result = append(result, messages[i])
+			}
+			logging.Debug("Reranked %d messages with %s", len(messages), r.model)
+			return truncateMessages(result, topK), nil
+		}
+
+		logging.Error("Reranking model failed, falling back to LLM scoring: %v", err)
+	}
+
+	return r.messageProcessor.RerankMessagesWithLLM(ctx, llmModel, query, messages, topK)
+}
+
+// rankWithModel scores documents with the reranking model and returns their indices by descending score
+func (r *Reranker) rankWithModel(ctx context.Context, query string, documents []string) ([]int, error) {
+	scores, err := r.backend.Rerank(ctx, llm.RerankingRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: documents,
+		Normalize: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank with %s: %w", r.model, err)
+	}
+
+	if len(scores) != len(documents) {
+		return nil, fmt.Errorf("reranker returned %d scores but expected %d", len(scores), len(documents))
+	}
+
+	order := make([]int, len(documents))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	return order, nil
+}
+
+// truncateChunks caps chunks to topK
+func truncateChunks(chunks []vector.DocumentChunk, topK int) []vector.DocumentChunk {
+	if len(chunks) > topK {
+		return chunks[:topK]
+	}
+	return chunks
+}
+
+// truncateMessages caps messages to topK
+func truncateMessages(messages []vector.Message, topK int) []vector.Message {
+	if len(messages) > topK {
+		return messages[:topK]
+	}
+	return messages
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"rag-terminal/internal/logging"
+	"rag-terminal/internal/models"
 )
 
 // ResponseProcessor handles stream collection and completion processing
@@ -20,12 +21,12 @@ func NewResponseProcessor(profileExtractor *ProfileExtractor) *ResponseProcessor
 	}
 }
 
-// CollectStreamedResponse collects tokens from a stream and calls onComplete when done
+// CollectStreamedResponse collects tokens from a stream, forwards them as token events and calls onComplete when done
 func (rp *ResponseProcessor) CollectStreamedResponse(
 	ctx context.Context,
 	streamChan <-chan string,
 	errChan <-chan error,
-	responseChan chan<- string,
+	events chan<- models.Event,
 	onComplete func(fullResponse string) error,
 ) error {
 	var fullResponse strings.Builder
@@ -43,8 +44,8 @@ func (rp *ResponseProcessor) CollectStreamedResponse(
 				return nil
 			}
 			fullResponse.WriteString(token)
-			if responseChan != nil {
-				responseChan <- token
+			if events != nil {
+				events <- models.TokenEvent(token)
 			}
 
 		case err := <-errChan:
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/models"
-	"rag-terminal/internal/nexa"
 	"rag-terminal/internal/vector"
 )
 
@@ -21,37 +21,69 @@ func (p *SimplePipeline) ProcessUserMessage(
 	chat *vector.Chat,
 	llmModel, embedModel string,
 	userMessage string,
-) (<-chan string, <-chan error, error) {
-	// Step 1: Generate embedding for user message
-	embeddings, err := p.nexaClient.GenerateEmbeddings(ctx, embedModel, []string{userMessage}, &p.config.EmbeddingDimensions)
+) (<-chan models.Event, <-chan error) {
+	return streamEvents(func(events chan<- models.Event) error {
+		return p.answer(ctx, chat, llmModel, embedModel, userMessage, events)
+	})
+}
+
+// answer runs the conversation steps, reporting each stage on events
+func (p *SimplePipeline) answer(
+	ctx context.Context,
+	chat *vector.Chat,
+	llmModel, embedModel string,
+	userMessage string,
+	events chan<- models.Event,
+) error {
+	session, err := p.vectorStore.OpenChat(ctx, chat.ID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate user message embedding: %w", err)
+		return fmt.Errorf("failed to open chat: %w", err)
 	}
-	userEmbedding := embeddings[0]
+	defer session.Close()
+
+	// The conversation so far, for query rewriting and the recent turns sent with the prompt
+	history, err := session.GetMessages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chat history: %w", err)
+	}
+	recent := recentTurns(history, p.config.GetRecentTurns())
+
+	// Step 1: Optionally rewrite the message into standalone search queries, using the turns before it
+	queries := p.searchQueries(ctx, chat, llmModel, userMessage, history, events)
 
-	// Step 2: Store user message WITHOUT embedding (will be embedded as Q&A pair later)
+	// Step 2: Generate embeddings for the search queries
+	events <- models.StageEvent(models.StageEmbedding)
+	queryEmbeddings, err := p.embedQueries(ctx, embedModel, queries)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Store user message WITHOUT embedding (will be embedded as Q&A pair later)
 	userMsg := models.NewMessage(chat.ID, "user", userMessage)
-	if err := p.vectorStore.StoreMessage(ctx, userMsg.ID, "user", userMessage, []float32{}, time.Now()); err != nil {
-		return nil, nil, fmt.Errorf("failed to store user message: %w", err)
+	if err := session.StoreMessage(ctx, userMsg.ID, "user", userMessage, []float32{}, time.Now()); err != nil {
+		return fmt.Errorf("failed to store user message: %w", err)
 	}
 
-	// Step 3: Search for similar Q&A pairs (conversation history only, no documents)
+	// Step 4: Search for similar Q&A pairs (conversation history only, no documents)
+	events <- models.StageEvent(models.StageRetrieving)
 	retrievalTopK := chat.TopK
 	if chat.UseReranking {
 		retrievalTopK = chat.TopK * 2
 	}
 
-	contextMessages, err := p.vectorStore.SearchSimilar(ctx, userEmbedding, retrievalTopK)
+	contextMessages, err := p.searchSimilar(ctx, session, queryEmbeddings, retrievalTopK)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to search similar messages: %w", err)
+		return fmt.Errorf("failed to search similar messages: %w", err)
 	}
 
-	// Step 4: Optional LLM-based reranking
+	// Step 5: Optional LLM-based reranking
 	if chat.UseReranking && len(contextMessages) > 0 {
-		reranked, err := p.rerankMessagesWithLLM(ctx, llmModel, userMessage, contextMessages, chat.TopK)
+		events <- models.StageEvent(models.StageReranking)
+		reranked, err := p.rerankMessages(ctx, llmModel, queries[0], contextMessages, chat.TopK)
 		if err == nil {
 			contextMessages = reranked
 		} else {
+			events <- models.WarningEvent("Reranking failed, using similarity order: %v", err)
 			if len(contextMessages) > chat.TopK {
 				contextMessages = contextMessages[:chat.TopK]
 			}
@@ -62,16 +94,14 @@ func (p *SimplePipeline) ProcessUserMessage(
 		}
 	}
 
-	// Step 5: Build simple prompt with conversation context and user profile
-	prompt := p.buildPromptWithContext(ctx, chat.ID, chat.SystemPrompt, contextMessages, userMessage)
+	// Step 6: Build simple prompt with conversation context, summary, user profile and the recent turns
+	tokenizer := p.tokenizers.forModel(llmModel)
+	prompt, turns := p.buildPromptWithContext(ctx, chat, contextMessages, recent, userMessage, tokenizer)
 
-	// Step 6: Call chat completion
-	req := nexa.ChatCompletionRequest{
-		Model: llmModel,
-		Messages: []nexa.ChatMessage{
-			{Role: "system", Content: chat.SystemPrompt},
-			{Role: "user", Content: prompt},
-		},
+	// Step 7: Call chat completion
+	req := llm.ChatCompletionRequest{
+		Model:       llmModel,
+		Messages:    chatMessages(chat.SystemPrompt, turns, prompt),
 		Temperature: chat.Temperature,
 		MaxTokens:   chat.MaxTokens,
 		TopK:        chat.TopK,
@@ -79,28 +109,25 @@ func (p *SimplePipeline) ProcessUserMessage(
 		Stream:      true,
 	}
 
-	streamChan, errChan, err := p.nexaClient.ChatCompletion(ctx, req)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to start chat completion: %w", err)
+	// Set by the backend before the stream closes, so it is visible to onComplete
+	var reported *llm.Usage
+	req.OnUsage = func(usage llm.Usage) {
+		reported = &usage
 	}
 
-	// Step 7: Collect response and store
-	responseChan := make(chan string, 10)
-	finalErrChan := make(chan error, 1)
-
-	go func() {
-		defer close(responseChan)
-		defer close(finalErrChan)
-
-		// Use helper to collect stream and store completion pair with fact extraction
-		err := p.collectStreamedResponse(ctx, streamChan, errChan, responseChan, func(fullResponse string) error {
-			return p.storeCompletionPairWithExtraction(ctx, chat, llmModel, embedModel, userMessage, fullResponse)
-		})
+	events <- models.StageEvent(models.StageGenerating)
+	streamChan, errChan, err := p.backend.ChatCompletion(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to start chat completion: %w", err)
+	}
 
-		if err != nil {
-			finalErrChan <- err
+	// Step 8: Collect response and store completion pair with fact extraction
+	return p.collectStreamedResponse(ctx, streamChan, errChan, events, func(fullResponse string) error {
+		usage := responseUsage(tokenizer, reported, req.Messages, fullResponse)
+		if err := p.storeCompletionPairWithExtraction(ctx, chat, llmModel, embedModel, userMessage, fullResponse, usage, nil, tokenizer); err != nil {
+			return err
 		}
-	}()
-
-	return responseChan, finalErrChan, nil
+		events <- models.UsageEvent(usage)
+		return nil
+	})
 }
@@ -2,12 +2,8 @@ package rag
 
 import (
 	"rag-terminal/internal/config"
-)
-
-const (
-	// CharsPerToken is a rough estimation of characters per token
-	// This is a conservative estimate; actual values vary by tokenizer
-	CharsPerToken = 4
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/vector"
 )
 
 // TokenBudget represents allocated token budgets for different content types
@@ -19,21 +15,27 @@ type TokenBudget struct {
 	HistoryBudget      int // Tokens allocated for conversation history
 	ChunksBudget       int // Tokens allocated for full chunks
 	FileListBudget     int // Small fixed budget for file list
+	Tokenizer          Tokenizer // Counts tokens against these budgets
 }
 
 // CalculateTokenBudget calculates token budgets based on context window and config
 // Uses the default (text) token budget configuration
-func CalculateTokenBudget(contextWindow, maxTokens int, cfg *config.Config) *TokenBudget {
-	return CalculateTokenBudgetForType(contextWindow, maxTokens, cfg, false)
+func CalculateTokenBudget(contextWindow, maxTokens int, cfg *config.Config, tokenizer Tokenizer) *TokenBudget {
+	return CalculateTokenBudgetForType(contextWindow, maxTokens, cfg, false, tokenizer)
 }
 
 // CalculateTokenBudgetForCode calculates token budgets optimized for code files
-func CalculateTokenBudgetForCode(contextWindow, maxTokens int, cfg *config.Config) *TokenBudget {
-	return CalculateTokenBudgetForType(contextWindow, maxTokens, cfg, true)
+func CalculateTokenBudgetForCode(contextWindow, maxTokens int, cfg *config.Config, tokenizer Tokenizer) *TokenBudget {
+	return CalculateTokenBudgetForType(contextWindow, maxTokens, cfg, true, tokenizer)
 }
 
-// CalculateTokenBudgetForType calculates token budgets based on file type
-func CalculateTokenBudgetForType(contextWindow, maxTokens int, cfg *config.Config, isCodeFile bool) *TokenBudget {
+// CalculateTokenBudgetForType calculates token budgets based on file type.
+// A nil tokenizer falls back to the heuristic.
+func CalculateTokenBudgetForType(contextWindow, maxTokens int, cfg *config.Config, isCodeFile bool, tokenizer Tokenizer) *TokenBudget {
+	if tokenizer == nil {
+		tokenizer = HeuristicTokenizer{}
+	}
+
 	// Select appropriate budget config based on file type
 	var budgetConfig config.TokenBudgetConfig
 	if isCodeFile {
@@ -76,26 +78,27 @@ func CalculateTokenBudgetForType(contextWindow, maxTokens int, cfg *config.Confi
 		HistoryBudget:  historyBudget,
 		ChunksBudget:   chunksBudget,
 		FileListBudget: fileListBudget,
+		Tokenizer:      tokenizer,
 	}
 }
 
-// EstimateTokens estimates the number of tokens in a text string
-func EstimateTokens(text string) int {
-	return len(text) / CharsPerToken
-}
-
-// TruncateToTokenLimit truncates text to fit within a token limit
-func TruncateToTokenLimit(text string, tokenLimit int) string {
-	maxChars := tokenLimit * CharsPerToken
-
-	if len(text) <= maxChars {
-		return text
+// responseUsage combines the usage the backend reported with the tokenizer's counts.
+// Without a report the counts stand in for the actual usage.
+func responseUsage(tokenizer Tokenizer, reported *llm.Usage, messages []llm.ChatMessage, response string) vector.TokenUsage {
+	var usage vector.TokenUsage
+	for _, msg := range messages {
+		usage.EstimatedPromptTokens += tokenizer.CountTokens(msg.Content)
 	}
+	usage.EstimatedCompletionTokens = tokenizer.CountTokens(response)
 
-	// Truncate and add ellipsis
-	if maxChars > 3 {
-		return text[:maxChars-3] + "..."
+	if reported != nil {
+		usage.PromptTokens = reported.PromptTokens
+		usage.CompletionTokens = reported.CompletionTokens
+		usage.Reported = true
+	} else {
+		usage.PromptTokens = usage.EstimatedPromptTokens
+		usage.CompletionTokens = usage.EstimatedCompletionTokens
 	}
 
-	return text[:maxChars]
+	return usage
 }
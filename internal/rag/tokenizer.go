@@ -0,0 +1,207 @@
+package rag
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"rag-terminal/internal/llm"
+	"rag-terminal/internal/logging"
+)
+
+// Tokenizer counts the tokens a model sees for a text
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// HeuristicTokenizer estimates token counts from the scripts in the text, for models whose
+// vocabulary can't be loaded. The rates approximate common BPE vocabularies: English words
+// take about four characters per token, while punctuation-heavy code and JSON, Cyrillic
+// and CJK text need far more tokens per character.
+type HeuristicTokenizer struct{}
+
+// CountTokens implements Tokenizer
+func (HeuristicTokenizer) CountTokens(text string) int {
+	var tokens float64
+	for _, r := range text {
+		tokens += runeTokens(r)
+	}
+	return int(math.Ceil(tokens))
+}
+
+// runeTokens returns the average share of a token one character takes
+func runeTokens(r rune) float64 {
+	if r < utf8.RuneSelf {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			return 0.25
+		case r >= '0' && r <= '9':
+			return 0.34 // Numbers are split into groups of up to three digits
+		case r == ' ':
+			return 0.1 // Mostly merged into the following word
+		case r == '\n', r == '\t', r == '\r':
+			return 0.5
+		default:
+			return 0.6 // Brackets, quotes and operators rarely merge
+		}
+	}
+
+	switch {
+	case unicode.In(r, unicode.Cyrillic, unicode.Greek, unicode.Armenian, unicode.Georgian):
+		return 0.45
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+		return 1.0
+	case unicode.IsLetter(r), unicode.IsNumber(r):
+		return 0.5 // Accented Latin, Arabic, Hebrew, Indic scripts
+	case unicode.IsSpace(r):
+		return 0.5
+	default:
+		return 1.5 // Emoji and symbols are often split into byte tokens
+	}
+}
+
+// TruncateToTokens shortens text to at most limit tokens, marking the cut with "..."
+func TruncateToTokens(tokenizer Tokenizer, text string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if tokenizer.CountTokens(text) <= limit {
+		return text
+	}
+
+	// Binary search over rune boundaries for the longest prefix that fits with the ellipsis
+	offsets := make([]int, 0, len(text))
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+
+	low, high := 0, len(offsets)-1
+	for low < high {
+		mid := (low + high + 1) / 2
+		if tokenizer.CountTokens(text[:offsets[mid]]+"...") <= limit {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	if offsets[low] == 0 {
+		return ""
+	}
+	return text[:offsets[low]] + "..."
+}
+
+// LoadTokenizer loads the vocabulary at location: a tokenizer.json, a GGUF model file,
+// or a model directory containing one of them
+func LoadTokenizer(location string) (Tokenizer, error) {
+	if strings.HasPrefix(location, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			location = filepath.Join(homeDir, location[2:])
+		}
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access tokenizer location: %w", err)
+	}
+
+	path := location
+	if info.IsDir() {
+		path, err = findTokenizerFile(location)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".gguf") {
+		return loadGGUFTokenizer(path)
+	}
+	return loadTokenizerJSON(path)
+}
+
+// findTokenizerFile prefers tokenizer.json over the vocabulary embedded in a GGUF file
+func findTokenizerFile(dir string) (string, error) {
+	candidate := filepath.Join(dir, "tokenizer.json")
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.gguf"))
+	if err != nil {
+		return "", fmt.Errorf("failed to search for model files: %w", err)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no tokenizer.json or GGUF file in %s", dir)
+	}
+	return matches[0], nil
+}
+
+// tokenizerCache resolves the tokenizer of each model once. Models without a loadable
+// vocabulary get the heuristic.
+type tokenizerCache struct {
+	backend    llm.Backend
+	paths      map[string]string // Configured tokenizer locations by model name
+	mu         sync.Mutex
+	tokenizers map[string]Tokenizer
+}
+
+func newTokenizerCache(backend llm.Backend, paths map[string]string) *tokenizerCache {
+	return &tokenizerCache{
+		backend:    backend,
+		paths:      paths,
+		tokenizers: make(map[string]Tokenizer),
+	}
+}
+
+// forModel returns the tokenizer of a model
+func (c *tokenizerCache) forModel(model string) Tokenizer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tokenizer, ok := c.tokenizers[model]; ok {
+		return tokenizer
+	}
+
+	var tokenizer Tokenizer = HeuristicTokenizer{}
+	if location := c.location(model); location != "" {
+		loaded, err := LoadTokenizer(location)
+		if err != nil {
+			logging.Info("Failed to load tokenizer for %s, estimating token counts: %v", model, err)
+		} else {
+			logging.Info("Loaded tokenizer for %s from %s", model, location)
+			tokenizer = loaded
+		}
+	} else {
+		logging.Debug("No tokenizer location known for %s, estimating token counts", model)
+	}
+
+	c.tokenizers[model] = tokenizer
+	return tokenizer
+}
+
+// location returns the configured tokenizer path, or the model location reported by the backend
+func (c *tokenizerCache) location(model string) string {
+	if path, ok := c.paths[model]; ok {
+		return path
+	}
+	if c.backend == nil {
+		return ""
+	}
+
+	models, err := c.backend.GetModels()
+	if err != nil {
+		logging.Debug("Failed to list models for tokenizer lookup: %v", err)
+		return ""
+	}
+	for _, m := range models {
+		if m.Name == model {
+			return m.Location
+		}
+	}
+	return ""
+}
@@ -0,0 +1,301 @@
+package rag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// spaceMarker replaces spaces in SentencePiece vocabularies
+const spaceMarker = "▁"
+
+// bpeTokenizer counts tokens by applying a BPE vocabulary. Merges are ranked either by
+// the merge list (tokenizer.json, GPT-2 style GGUF) or by the score of the merged token
+// (SentencePiece GGUF).
+type bpeTokenizer struct {
+	vocab        map[string]int
+	mergeRanks   map[string]int // "left right" -> rank, lower merges first
+	scores       []float32      // Token scores by vocab ID, used when there are no merges
+	byteLevel    bool           // GPT-2 style: text is split into words and mapped byte-to-unicode
+	byteFallback bool           // Unknown characters become one token per UTF-8 byte
+
+	mu    sync.Mutex
+	cache map[string]int // Token count per pre-tokenized piece
+}
+
+func newBPETokenizer(vocab map[string]int, merges []string, scores []float32, byteLevel bool) *bpeTokenizer {
+	t := &bpeTokenizer{
+		vocab:     vocab,
+		scores:    scores,
+		byteLevel: byteLevel,
+		cache:     make(map[string]int),
+	}
+
+	if len(merges) > 0 {
+		t.mergeRanks = make(map[string]int, len(merges))
+		for rank, merge := range merges {
+			if _, exists := t.mergeRanks[merge]; !exists {
+				t.mergeRanks[merge] = rank
+			}
+		}
+	}
+
+	// SentencePiece vocabularies encode unknown bytes as <0xXX> tokens
+	_, t.byteFallback = vocab["<0x0A>"]
+	return t
+}
+
+// CountTokens implements Tokenizer
+func (t *bpeTokenizer) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	var pieces []string
+	if t.byteLevel {
+		for _, piece := range splitWords(text) {
+			pieces = append(pieces, byteLevelEncode(piece))
+		}
+	} else {
+		pieces = splitSentencePiece(text)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := 0
+	for _, piece := range pieces {
+		n, ok := t.cache[piece]
+		if !ok {
+			n = t.encodePiece(piece)
+			// Bound the cache; pieces are words, so it stays small in practice
+			if len(t.cache) > 100000 {
+				t.cache = make(map[string]int)
+			}
+			t.cache[piece] = n
+		}
+		count += n
+	}
+	return count
+}
+
+// encodePiece merges the characters of one piece and returns the resulting token count
+func (t *bpeTokenizer) encodePiece(piece string) int {
+	symbols := make([]string, 0, len(piece))
+	for _, r := range piece {
+		symbols = append(symbols, string(r))
+	}
+
+	for len(symbols) > 1 {
+		best := -1
+		bestRank := 0
+		var bestScore float32
+
+		for i := 0; i < len(symbols)-1; i++ {
+			if t.mergeRanks != nil {
+				rank, ok := t.mergeRanks[symbols[i]+" "+symbols[i+1]]
+				if ok && (best < 0 || rank < bestRank) {
+					best, bestRank = i, rank
+				}
+				continue
+			}
+
+			id, ok := t.vocab[symbols[i]+symbols[i+1]]
+			if !ok || id >= len(t.scores) {
+				continue
+			}
+			if best < 0 || t.scores[id] > bestScore {
+				best, bestScore = i, t.scores[id]
+			}
+		}
+
+		if best < 0 {
+			break
+		}
+		symbols[best] += symbols[best+1]
+		symbols = append(symbols[:best+1], symbols[best+2:]...)
+	}
+
+	count := 0
+	for _, symbol := range symbols {
+		if _, ok := t.vocab[symbol]; !ok && t.byteFallback {
+			count += len(symbol)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// splitSentencePiece marks spaces and splits before each of them, since SentencePiece
+// tokens only carry the space marker at their start
+func splitSentencePiece(text string) []string {
+	text = spaceMarker + strings.ReplaceAll(text, " ", spaceMarker)
+
+	var pieces []string
+	for len(text) > 0 {
+		next := strings.Index(text[len(spaceMarker):], spaceMarker)
+		if next < 0 {
+			pieces = append(pieces, text)
+			break
+		}
+		end := next + len(spaceMarker)
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	return pieces
+}
+
+// splitWords pre-tokenizes like the GPT-2 and Llama 3 patterns: contractions, words with
+// their leading space, numbers of up to three digits, punctuation runs and whitespace
+func splitWords(text string) []string {
+	runes := []rune(text)
+	var pieces []string
+
+	for i := 0; i < len(runes); {
+		start := i
+
+		if runes[i] == '\'' {
+			if n := contractionLength(runes[i+1:]); n > 0 {
+				i += n + 1
+				pieces = append(pieces, string(runes[start:i]))
+				continue
+			}
+		}
+
+		j := i
+		if runes[i] == ' ' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			j = i + 1
+		}
+
+		switch r := runes[j]; {
+		case unicode.IsLetter(r):
+			i = j
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				i++
+			}
+		case unicode.IsNumber(r):
+			// Numbers don't carry the leading space
+			if j > i {
+				pieces = append(pieces, " ")
+				start = j
+			}
+			i = j
+			for i < len(runes) && i-j < 3 && unicode.IsNumber(runes[i]) {
+				i++
+			}
+		case !unicode.IsSpace(r):
+			i = j
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !unicode.IsLetter(runes[i]) && !unicode.IsNumber(runes[i]) {
+				i++
+			}
+		default:
+			for i < len(runes) && unicode.IsSpace(runes[i]) {
+				i++
+			}
+			// A space before a word belongs to the word
+			if i < len(runes) && i-start > 1 && runes[i-1] == ' ' {
+				i--
+			}
+		}
+
+		pieces = append(pieces, string(runes[start:i]))
+	}
+
+	return pieces
+}
+
+// contractionLength returns the length of the English contraction suffix at the start of runes
+func contractionLength(runes []rune) int {
+	if len(runes) >= 2 {
+		switch strings.ToLower(string(runes[:2])) {
+		case "re", "ve", "ll":
+			return 2
+		}
+	}
+	if len(runes) >= 1 {
+		switch unicode.ToLower(runes[0]) {
+		case 's', 't', 'm', 'd':
+			return 1
+		}
+	}
+	return 0
+}
+
+// byteToUnicode is the GPT-2 mapping of bytes to printable characters used in byte-level vocabularies
+var byteToUnicode = func() [256]rune {
+	var table [256]rune
+	next := rune(256)
+	for b := 0; b < 256; b++ {
+		if (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF) {
+			table[b] = rune(b)
+		} else {
+			table[b] = next
+			next++
+		}
+	}
+	return table
+}()
+
+func byteLevelEncode(piece string) string {
+	var b strings.Builder
+	for i := 0; i < len(piece); i++ {
+		b.WriteRune(byteToUnicode[piece[i]])
+	}
+	return b.String()
+}
+
+// tokenizerJSON is the subset of the Hugging Face tokenizer.json format needed for counting
+type tokenizerJSON struct {
+	Model struct {
+		Type   string            `json:"type"`
+		Vocab  json.RawMessage   `json:"vocab"`
+		Merges []json.RawMessage `json:"merges"`
+	} `json:"model"`
+	PreTokenizer json.RawMessage `json:"pre_tokenizer"`
+	Decoder      json.RawMessage `json:"decoder"`
+}
+
+// loadTokenizerJSON loads a BPE vocabulary from a Hugging Face tokenizer.json
+func loadTokenizerJSON(path string) (Tokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer: %w", err)
+	}
+
+	var tj tokenizerJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer: %w", err)
+	}
+
+	if tj.Model.Type != "BPE" {
+		return nil, fmt.Errorf("unsupported tokenizer model %q", tj.Model.Type)
+	}
+
+	var vocab map[string]int
+	if err := json.Unmarshal(tj.Model.Vocab, &vocab); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer vocabulary: %w", err)
+	}
+
+	// Merges are "left right" strings in older files and [left, right] pairs in newer ones
+	merges := make([]string, 0, len(tj.Model.Merges))
+	for _, raw := range tj.Model.Merges {
+		var merge string
+		if err := json.Unmarshal(raw, &merge); err == nil {
+			merges = append(merges, merge)
+			continue
+		}
+		var pair []string
+		if err := json.Unmarshal(raw, &pair); err != nil || len(pair) != 2 {
+			return nil, fmt.Errorf("failed to parse tokenizer merge %s", raw)
+		}
+		merges = append(merges, pair[0]+" "+pair[1])
+	}
+
+	byteLevel := bytes.Contains(tj.PreTokenizer, []byte(`"ByteLevel"`)) || bytes.Contains(tj.Decoder, []byte(`"ByteLevel"`))
+	return newBPETokenizer(vocab, merges, nil, byteLevel), nil
+}
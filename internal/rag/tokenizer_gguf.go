@@ -0,0 +1,226 @@
+package rag
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// GGUF metadata value types
+const (
+	ggufTypeUint8   = 0
+	ggufTypeInt8    = 1
+	ggufTypeUint16  = 2
+	ggufTypeInt16   = 3
+	ggufTypeUint32  = 4
+	ggufTypeInt32   = 5
+	ggufTypeFloat32 = 6
+	ggufTypeBool    = 7
+	ggufTypeString  = 8
+	ggufTypeArray   = 9
+	ggufTypeUint64  = 10
+	ggufTypeInt64   = 11
+	ggufTypeFloat64 = 12
+)
+
+// loadGGUFTokenizer reads the vocabulary from the metadata of a GGUF model file.
+// Only the header is read; the tensor data is never touched.
+func loadGGUFTokenizer(path string) (Tokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open model file: %w", err)
+	}
+	defer f.Close()
+
+	r := &ggufReader{r: bufio.NewReaderSize(f, 1<<20)}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r.r, magic[:]); err != nil || string(magic[:]) != "GGUF" {
+		return nil, fmt.Errorf("%s is not a GGUF file", path)
+	}
+
+	version := r.uint32()
+	if r.err == nil && version < 2 {
+		return nil, fmt.Errorf("unsupported GGUF version %d", version)
+	}
+	r.uint64() // Tensor count
+	kvCount := r.uint64()
+
+	var (
+		model  string
+		tokens []string
+		scores []float32
+		merges []string
+	)
+
+	for i := uint64(0); i < kvCount && r.err == nil; i++ {
+		key := r.string()
+		valueType := r.uint32()
+
+		switch key {
+		case "tokenizer.ggml.model":
+			if valueType != ggufTypeString {
+				return nil, fmt.Errorf("unexpected type %d for %s", valueType, key)
+			}
+			model = r.string()
+		case "tokenizer.ggml.tokens":
+			tokens = r.stringArray(valueType)
+		case "tokenizer.ggml.merges":
+			merges = r.stringArray(valueType)
+		case "tokenizer.ggml.scores":
+			scores = r.float32Array(valueType)
+		default:
+			r.skip(valueType)
+		}
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to read GGUF metadata: %w", r.err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s has no embedded vocabulary", path)
+	}
+
+	vocab := make(map[string]int, len(tokens))
+	for id, token := range tokens {
+		if _, exists := vocab[token]; !exists {
+			vocab[token] = id
+		}
+	}
+
+	switch model {
+	case "gpt2":
+		return newBPETokenizer(vocab, merges, nil, true), nil
+	case "llama":
+		return newBPETokenizer(vocab, nil, scores, false), nil
+	default:
+		return nil, fmt.Errorf("unsupported GGUF tokenizer model %q", model)
+	}
+}
+
+// ggufReader decodes little-endian GGUF values, keeping the first error
+type ggufReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (g *ggufReader) read(data interface{}) {
+	if g.err == nil {
+		g.err = binary.Read(g.r, binary.LittleEndian, data)
+	}
+}
+
+func (g *ggufReader) uint32() uint32 {
+	var v uint32
+	g.read(&v)
+	return v
+}
+
+func (g *ggufReader) uint64() uint64 {
+	var v uint64
+	g.read(&v)
+	return v
+}
+
+func (g *ggufReader) string() string {
+	n := g.uint64()
+	if g.err != nil {
+		return ""
+	}
+	if n > math.MaxInt32 {
+		g.err = fmt.Errorf("string length %d out of range", n)
+		return ""
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(g.r, buf); err != nil {
+		g.err = err
+		return ""
+	}
+	return string(buf)
+}
+
+// arrayHeader reads the element type and length of an array value
+func (g *ggufReader) arrayHeader(valueType uint32, want uint32) uint64 {
+	if valueType != ggufTypeArray {
+		g.err = fmt.Errorf("expected array, got type %d", valueType)
+		return 0
+	}
+	elemType := g.uint32()
+	n := g.uint64()
+	if g.err == nil && elemType != want {
+		g.err = fmt.Errorf("expected array of type %d, got %d", want, elemType)
+	}
+	return n
+}
+
+func (g *ggufReader) stringArray(valueType uint32) []string {
+	n := g.arrayHeader(valueType, ggufTypeString)
+	var values []string
+	for i := uint64(0); i < n && g.err == nil; i++ {
+		values = append(values, g.string())
+	}
+	return values
+}
+
+func (g *ggufReader) float32Array(valueType uint32) []float32 {
+	n := g.arrayHeader(valueType, ggufTypeFloat32)
+	var values []float32
+	for i := uint64(0); i < n && g.err == nil; i++ {
+		var v float32
+		g.read(&v)
+		values = append(values, v)
+	}
+	return values
+}
+
+// skip discards a value of the given type
+func (g *ggufReader) skip(valueType uint32) {
+	if g.err != nil {
+		return
+	}
+
+	switch valueType {
+	case ggufTypeString:
+		g.string()
+	case ggufTypeArray:
+		elemType := g.uint32()
+		n := g.uint64()
+		if g.err == nil && n > math.MaxInt32 {
+			g.err = fmt.Errorf("array length %d out of range", n)
+			return
+		}
+		if size := ggufTypeSize(elemType); size > 0 {
+			_, g.err = g.r.Discard(int(n) * size)
+			return
+		}
+		for i := uint64(0); i < n && g.err == nil; i++ {
+			g.skip(elemType)
+		}
+	default:
+		size := ggufTypeSize(valueType)
+		if size == 0 {
+			g.err = fmt.Errorf("unknown GGUF value type %d", valueType)
+			return
+		}
+		_, g.err = g.r.Discard(size)
+	}
+}
+
+// ggufTypeSize returns the byte size of fixed-size types, 0 for strings and arrays
+func ggufTypeSize(valueType uint32) int {
+	switch valueType {
+	case ggufTypeUint8, ggufTypeInt8, ggufTypeBool:
+		return 1
+	case ggufTypeUint16, ggufTypeInt16:
+		return 2
+	case ggufTypeUint32, ggufTypeInt32, ggufTypeFloat32:
+		return 4
+	case ggufTypeUint64, ggufTypeInt64, ggufTypeFloat64:
+		return 8
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"rag-terminal/internal/backend"
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/llm"
+)
+
+// BackendStatusModel is shown when the backend fails the startup health check.
+// It lists the diagnostics and lets the user fix the base URL and retry.
+type BackendStatusModel struct {
+	cfg      config.BackendConfig
+	diag     *backend.Diagnostics
+	err      error // Set when no client could be created for the entered URL
+	urlInput textinput.Model
+	checking bool
+	width    int
+	height   int
+}
+
+// BackendReady is sent once the backend passes the health check.
+// Config holds the base URL that worked, so it can be persisted.
+type BackendReady struct {
+	Backend llm.Backend
+	Config  config.BackendConfig
+	Models  []llm.Model
+}
+
+// backendChecked carries the result of a retry
+type backendChecked struct {
+	client llm.Backend
+	cfg    config.BackendConfig
+	diag   *backend.Diagnostics
+	err    error
+}
+
+func NewBackendStatusModel(cfg config.BackendConfig, diag *backend.Diagnostics, width, height int) BackendStatusModel {
+	urlInput := textinput.New()
+	urlInput.Placeholder = backend.BaseURL(config.BackendConfig{Provider: cfg.Provider})
+	urlInput.SetValue(backend.BaseURL(cfg))
+	urlInput.Focus()
+	urlInput.CharLimit = 200
+	urlInput.Width = 50
+
+	return BackendStatusModel{
+		cfg:      cfg,
+		diag:     diag,
+		urlInput: urlInput,
+		width:    width,
+		height:   height,
+	}
+}
+
+func (m BackendStatusModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m BackendStatusModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case backendChecked:
+		m.checking = false
+		m.err = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+
+		m.cfg = msg.cfg
+		m.diag = msg.diag
+		if msg.diag.Healthy() {
+			return m, func() tea.Msg {
+				return BackendReady{Backend: msg.client, Config: msg.cfg, Models: msg.diag.Models}
+			}
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+x", "esc":
+			return m, tea.Quit
+
+		case "enter", "ctrl+r":
+			if m.checking {
+				return m, nil
+			}
+			m.checking = true
+			return m, checkBackend(m.retryConfig())
+		}
+	}
+
+	var cmd tea.Cmd
+	m.urlInput, cmd = m.urlInput.Update(msg)
+	return m, cmd
+}
+
+// retryConfig applies the entered base URL. The provider default is kept as an empty
+// base URL, so it isn't written to the config file.
+func (m BackendStatusModel) retryConfig() config.BackendConfig {
+	cfg := m.cfg
+	cfg.BaseURL = strings.TrimRight(strings.TrimSpace(m.urlInput.Value()), "/")
+	if cfg.BaseURL == backend.BaseURL(config.BackendConfig{Provider: cfg.Provider}) {
+		cfg.BaseURL = ""
+	}
+	return cfg
+}
+
+func checkBackend(cfg config.BackendConfig) tea.Cmd {
+	return func() tea.Msg {
+		client, err := backend.New(cfg)
+		if err != nil {
+			return backendChecked{err: err}
+		}
+		diag := backend.CheckHealth(context.Background(), client, cfg)
+		return backendChecked{client: client, cfg: cfg, diag: diag}
+	}
+}
+
+func (m BackendStatusModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render("Backend Unavailable") + "\n\n")
+
+	if m.diag != nil {
+		b.WriteString(MetadataStyle.Render(fmt.Sprintf("Provider: %s | URL: %s", m.diag.Provider, m.diag.BaseURL)) + "\n\n")
+
+		for _, check := range m.diag.Checks {
+			if check.Err != nil {
+				b.WriteString(RenderError(fmt.Sprintf("✗ %s: %v", check.Name, check.Err)) + "\n")
+			} else {
+				b.WriteString(fmt.Sprintf("✓ %s\n", check.Name))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if m.err != nil {
+		b.WriteString(RenderError(fmt.Sprintf("Error: %v", m.err)) + "\n\n")
+	}
+
+	b.WriteString(HelpTextSimpleStyle.Render(m.hint()) + "\n\n")
+
+	b.WriteString(RenderFieldLabel("Base URL:", true) + "\n")
+	b.WriteString(m.urlInput.View() + "\n\n")
+
+	if m.checking {
+		b.WriteString(SpinnerStyle.Render("Checking backend...") + "\n\n")
+	}
+
+	helpText := "Enter/Ctrl+R: Retry • Esc/Ctrl+X: Exit"
+	b.WriteString(helpStyle.Render(helpText))
+
+	return b.String()
+}
+
+// hint suggests how to fix the first failed check
+func (m BackendStatusModel) hint() string {
+	if m.diag == nil {
+		return "Enter the server address and retry."
+	}
+
+	for _, check := range m.diag.Checks {
+		if check.Err == nil {
+			continue
+		}
+
+		switch check.Name {
+		case backend.CheckServer:
+			switch m.diag.Provider {
+			case config.ProviderNexa:
+				return "Start the server with `nexa serve`, or enter the address it listens on."
+			case config.ProviderOllama:
+				return "Start the server with `ollama serve`, or enter the address it listens on."
+			default:
+				return "Start the server, or enter the address it listens on."
+			}
+		case backend.CheckTextModel, backend.CheckEmbeddingModel:
+			return "Install a text generation and an embedding model, then retry."
+		default:
+			return "Fix the problem above, then retry."
+		}
+	}
+
+	return ""
+}
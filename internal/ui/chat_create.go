@@ -23,6 +23,8 @@ const (
 	fieldTopK
 	fieldContextWindow
 	fieldReranking
+	fieldQueryRewriting
+	fieldMultiQuery
 	fieldCreateButton
 )
 
@@ -33,6 +35,8 @@ type ChatCreateModel struct {
 	topKInput           textinput.Model
 	contextWindowInput  textinput.Model
 	rerankingEnabled    bool
+	queryRewriting      bool
+	multiQuery          bool
 	currentField        chatCreateField
 	llmModel            string
 	embedModel          string
@@ -160,9 +164,16 @@ func (m ChatCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case " ":
-			if m.currentField == fieldReranking {
+			switch m.currentField {
+			case fieldReranking:
 				m.rerankingEnabled = !m.rerankingEnabled
 				return m, nil
+			case fieldQueryRewriting:
+				m.queryRewriting = !m.queryRewriting
+				return m, nil
+			case fieldMultiQuery:
+				m.multiQuery = !m.multiQuery
+				return m, nil
 			}
 		}
 	}
@@ -250,11 +261,13 @@ func (m ChatCreateModel) View() string {
 
 	// LLM Reranking checkbox
 	rerankLabel := RenderFieldLabel("Use LLM Reranking:", m.currentField == fieldReranking)
-	checkbox := "[ ]"
-	if m.rerankingEnabled {
-		checkbox = "[✓]"
-	}
-	b.WriteString(rerankLabel + " " + checkbox + "\n\n")
+	b.WriteString(rerankLabel + " " + renderCheckbox(m.rerankingEnabled) + "\n")
+
+	// Query preparation checkboxes, off by default since each costs LLM calls per message
+	rewriteLabel := RenderFieldLabel("Rewrite Follow-up Queries:", m.currentField == fieldQueryRewriting)
+	b.WriteString(rewriteLabel + " " + renderCheckbox(m.queryRewriting) + "\n")
+	multiQueryLabel := RenderFieldLabel("Multi-Query Retrieval (sub-queries + HyDE):", m.currentField == fieldMultiQuery)
+	b.WriteString(multiQueryLabel + " " + renderCheckbox(m.multiQuery) + "\n\n")
 
 	// Model info
 	modelInfo := MetadataStyle.Render(
@@ -307,6 +320,14 @@ func (m *ChatCreateModel) updateFocus() {
 	}
 }
 
+// renderCheckbox renders the state of a toggle field
+func renderCheckbox(checked bool) string {
+	if checked {
+		return "[✓]"
+	}
+	return "[ ]"
+}
+
 type ValidationFailed struct {
 	TemperatureError   string
 	TopKError          string
@@ -399,6 +420,9 @@ func (m ChatCreateModel) createChat() tea.Cmd {
 			UseReranking:  m.rerankingEnabled,
 			MaxTokens:     2048,
 			ContextWindow: contextWindow,
+
+			UseQueryRewriting: m.queryRewriting,
+			UseMultiQuery:     m.multiQuery,
 		}
 
 		return ChatCreated{Chat: chat}
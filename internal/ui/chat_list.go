@@ -8,19 +8,25 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"rag-terminal/internal/export"
 	"rag-terminal/internal/vector"
 )
 
 type ChatListModel struct {
-	list   list.Model
-	chats  []vector.Chat
-	width  int
-	height int
-	err    error
+	list       list.Model
+	chats      []vector.Chat
+	embedModel string // Selected embedding model, chats built with another one are marked
+	dimensions int
+	width      int
+	height     int
+	err        error
+	exporting  bool   // Waiting for the export format key
+	status     string // Result of the last export or re-embedding
 }
 
 type chatItem struct {
-	chat vector.Chat
+	chat     vector.Chat
+	mismatch bool // Embedded with another model than the selected one
 }
 
 func (i chatItem) Title() string { return i.chat.Name }
@@ -34,13 +40,23 @@ func (i chatItem) Description() string {
 	if i.chat.FileCount > 0 {
 		fileInfo = fmt.Sprintf(" | Files: %d", i.chat.FileCount)
 	}
+	if i.chat.EmbedModel != "" {
+		fileInfo += " | Embedding: " + i.chat.EmbedModel
+		if i.mismatch {
+			fileInfo += " (Ctrl+R to re-embed)"
+		}
+	}
+	if i.chat.Usage.Responses > 0 {
+		fileInfo += " | " + formatChatUsage(i.chat.Usage)
+	}
 
-	return fmt.Sprintf("Created: %s | Temp: %.1f | TopK: %d | Ctx: %d | Reranking: %s%s",
+	return fmt.Sprintf("Created: %s | Temp: %.1f | TopK: %d | Ctx: %d | Reranking: %s | Query: %s%s",
 		i.chat.CreatedAt.Format("2006-01-02 15:04"),
 		i.chat.Temperature,
 		i.chat.TopK,
 		i.chat.ContextWindow,
 		reranking,
+		queryPreparation(&i.chat),
 		fileInfo)
 }
 func (i chatItem) FilterValue() string { return i.chat.Name }
@@ -55,11 +71,20 @@ type DeleteChat struct {
 	ChatID string
 }
 
-func NewChatListModel(chats []vector.Chat, width, height int) ChatListModel {
-	items := make([]list.Item, len(chats))
-	for i, c := range chats {
-		items[i] = chatItem{chat: c}
-	}
+// ExportChat asks for a chat to be written to the export directory
+type ExportChat struct {
+	ChatID string
+	Format export.Format
+}
+
+// ChatExported reports where an export was written
+type ChatExported struct {
+	Path string
+	Err  error
+}
+
+func NewChatListModel(chats []vector.Chat, embedModel string, dimensions int, width, height int) ChatListModel {
+	items := chatItems(chats, embedModel, dimensions)
 
 	delegate := CreateThemedDelegate()
 	l := list.New(items, delegate, width, height-4)
@@ -86,13 +111,23 @@ func NewChatListModel(chats []vector.Chat, width, height int) ChatListModel {
 	l.KeyMap.ForceQuit = key.NewBinding()
 
 	return ChatListModel{
-		list:   l,
-		chats:  chats,
-		width:  width,
-		height: height,
+		list:       l,
+		chats:      chats,
+		embedModel: embedModel,
+		dimensions: dimensions,
+		width:      width,
+		height:     height,
 	}
 }
 
+func chatItems(chats []vector.Chat, embedModel string, dimensions int) []list.Item {
+	items := make([]list.Item, len(chats))
+	for i, c := range chats {
+		items[i] = chatItem{chat: c, mismatch: c.CheckEmbedding(embedModel, dimensions) != nil}
+	}
+	return items
+}
+
 func (m ChatListModel) Init() tea.Cmd {
 	return nil
 }
@@ -105,7 +140,31 @@ func (m ChatListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetSize(msg.Width, msg.Height-4)
 		return m, nil
 
+	case ChatExported:
+		if msg.Err != nil {
+			m.status = RenderError(fmt.Sprintf("Export failed: %v", msg.Err))
+		} else {
+			m.status = fmt.Sprintf("Exported to %s", msg.Path)
+		}
+		return m, nil
+
+	case ChatReembedProgress:
+		m.status = fmt.Sprintf("Re-embedding %s (%d/%d)...", msg.Name, msg.Done, msg.Total)
+		return m, nil
+
+	case ChatReembedded:
+		if msg.Err != nil {
+			m.status = RenderError(fmt.Sprintf("Re-embedding %s failed: %v (Ctrl+R resumes)", msg.Name, msg.Err))
+		} else {
+			m.status = fmt.Sprintf("Re-embedded %s with %s", msg.Name, m.embedModel)
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.exporting {
+			return m.updateExport(msg)
+		}
+
 		switch msg.String() {
 		case "ctrl+x":
 			return m, tea.Quit
@@ -134,6 +193,29 @@ func (m ChatListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, func() tea.Msg {
 				return DeleteChat{ChatID: chat.ID}
 			}
+
+		case "ctrl+r":
+			selectedItem := m.list.SelectedItem()
+			if selectedItem == nil {
+				return m, nil
+			}
+			chat := selectedItem.(chatItem).chat
+			if chat.EmbedModel != "" && chat.CheckEmbedding(m.embedModel, m.dimensions) == nil {
+				m.status = fmt.Sprintf("%s is already embedded with %s", chat.Name, m.embedModel)
+				return m, nil
+			}
+			m.status = fmt.Sprintf("Re-embedding %s...", chat.Name)
+			return m, func() tea.Msg {
+				return ReembedChat{ChatID: chat.ID, Name: chat.Name}
+			}
+
+		case "ctrl+e":
+			if m.list.SelectedItem() == nil {
+				return m, nil
+			}
+			m.exporting = true
+			m.status = ""
+			return m, nil
 		}
 	}
 
@@ -142,24 +224,55 @@ func (m ChatListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// updateExport handles the format key after Ctrl+E
+func (m ChatListModel) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var format export.Format
+	switch msg.String() {
+	case "m":
+		format = export.FormatMarkdown
+	case "j":
+		format = export.FormatJSON
+	case "h":
+		format = export.FormatHTML
+	case "esc":
+		m.exporting = false
+		return m, nil
+	default:
+		return m, nil
+	}
+
+	m.exporting = false
+	selectedItem := m.list.SelectedItem()
+	if selectedItem == nil {
+		return m, nil
+	}
+	chat := selectedItem.(chatItem).chat
+	m.status = fmt.Sprintf("Exporting %s...", chat.Name)
+	return m, func() tea.Msg {
+		return ExportChat{ChatID: chat.ID, Format: format}
+	}
+}
+
 func (m ChatListModel) View() string {
 	if m.err != nil {
 		return errorStyle.Render(fmt.Sprintf("Error: %v\n\nPress Ctrl+X to exit", m.err))
 	}
 
-	helpText := "↑/↓: Navigate • Enter: Open • /: Filter • Ctrl+N: New Chat • Ctrl+D: Delete • Ctrl+X: Exit"
+	helpText := "↑/↓: Navigate • Enter: Open • /: Filter • Ctrl+N: New Chat • Ctrl+D: Delete • Ctrl+E: Export • Ctrl+R: Re-embed • Ctrl+X: Exit"
+	if m.exporting {
+		helpText = "Export as: m: Markdown • j: JSON • h: HTML • Esc: Cancel"
+	}
+
+	views := []string{m.list.View()}
+	if m.status != "" {
+		views = append(views, HelpTextSimpleStyle.Render(m.status))
+	}
+	views = append(views, helpStyle.Render(helpText))
 
-	return lipgloss.JoinVertical(lipgloss.Left,
-		m.list.View(),
-		helpStyle.Render(helpText),
-	)
+	return lipgloss.JoinVertical(lipgloss.Left, views...)
 }
 
 func (m *ChatListModel) RefreshChats(chats []vector.Chat) {
 	m.chats = chats
-	items := make([]list.Item, len(chats))
-	for i, c := range chats {
-		items[i] = chatItem{chat: c}
-	}
-	m.list.SetItems(items)
+	m.list.SetItems(chatItems(chats, m.embedModel, m.dimensions))
 }
@@ -16,6 +16,7 @@ import (
 
 	"rag-terminal/internal/document"
 	"rag-terminal/internal/logging"
+	"rag-terminal/internal/models"
 	"rag-terminal/internal/rag"
 	"rag-terminal/internal/vector"
 )
@@ -36,6 +37,11 @@ const (
 	StateEmbedding
 	StateReranking
 	StateThinking
+	StateSyncing
+	StateReembedding
+	StateRetrieving
+	StateRewriting
+	StateDeleting
 )
 
 type ChatViewModel struct {
@@ -49,6 +55,7 @@ type ChatViewModel struct {
 	spinner            spinner.Model
 	fileSelector       FileSelectorOverlayModel
 	factsViewer        FactsViewerOverlayModel
+	summaryViewer      SummaryViewerOverlayModel
 	width              int
 	height             int
 	processingState    ProcessingState
@@ -59,9 +66,10 @@ type ChatViewModel struct {
 	err                error
 	ctx                context.Context
 	cancelFunc         context.CancelFunc
-	streamChan         <-chan string
+	streamChan         <-chan models.Event
 	errChan            <-chan error
 	streamBuffer       *strings.Builder
+	streamSources      []vector.Source // Sources of the answer being streamed
 	lastRender         time.Time
 	tokenCount         int
 	thinkingStartTime  time.Time
@@ -70,11 +78,18 @@ type ChatViewModel struct {
 	mdRenderer         *glamour.TermRenderer
 	llmModel           string
 	embedModel         string
+	rerankModel        string
+	watchDone          int                       // Files processed in the current watch sync
+	watchTotal         int                       // Files to process in the current watch sync
+	watchErr           error                     // Last watch sync failure, shown in the status bar
+	embeddingMismatch  *vector.EmbeddingMismatch // Set while the chat's vectors come from another embedding model
+	reembedDone        int                       // Vectors re-embedded so far
+	reembedTotal       int                       // Vectors to re-embed
 }
 
 type ChatMessageReceived struct {
-	Token           string
-	StreamChan      <-chan string
+	Event           models.Event
+	StreamChan      <-chan models.Event
 	ErrChan         <-chan error
 	OriginalMessage string                         // Original user message with file paths
 	PathResults     []document.PathDetectionResult // Detected file paths to replace
@@ -95,19 +110,39 @@ type StateChange struct {
 	State ProcessingState
 }
 
-type FileEmbeddingProgress struct {
-	Embedded int
-	Total    int
-}
-
 type RenderTickMsg struct{}
 
-type StateTransitionMsg struct{}
-
 type FileOnlyEmbedding struct {
 	Count int
 }
 
+type WatchTick struct{}
+
+type WatchChangesDetected struct {
+	Changes *document.WatchChanges
+}
+
+type WatchSyncProgress struct {
+	Event      models.Event
+	StreamChan <-chan models.Event
+	ErrChan    <-chan error
+}
+
+type ReembedProgress struct {
+	Done         int
+	Total        int
+	progressChan <-chan reembedStatus
+	errChan      <-chan error
+}
+
+type ReembedComplete struct {
+	Err error
+}
+
+type WatchSyncComplete struct {
+	Err error
+}
+
 // createMarkdownRenderer creates a markdown renderer with fallback handling
 func createMarkdownRenderer(width int) *glamour.TermRenderer {
 	// Try auto style first
@@ -169,7 +204,7 @@ func (m *ChatViewModel) safeRenderMarkdown(content string) string {
 	return strings.TrimRight(rendered, "\n")
 }
 
-func NewChatViewModel(chat *vector.Chat, pipeline rag.Pipeline, vectorStore vector.VectorStore, llmModel, embedModel string, width, height int) ChatViewModel {
+func NewChatViewModel(chat *vector.Chat, pipeline rag.Pipeline, vectorStore vector.VectorStore, llmModel, embedModel, rerankModel string, width, height int) ChatViewModel {
 	ta := textarea.New()
 	ta.Placeholder = "Type your message, drop file or folder..."
 	ta.Focus()
@@ -223,6 +258,9 @@ func NewChatViewModel(chat *vector.Chat, pipeline rag.Pipeline, vectorStore vect
 	// Initialize facts viewer with current dimensions
 	fv.UpdateSize(width, height)
 
+	sv := NewSummaryViewerOverlayModel(vectorStore)
+	sv.UpdateSize(width, height)
+
 	// Initialize markdown renderer with dark theme
 	mdRenderer := createMarkdownRenderer(width)
 
@@ -236,6 +274,7 @@ func NewChatViewModel(chat *vector.Chat, pipeline rag.Pipeline, vectorStore vect
 		spinner:         sp,
 		fileSelector:    fs,
 		factsViewer:     fv,
+		summaryViewer:   sv,
 		width:           width,
 		height:          height,
 		ctx:             ctx,
@@ -245,14 +284,22 @@ func NewChatViewModel(chat *vector.Chat, pipeline rag.Pipeline, vectorStore vect
 		streamBuffer:    &strings.Builder{},
 		llmModel:        llmModel,
 		embedModel:      embedModel,
+		rerankModel:     rerankModel,
 	}
 }
 
+// SetEmbeddingMismatch marks the chat as embedded with another model, which the view warns about
+// and offers to fix by re-embedding
+func (m *ChatViewModel) SetEmbeddingMismatch(mismatch *vector.EmbeddingMismatch) {
+	m.embeddingMismatch = mismatch
+}
+
 func (m ChatViewModel) Init() tea.Cmd {
 	return tea.Batch(
 		textarea.Blink,
 		m.spinner.Tick,
 		m.loadMessages(),
+		m.scheduleWatchTick(),
 	)
 }
 
@@ -275,6 +322,34 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.textarea.Focus()
 		return m, nil
 
+	case FileDeleteRequested:
+		// Remove the document and its chunks in the background; the selector reopens when done
+		m.fileSelector.Hide()
+		m.processingState = StateDeleting
+		return m, m.deleteDocument(msg.DocumentID, msg.FileName)
+
+	case DocumentDeleted:
+		m.processingState = StateIdle
+		if msg.Err != nil {
+			return m, func() tea.Msg {
+				return ChatResponseError{Err: fmt.Errorf("failed to remove %s: %w", msg.FileName, msg.Err)}
+			}
+		}
+		return m, m.loadAndShowFileSelector()
+
+	case FileReindexRequested:
+		// Re-embed in the background; the selector reopens when done
+		m.fileSelector.Hide()
+		m.processingState = StateEmbedding
+		return m, m.reindexDocument(msg.DocumentID, msg.FileName)
+
+	case DocumentReindexed:
+		m.processingState = StateIdle
+		if msg.Err != nil {
+			return m, func() tea.Msg { return ChatResponseError{Err: msg.Err} }
+		}
+		return m, m.loadAndShowFileSelector()
+
 	case FactDeleted:
 		// Delete the fact from storage
 		if err := m.factsViewer.DeleteSelectedFact(context.Background(), msg.Key); err != nil {
@@ -287,6 +362,21 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.factsViewer.Hide()
 		m.textarea.Focus()
 		return m, nil
+
+	case SummarySaved:
+		// Store the edited summary; the viewer stays open if that fails
+		if err := m.summaryViewer.SaveSummary(context.Background(), msg.Content); err != nil {
+			logging.Error("Failed to save summary: %v", err)
+			return m, nil
+		}
+		m.summaryViewer.Hide()
+		m.textarea.Focus()
+		return m, nil
+
+	case SummaryViewerClosed:
+		m.summaryViewer.Hide()
+		m.textarea.Focus()
+		return m, nil
 	}
 
 	// Handle file selector updates if visible
@@ -307,6 +397,15 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 	}
 
+	// Handle summary viewer updates if visible
+	if m.summaryViewer.IsVisible() {
+		cmd := m.summaryViewer.UpdateSummaryViewer(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -317,6 +416,7 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.textarea.SetWidth(msg.Width - 4)
 		m.fileSelector.UpdateSize(msg.Width, msg.Height)
 		m.factsViewer.UpdateSize(msg.Width, msg.Height)
+		m.summaryViewer.UpdateSize(msg.Width, msg.Height)
 
 		// Update markdown renderer word wrap width
 		m.mdRenderer = createMarkdownRenderer(msg.Width)
@@ -353,6 +453,40 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case "ctrl+o":
+			// Open the conversation summary
+			if m.processingState == StateIdle {
+				return m, m.loadAndShowSummaryViewer()
+			}
+			return m, nil
+
+		case "ctrl+w":
+			// Toggle watch mode for this chat
+			if m.processingState == StateIdle {
+				m.chat.WatchEnabled = !m.chat.WatchEnabled
+				m.watchErr = nil
+				if badgerStore, ok := m.vectorStore.(*vector.BadgerStore); ok {
+					if err := badgerStore.UpdateChat(context.Background(), m.chat); err != nil {
+						logging.Error("Failed to save watch mode: %v", err)
+					}
+				}
+				if m.chat.WatchEnabled {
+					// Check right away instead of waiting for the next tick
+					return m, m.detectWatchChanges()
+				}
+			}
+			return m, nil
+
+		case "ctrl+r":
+			// Move the chat to the embedding model in use
+			if m.processingState == StateIdle && m.embeddingMismatch != nil {
+				m.processingState = StateReembedding
+				m.reembedDone = 0
+				m.reembedTotal = 0
+				return m, m.reembedChat()
+			}
+			return m, nil
+
 		case "ctrl+x":
 			m.cancelFunc()
 			return m, tea.Quit
@@ -383,14 +517,8 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.lastResponseTPS = 0
 				}
 
-				// Only schedule state transition if there's a query (needs reranking/thinking)
-				var cmds []tea.Cmd
-				cmds = append(cmds, m.sendMessage(userMessage))
-				if m.hasQuery {
-					cmds = append(cmds, m.scheduleStateTransition())
-				}
-
-				return m, tea.Batch(cmds...)
+				// The pipeline reports the following stages as events
+				return m, m.sendMessage(userMessage)
 			}
 		}
 
@@ -410,69 +538,102 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.factsViewer.Show()
 		return m, nil
 
+	case SummaryViewerLoaded:
+		m.textarea.Blur()
+		m.summaryViewer.SetSummary(m.chat.ID, msg.Summary)
+		m.summaryViewer.Show()
+		return m, nil
+
 	case StateChange:
 		m.processingState = msg.State
 		return m, nil
 
-	case FileEmbeddingProgress:
-		m.embeddedFiles = msg.Embedded
-		m.totalFiles = msg.Total
+	case WatchTick:
+		cmds := []tea.Cmd{m.scheduleWatchTick()}
+		if m.chat.WatchEnabled && m.processingState == StateIdle {
+			cmds = append(cmds, m.detectWatchChanges())
+		}
+		return m, tea.Batch(cmds...)
+
+	case WatchChangesDetected:
+		// The user may have started something while the scan ran
+		if msg.Changes.Count() == 0 || m.processingState != StateIdle {
+			return m, nil
+		}
+		m.processingState = StateSyncing
+		m.watchDone = 0
+		m.watchTotal = msg.Changes.Count()
+		m.watchErr = nil
+		streamChan, errChan := m.documentManager.ApplyChanges(m.ctx, m.chat, m.embedModel, msg.Changes)
+		return m, waitForWatchSync(streamChan, errChan)
+
+	case WatchSyncProgress:
+		if msg.Event.Type == models.EventProgress {
+			m.watchDone = msg.Event.Done
+			m.watchTotal = msg.Event.Total
+		}
+		return m, waitForWatchSync(msg.StreamChan, msg.ErrChan)
+
+	case WatchSyncComplete:
+		if msg.Err != nil {
+			logging.Error("Watch sync failed: %v", msg.Err)
+			m.watchErr = msg.Err
+		}
+		m.processingState = StateIdle
+		m.watchDone = 0
+		m.watchTotal = 0
 		return m, nil
 
-	case StateTransitionMsg:
-		// Auto-transition from Embedding to Reranking if appropriate
-		// Only transition if: still embedding, reranking enabled, AND there's a query to process
-		if m.processingState == StateEmbedding && m.chat.UseReranking && m.hasQuery {
-			m.processingState = StateReranking
+	case ReembedProgress:
+		m.reembedDone = msg.Done
+		m.reembedTotal = msg.Total
+		return m, waitForReembed(msg.progressChan, msg.errChan)
+
+	case ReembedComplete:
+		m.processingState = StateIdle
+		if msg.Err != nil {
+			logging.Error("Re-embedding chat %s failed: %v", m.chat.ID, msg.Err)
+			return m, func() tea.Msg { return ChatResponseError{Err: fmt.Errorf("re-embedding failed: %w", msg.Err)} }
 		}
+		// Keep the view's copy in sync, later metadata saves write it back
+		m.chat.EmbedModel = m.embedModel
+		m.chat.EmbeddingDimensions = m.embeddingMismatch.Dimensions
+		m.embeddingMismatch = nil
 		return m, nil
 
 	case ChatMessageReceived:
-		// Check if this is a progress token
-		if strings.HasPrefix(msg.Token, "@@PROGRESS:") && strings.HasSuffix(msg.Token, "@@") {
-			// Parse progress: @@PROGRESS:X/Y@@
-			progressStr := strings.TrimPrefix(msg.Token, "@@PROGRESS:")
-			progressStr = strings.TrimSuffix(progressStr, "@@")
-			parts := strings.Split(progressStr, "/")
-			if len(parts) == 2 {
-				var embedded, total int
-				fmt.Sscanf(parts[0], "%d", &embedded)
-				fmt.Sscanf(parts[1], "%d", &total)
-
-				// Choose the correct continuation based on whether we're waiting for document loading + query
-				var continueCmd tea.Cmd
-				if msg.OriginalMessage != "" && len(msg.PathResults) > 0 {
-					continueCmd = waitForDocumentLoadingAndProcessQuery(msg.StreamChan, msg.ErrChan, msg.OriginalMessage, msg.PathResults)
-				} else {
-					continueCmd = waitForStreamToken(msg.StreamChan, msg.ErrChan)
-				}
+		event := msg.Event
+		switch event.Type {
+		case models.EventStage:
+			m.processingState = stageState(event.Stage)
 
-				return m, tea.Batch(
-					continueCmd,
-					func() tea.Msg {
-						return FileEmbeddingProgress{
-							Embedded: embedded,
-							Total:    total,
-						}
-					},
-				)
-			}
-		}
+		case models.EventProgress:
+			m.embeddedFiles = event.Done
+			m.totalFiles = event.Total
+
+		case models.EventSources:
+			m.streamSources = event.Sources
 
-		// Transition to thinking state when we start receiving tokens
-		if m.processingState != StateThinking {
+		case models.EventWarning:
+			// Shown with the next message, like the answer tokens
+			m.streamBuffer.WriteString("⚠ " + event.Message + "\n")
+
+		case models.EventToken:
+			// Measure the token rate from the first token on
 			m.processingState = StateThinking
-			m.thinkingStartTime = time.Now()
-		}
+			if m.thinkingStartTime.IsZero() {
+				m.thinkingStartTime = time.Now()
+			}
 
-		// Filter out invalid UTF-8 replacement characters (�)
-		cleanToken := strings.ReplaceAll(msg.Token, "\uFFFD", "")
+			// Filter out invalid UTF-8 replacement characters (�)
+			cleanToken := strings.ReplaceAll(event.Token, "\uFFFD", "")
 
-		// Collect tokens in buffer - we'll render the complete message when done
-		if cleanToken != "" {
-			m.streamBuffer.WriteString(cleanToken)
+			// Collect tokens in buffer - we'll render the complete message when done
+			if cleanToken != "" {
+				m.streamBuffer.WriteString(cleanToken)
+			}
+			m.tokenCount++
 		}
-		m.tokenCount++
 
 		// Continue with appropriate handler based on whether we're waiting for document loading
 		if msg.OriginalMessage != "" && len(msg.PathResults) > 0 {
@@ -500,22 +661,8 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.streamBuffer.Reset()
 
 		// Process the message (with filenames instead of paths) through the pipeline
-		streamChan, errChan, err := m.pipeline.ProcessUserMessage(m.ctx, m.chat, m.llmModel, m.embedModel, messageWithFilenames)
-		if err != nil {
-			logging.Error("ProcessUserMessage failed after document loading: %v", err)
-			m.processingState = StateIdle
-			m.hasQuery = false
-			return m, func() tea.Msg { return ChatResponseError{Err: err} }
-		}
-
-		// Transition to reranking/thinking state
-		var cmds []tea.Cmd
-		cmds = append(cmds, waitForStreamToken(streamChan, errChan))
-		if m.hasQuery {
-			cmds = append(cmds, m.scheduleStateTransition())
-		}
-
-		return m, tea.Batch(cmds...)
+		streamChan, errChan := m.pipeline.ProcessUserMessage(m.ctx, m.chat, m.llmModel, m.embedModel, messageWithFilenames)
+		return m, waitForStreamToken(streamChan, errChan)
 
 	case ChatResponseComplete:
 		m.flushStreamBuffer()
@@ -542,6 +689,15 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.embeddedFiles = 0
 		m.totalFiles = 0
 		m.thinkingStartTime = time.Time{}
+
+		// The pipeline has stored the response with its token usage by now
+		return m, m.loadUsage()
+
+	case UsageLoaded:
+		m.messages = msg.Messages
+		m.chat.Usage = msg.Usage
+		m.renderMessages()
+		m.viewport.GotoBottom()
 		return m, nil
 
 	case ChatResponseError:
@@ -549,6 +705,7 @@ func (m ChatViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.processingState = StateIdle
 		m.hasQuery = false // Reset query flag for next operation
 		m.streamBuffer.Reset()
+		m.streamSources = nil
 		m.tokenCount = 0
 		m.embeddedFiles = 0
 		m.totalFiles = 0
@@ -593,20 +750,36 @@ func (m ChatViewModel) View() string {
 		rerankingStatus = "ON"
 	}
 
+	watchStatus := "OFF"
+	if m.chat.WatchEnabled {
+		watchStatus = "ON"
+		if m.watchErr != nil {
+			watchStatus = "ON (last sync failed)"
+		}
+	}
+
 	// Line 1: Models
 	modelLine := fmt.Sprintf("LLM: %s | Embedding: %s",
 		m.llmModel,
 		m.embedModel,
 	)
+	if m.rerankModel != "" {
+		modelLine += fmt.Sprintf(" | Reranker: %s", m.rerankModel)
+	}
+	if m.embeddingMismatch != nil && m.processingState != StateReembedding {
+		modelLine += " | " + ErrorMessageStyle.Render(fmt.Sprintf("Chat embedded with %s, search unreliable (Ctrl+R: re-embed)", m.embeddingMismatch.ChatModel))
+	}
 	b.WriteString(statusBarStyle.Render(modelLine) + "\n")
 
 	// Line 2: Chat properties
 	var propertyLine string
-	propertyLine = fmt.Sprintf("Temp: %.1f | TopK: %d | Ctx: %d | Reranking: %s",
+	propertyLine = fmt.Sprintf("Temp: %.1f | TopK: %d | Ctx: %d | Reranking: %s | Query: %s | Watch: %s",
 		m.chat.Temperature,
 		m.chat.TopK,
 		m.chat.ContextWindow,
 		rerankingStatus,
+		queryPreparation(m.chat),
+		watchStatus,
 	)
 
 	// Add files count if documents are embedded
@@ -623,15 +796,28 @@ func (m ChatViewModel) View() string {
 		} else {
 			propertyLine += " | " + m.spinner.View() + " Embedding..."
 		}
+	case StateRewriting:
+		propertyLine += " | " + m.spinner.View() + " Rewriting query..."
+	case StateRetrieving:
+		propertyLine += " | " + m.spinner.View() + " Retrieving..."
 	case StateReranking:
 		propertyLine += " | " + m.spinner.View() + " Reranking..."
 	case StateThinking:
 		propertyLine += fmt.Sprintf(" | %s Thinking... (%d tokens)", m.spinner.View(), m.tokenCount)
+	case StateSyncing:
+		propertyLine += fmt.Sprintf(" | %s Syncing watched files (%d/%d)...", m.spinner.View(), m.watchDone, m.watchTotal)
+	case StateDeleting:
+		propertyLine += " | " + m.spinner.View() + " Removing file..."
+	case StateReembedding:
+		propertyLine += fmt.Sprintf(" | %s Re-embedding with %s (%d/%d)...", m.spinner.View(), m.embedModel, m.reembedDone, m.reembedTotal)
 	case StateIdle:
 		// Show last response statistics if available
 		if m.lastResponseTokens > 0 {
 			propertyLine += fmt.Sprintf(" | Last response: %d tokens, %.1f tok/s", m.lastResponseTokens, m.lastResponseTPS)
 		}
+		if m.chat.Usage.Responses > 0 {
+			propertyLine += " | " + formatChatUsage(m.chat.Usage)
+		}
 	}
 
 	b.WriteString(statusBarStyle.Render(propertyLine) + "\n\n")
@@ -648,7 +834,7 @@ func (m ChatViewModel) View() string {
 
 	b.WriteString(m.textarea.View() + "\n")
 
-	helpText := "Enter: Send • Ctrl+F: Files • Ctrl+U: Facts • ↑/↓: Scroll • PgUp/PgDn: Page Scroll • Esc: Back • Ctrl+X: Exit"
+	helpText := "Enter: Send • Ctrl+F: Files • Ctrl+U: Facts • Ctrl+O: Summary • Ctrl+W: Watch • ↑/↓: Scroll • PgUp/PgDn: Page Scroll • Esc: Back • Ctrl+X: Exit"
 	b.WriteString(helpStyle.Render(helpText))
 
 	baseView := b.String()
@@ -657,6 +843,9 @@ func (m ChatViewModel) View() string {
 	if m.factsViewer.IsVisible() {
 		return m.factsViewer.RenderOverlay(baseView)
 	}
+	if m.summaryViewer.IsVisible() {
+		return m.summaryViewer.RenderOverlay(baseView)
+	}
 
 	// Render file selector overlay on top if visible using the overlay library
 	return m.fileSelector.RenderOverlay(baseView)
@@ -711,12 +900,7 @@ func (m ChatViewModel) sendMessage(userMessage string) tea.Cmd {
 
 		// No path detected, process as regular message with document support
 		logging.Debug("No path detected, processing as regular message")
-		streamChan, errChan, err := m.pipeline.ProcessUserMessage(m.ctx, m.chat, m.llmModel, m.embedModel, userMessage)
-		if err != nil {
-			logging.Error("ProcessUserMessage failed: %v", err)
-			return ChatResponseError{Err: err}
-		}
-
+		streamChan, errChan := m.pipeline.ProcessUserMessage(m.ctx, m.chat, m.llmModel, m.embedModel, userMessage)
 		return waitForStreamToken(streamChan, errChan)()
 	}
 }
@@ -772,18 +956,18 @@ func replacePathsWithFilenames(originalMessage string, pathResults []document.Pa
 	return result
 }
 
-// waitForStreamToken creates a command that waits for the next stream token
-func waitForStreamToken(streamChan <-chan string, errChan <-chan error) tea.Cmd {
+// waitForStreamToken creates a command that waits for the next pipeline or loader event
+func waitForStreamToken(streamChan <-chan models.Event, errChan <-chan error) tea.Cmd {
 	return func() tea.Msg {
 		select {
-		case token, ok := <-streamChan:
+		case event, ok := <-streamChan:
 			if !ok {
 				// Stream closed
 				return ChatResponseComplete{}
 			}
-			// Return token with channels for continuation
+			// Return event with channels for continuation
 			return ChatMessageReceived{
-				Token:      token,
+				Event:      event,
 				StreamChan: streamChan,
 				ErrChan:    errChan,
 			}
@@ -799,10 +983,10 @@ func waitForStreamToken(streamChan <-chan string, errChan <-chan error) tea.Cmd
 }
 
 // waitForDocumentLoadingAndProcessQuery waits for document loading to complete, then triggers query processing
-func waitForDocumentLoadingAndProcessQuery(streamChan <-chan string, errChan <-chan error, originalMessage string, pathResults []document.PathDetectionResult) tea.Cmd {
+func waitForDocumentLoadingAndProcessQuery(streamChan <-chan models.Event, errChan <-chan error, originalMessage string, pathResults []document.PathDetectionResult) tea.Cmd {
 	return func() tea.Msg {
 		select {
-		case token, ok := <-streamChan:
+		case event, ok := <-streamChan:
 			if !ok {
 				// Stream closed, document loading complete
 				return DocumentLoadingComplete{
@@ -810,9 +994,9 @@ func waitForDocumentLoadingAndProcessQuery(streamChan <-chan string, errChan <-c
 					PathResults:     pathResults,
 				}
 			}
-			// Return token with channels for continuation
+			// Return event with channels for continuation
 			return ChatMessageReceived{
-				Token:           token,
+				Event:           event,
 				StreamChan:      streamChan,
 				ErrChan:         errChan,
 				OriginalMessage: originalMessage,
@@ -829,9 +1013,88 @@ func waitForDocumentLoadingAndProcessQuery(streamChan <-chan string, errChan <-c
 	}
 }
 
+// waitForWatchSync creates a command that waits for the next watch sync progress update
+func waitForWatchSync(streamChan <-chan models.Event, errChan <-chan error) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case event, ok := <-streamChan:
+			if !ok {
+				// Drain a trailing error sent just before the channels closed
+				if err, ok := <-errChan; ok && err != nil {
+					return WatchSyncComplete{Err: err}
+				}
+				return WatchSyncComplete{}
+			}
+			return WatchSyncProgress{
+				Event:      event,
+				StreamChan: streamChan,
+				ErrChan:    errChan,
+			}
+
+		case err := <-errChan:
+			if err != nil {
+				return WatchSyncComplete{Err: err}
+			}
+			return waitForWatchSync(streamChan, errChan)()
+		}
+	}
+}
+
+// reembedChat regenerates the chat's vectors with the embedding model in use, reporting progress per batch.
+// Leaving the chat stops it; the next run resumes from the checkpoint.
+func (m ChatViewModel) reembedChat() tea.Cmd {
+	progressChan, errChan := runReembed(m.ctx, m.pipeline.GetReembedder(), m.chat.ID, m.embedModel, m.embeddingMismatch.Dimensions)
+	return waitForReembed(progressChan, errChan)
+}
+
+// waitForReembed creates a command that waits for the next re-embedding progress update
+func waitForReembed(progressChan <-chan reembedStatus, errChan <-chan error) tea.Cmd {
+	return func() tea.Msg {
+		status, ok := <-progressChan
+		if !ok {
+			return ReembedComplete{Err: <-errChan}
+		}
+		return ReembedProgress{Done: status.done, Total: status.total, progressChan: progressChan, errChan: errChan}
+	}
+}
+
+// scheduleWatchTick schedules the next poll of the chat's watched paths.
+// The chain stops once the view's context is cancelled on leaving the chat.
+func (m ChatViewModel) scheduleWatchTick() tea.Cmd {
+	ctx := m.ctx
+	return tea.Tick(m.documentManager.WatchInterval(), func(t time.Time) tea.Msg {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return WatchTick{}
+	})
+}
+
+func (m ChatViewModel) detectWatchChanges() tea.Cmd {
+	return func() tea.Msg {
+		if len(m.chat.WatchPaths) == 0 {
+			return nil
+		}
+
+		changes, err := m.documentManager.DetectChanges(m.ctx, m.chat)
+		if err != nil {
+			logging.Error("Failed to detect changes in watched paths: %v", err)
+			return nil
+		}
+
+		return WatchChangesDetected{Changes: changes}
+	}
+}
+
 func (m ChatViewModel) loadMessages() tea.Cmd {
 	return func() tea.Msg {
-		messages, err := m.vectorStore.GetMessages(context.Background())
+		session, err := m.vectorStore.OpenChat(context.Background(), m.chat.ID)
+		if err != nil {
+			return ChatResponseError{Err: err}
+		}
+		defer session.Close()
+
+		messages, err := session.GetMessages(context.Background())
 		if err != nil {
 			return ChatResponseError{Err: err}
 		}
@@ -839,10 +1102,87 @@ func (m ChatViewModel) loadMessages() tea.Cmd {
 	}
 }
 
-func (m ChatViewModel) scheduleStateTransition() tea.Cmd {
-	return tea.Tick(800*time.Millisecond, func(t time.Time) tea.Msg {
-		return StateTransitionMsg{}
-	})
+// loadUsage reloads the messages and the chat totals after a response was stored
+func (m ChatViewModel) loadUsage() tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		chat, err := m.vectorStore.GetChat(ctx, m.chat.ID)
+		if err != nil {
+			logging.Error("Failed to load chat usage: %v", err)
+			return nil
+		}
+
+		session, err := m.vectorStore.OpenChat(ctx, m.chat.ID)
+		if err != nil {
+			logging.Error("Failed to load chat usage: %v", err)
+			return nil
+		}
+		defer session.Close()
+
+		messages, err := session.GetMessages(ctx)
+		if err != nil {
+			logging.Error("Failed to load chat usage: %v", err)
+			return nil
+		}
+		return UsageLoaded{Messages: messages, Usage: chat.Usage}
+	}
+}
+
+// formatTokenUsage shows the tokens of one response, with our estimate next to the reported count
+func formatTokenUsage(usage vector.TokenUsage) string {
+	if !usage.Reported {
+		return fmt.Sprintf("~%d prompt + %d completion tokens (estimated)",
+			usage.EstimatedPromptTokens, usage.EstimatedCompletionTokens)
+	}
+	return fmt.Sprintf("%d prompt + %d completion tokens (estimated %d + %d)",
+		usage.PromptTokens, usage.CompletionTokens,
+		usage.EstimatedPromptTokens, usage.EstimatedCompletionTokens)
+}
+
+// formatSources lists the sources an answer cites with their file:line ranges
+func formatSources(answer string, sources []vector.Source) string {
+	var b strings.Builder
+	b.WriteString("Sources:")
+	for _, source := range vector.CitedSources(answer, sources) {
+		b.WriteString(fmt.Sprintf("\n  [%s] %s", source.ID, source.Location()))
+	}
+	return b.String()
+}
+
+// formatChatUsage shows the cumulative tokens of a chat, actual vs estimated
+func formatChatUsage(usage vector.ChatUsage) string {
+	return fmt.Sprintf("Tokens: %d (est. %d)", usage.TotalTokens(), usage.EstimatedTotalTokens())
+}
+
+// queryPreparation names the LLM steps that turn a chat's messages into search queries
+func queryPreparation(chat *vector.Chat) string {
+	switch {
+	case chat.UseQueryRewriting && chat.UseMultiQuery:
+		return "rewrite + multi"
+	case chat.UseQueryRewriting:
+		return "rewrite"
+	case chat.UseMultiQuery:
+		return "multi"
+	default:
+		return "as typed"
+	}
+}
+
+// stageState maps a pipeline stage to the processing state shown in the status bar
+func stageState(stage models.Stage) ProcessingState {
+	switch stage {
+	case models.StageRewriting:
+		return StateRewriting
+	case models.StageRetrieving:
+		return StateRetrieving
+	case models.StageReranking:
+		return StateReranking
+	case models.StageGenerating:
+		return StateThinking
+	default:
+		return StateEmbedding
+	}
 }
 
 func (m *ChatViewModel) renderMessages() {
@@ -869,7 +1209,14 @@ func (m *ChatViewModel) renderMessages() {
 			renderedContent := m.safeRenderMarkdown(msg.Content)
 
 			b.WriteString(GetAssistantMessageContentStyle(m.width).Render(label + "\n" + renderedContent))
-			b.WriteString("\n\n")
+			b.WriteString("\n")
+			if len(msg.Sources) > 0 {
+				b.WriteString(MetadataStyle.Render(formatSources(msg.Content, msg.Sources)) + "\n")
+			}
+			if msg.Usage != nil {
+				b.WriteString(MetadataStyle.Render(formatTokenUsage(*msg.Usage)) + "\n")
+			}
+			b.WriteString("\n")
 		}
 	}
 
@@ -888,10 +1235,12 @@ func (m *ChatViewModel) flushStreamBuffer() {
 		Role:      "assistant",
 		Content:   m.streamBuffer.String(),
 		Timestamp: time.Now(),
+		Sources:   m.streamSources,
 	}
 	m.messages = append(m.messages, assistantMsg)
 
 	m.streamBuffer.Reset()
+	m.streamSources = nil
 	m.renderMessages()
 	m.viewport.GotoBottom()
 }
@@ -909,7 +1258,14 @@ func (m ChatViewModel) renderScrollIndicator() string {
 
 func (m ChatViewModel) loadAndShowFileSelector() tea.Cmd {
 	return func() tea.Msg {
-		docs, err := m.vectorStore.GetDocuments(context.Background())
+		session, err := m.vectorStore.OpenChat(context.Background(), m.chat.ID)
+		if err != nil {
+			logging.Error("Failed to open chat for file selector: %v", err)
+			return FileSelectorClosed{}
+		}
+		defer session.Close()
+
+		docs, err := session.GetDocuments(context.Background())
 		if err != nil {
 			logging.Error("Failed to load documents for file selector: %v", err)
 			return FileSelectorClosed{}
@@ -919,9 +1275,39 @@ func (m ChatViewModel) loadAndShowFileSelector() tea.Cmd {
 	}
 }
 
+func (m ChatViewModel) reindexDocument(docID, fileName string) tea.Cmd {
+	return func() tea.Msg {
+		changed, err := m.documentManager.ReindexDocument(m.ctx, m.chat, m.embedModel, docID)
+		if err != nil {
+			logging.Error("Failed to reindex file %s: %v", fileName, err)
+			return DocumentReindexed{FileName: fileName, Err: err}
+		}
+
+		return DocumentReindexed{FileName: fileName, Changed: changed}
+	}
+}
+
+func (m ChatViewModel) deleteDocument(docID, fileName string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.documentManager.DeleteDocument(m.ctx, m.chat, docID); err != nil {
+			logging.Error("Failed to delete file %s: %v", fileName, err)
+			return DocumentDeleted{FileName: fileName, Err: err}
+		}
+
+		return DocumentDeleted{FileName: fileName}
+	}
+}
+
 func (m ChatViewModel) loadAndShowFactsViewer() tea.Cmd {
 	return func() tea.Msg {
-		profile, err := m.vectorStore.GetUserProfile(context.Background(), m.chat.ID)
+		session, err := m.vectorStore.OpenChat(context.Background(), m.chat.ID)
+		if err != nil {
+			logging.Error("Failed to open chat for facts viewer: %v", err)
+			return FactsViewerClosed{}
+		}
+		defer session.Close()
+
+		profile, err := session.GetUserProfile(context.Background(), m.chat.ID)
 		if err != nil {
 			logging.Error("Failed to load user profile for facts viewer: %v", err)
 			return FactsViewerClosed{}
@@ -935,10 +1321,51 @@ type FactsViewerLoaded struct {
 	Profile *vector.UserProfile
 }
 
+func (m ChatViewModel) loadAndShowSummaryViewer() tea.Cmd {
+	return func() tea.Msg {
+		session, err := m.vectorStore.OpenChat(context.Background(), m.chat.ID)
+		if err != nil {
+			logging.Error("Failed to open chat for summary viewer: %v", err)
+			return SummaryViewerClosed{}
+		}
+		defer session.Close()
+
+		summary, err := session.GetSummary(context.Background())
+		if err != nil {
+			logging.Error("Failed to load conversation summary: %v", err)
+			return SummaryViewerClosed{}
+		}
+
+		return SummaryViewerLoaded{Summary: summary}
+	}
+}
+
+// SummaryViewerLoaded carries the chat's summary, nil if there is none yet
+type SummaryViewerLoaded struct {
+	Summary *vector.ConversationSummary
+}
+
 type MessagesLoaded struct {
 	Messages []vector.Message
 }
 
+// UsageLoaded carries the stored messages and chat totals after a response
+type UsageLoaded struct {
+	Messages []vector.Message
+	Usage    vector.ChatUsage
+}
+
 type DocumentsLoaded struct {
 	Documents []vector.Document
 }
+
+type DocumentDeleted struct {
+	FileName string
+	Err      error
+}
+
+type DocumentReindexed struct {
+	FileName string
+	Changed  bool
+	Err      error
+}
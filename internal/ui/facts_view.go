@@ -353,7 +353,14 @@ func (m *FactsViewerOverlayModel) UpdateFactsViewer(msg tea.Msg) tea.Cmd {
 }
 
 func (m *FactsViewerOverlayModel) DeleteSelectedFact(ctx context.Context, key string) error {
-	if err := m.factsViewer.vectorStore.DeleteProfileFact(ctx, m.factsViewer.chatID, key); err != nil {
+	session, err := m.factsViewer.vectorStore.OpenChat(ctx, m.factsViewer.chatID)
+	if err != nil {
+		logging.Error("Failed to open chat to delete fact %s: %v", key, err)
+		return err
+	}
+	defer session.Close()
+
+	if err := session.DeleteProfileFact(ctx, m.factsViewer.chatID, key); err != nil {
 		logging.Error("Failed to delete fact %s: %v", key, err)
 		return err
 	}
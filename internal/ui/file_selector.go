@@ -31,6 +31,18 @@ type FileSelected struct {
 // FileSelectorClosed is sent when file selector is closed without selection
 type FileSelectorClosed struct{}
 
+// FileDeleteRequested is sent when user asks to remove a file from the chat
+type FileDeleteRequested struct {
+	DocumentID string
+	FileName   string
+}
+
+// FileReindexRequested is sent when user asks to re-read and re-embed a file
+type FileReindexRequested struct {
+	DocumentID string
+	FileName   string
+}
+
 func NewFileSelectorModel() FileSelectorModel {
 	ti := textinput.New()
 	ti.Placeholder = "Type to filter..."
@@ -109,6 +121,24 @@ func (m FileSelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("delete"))):
+			if len(m.filteredFiles) > 0 {
+				selected := m.filteredFiles[m.selectedIndex]
+				return m, func() tea.Msg {
+					return FileDeleteRequested{DocumentID: selected.ID, FileName: selected.FileName}
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+r"))):
+			if len(m.filteredFiles) > 0 {
+				selected := m.filteredFiles[m.selectedIndex]
+				return m, func() tea.Msg {
+					return FileReindexRequested{DocumentID: selected.ID, FileName: selected.FileName}
+				}
+			}
+			return m, nil
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
 			// If filter has text, clear it first
 			if m.filterInput.Value() != "" {
@@ -282,7 +312,7 @@ func (m FileSelectorModel) renderFileList() string {
 	content.WriteString("\n")
 
 	// Update help text based on filter state
-	helpText := "Type to filter • ↑/↓: Navigate • Enter: Select • Esc: "
+	helpText := "Type to filter • ↑/↓: Navigate • Enter: Select • Del: Remove • Ctrl+R: Re-index • Esc: "
 	if m.filterInput.Value() != "" {
 		helpText += "Clear filter"
 	} else {
@@ -8,7 +8,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
-	"rag-terminal/internal/nexa"
+	"rag-terminal/internal/llm"
 )
 
 type modelSelectState int
@@ -16,12 +16,14 @@ type modelSelectState int
 const (
 	selectingLLM modelSelectState = iota
 	selectingEmbedding
+	selectingReranker
 )
 
 type ModelSelectModel struct {
 	list          list.Model
-	llmModels     []nexa.Model
-	embedModels   []nexa.Model
+	llmModels     []llm.Model
+	embedModels   []llm.Model
+	rerankModels  []llm.Model
 	state         modelSelectState
 	selectedLLM   string
 	selectedEmbed string
@@ -31,26 +33,46 @@ type ModelSelectModel struct {
 }
 
 type modelItem struct {
-	model nexa.Model
+	model llm.Model
 }
 
 func (i modelItem) Title() string       { return i.model.Name }
-func (i modelItem) Description() string { return fmt.Sprintf("Type: %s", i.model.Type) }
 func (i modelItem) FilterValue() string { return i.model.Name }
 
+func (i modelItem) Description() string {
+	desc := fmt.Sprintf("Type: %s", i.model.Type)
+	if i.model.IsRunning {
+		desc += " • loaded"
+	}
+	if i.model.Location != "" {
+		desc += " • " + i.model.Location
+	}
+	return desc
+}
+
+// noRerankerItem lets the user skip the optional reranking model
+type noRerankerItem struct{}
+
+func (i noRerankerItem) Title() string       { return "None" }
+func (i noRerankerItem) Description() string { return "Rerank with the LLM instead" }
+func (i noRerankerItem) FilterValue() string { return "None" }
+
 type ModelSelectionComplete struct {
-	LLMModel   string
-	EmbedModel string
+	LLMModel    string
+	EmbedModel  string
+	RerankModel string // Empty when no reranking model was selected
 }
 
-func NewModelSelectModel(models []nexa.Model, width, height int) ModelSelectModel {
+func NewModelSelectModel(models []llm.Model, width, height int) ModelSelectModel {
 	// Separate models by type
-	var llmModels, embedModels []nexa.Model
+	var llmModels, embedModels, rerankModels []llm.Model
 	for _, m := range models {
 		if m.Type == "text-generation" {
 			llmModels = append(llmModels, m)
 		} else if m.Type == "embeddings" {
 			embedModels = append(embedModels, m)
+		} else if m.Type == "reranking" {
+			rerankModels = append(rerankModels, m)
 		}
 	}
 
@@ -85,12 +107,13 @@ func NewModelSelectModel(models []nexa.Model, width, height int) ModelSelectMode
 	l.KeyMap.ForceQuit = key.NewBinding()
 
 	return ModelSelectModel{
-		list:        l,
-		llmModels:   llmModels,
-		embedModels: embedModels,
-		state:       selectingLLM,
-		width:       width,
-		height:      height,
+		list:         l,
+		llmModels:    llmModels,
+		embedModels:  embedModels,
+		rerankModels: rerankModels,
+		state:        selectingLLM,
+		width:        width,
+		height:       height,
 	}
 }
 
@@ -132,17 +155,46 @@ func (m ModelSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				ConfigureListStyles(&m.list)
 				return m, nil
 			} else if m.state == selectingEmbedding {
-				// Save embedding selection and complete
+				// Save embedding selection
 				m.selectedEmbed = selectedItem.(modelItem).model.Name
-				return m, func() tea.Msg {
-					return ModelSelectionComplete{
-						LLMModel:   m.selectedLLM,
-						EmbedModel: m.selectedEmbed,
-					}
+
+				// The reranker is optional, so skip the step when none are installed
+				if len(m.rerankModels) == 0 {
+					return m, m.complete("")
 				}
+
+				m.state = selectingReranker
+				items := []list.Item{noRerankerItem{}}
+				for _, model := range m.rerankModels {
+					items = append(items, modelItem{model: model})
+				}
+				m.list.SetItems(items)
+				m.list.Title = "Select Reranking Model (optional)"
+				ConfigureListStyles(&m.list)
+				return m, nil
+			} else if m.state == selectingReranker {
+				// Save reranker selection and complete
+				rerankModel := ""
+				if item, ok := selectedItem.(modelItem); ok {
+					rerankModel = item.model.Name
+				}
+				return m, m.complete(rerankModel)
 			}
 
 		case "esc":
+			if m.state == selectingReranker {
+				// Go back to embedding selection
+				m.state = selectingEmbedding
+				m.selectedEmbed = ""
+				items := make([]list.Item, len(m.embedModels))
+				for i, model := range m.embedModels {
+					items[i] = modelItem{model: model}
+				}
+				m.list.SetItems(items)
+				m.list.Title = "Select Embedding Model"
+				ConfigureListStyles(&m.list)
+				return m, nil
+			}
 			if m.state == selectingEmbedding {
 				// Go back to LLM selection
 				m.state = selectingLLM
@@ -163,6 +215,17 @@ func (m ModelSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// complete finishes model selection with the chosen models
+func (m ModelSelectModel) complete(rerankModel string) tea.Cmd {
+	return func() tea.Msg {
+		return ModelSelectionComplete{
+			LLMModel:    m.selectedLLM,
+			EmbedModel:  m.selectedEmbed,
+			RerankModel: rerankModel,
+		}
+	}
+}
+
 func (m ModelSelectModel) View() string {
 	if m.err != nil {
 		return errorStyle.Render(fmt.Sprintf("Error: %v\n\nPress Ctrl+X to exit", m.err))
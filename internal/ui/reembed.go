@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"rag-terminal/internal/rag"
+)
+
+// ReembedChat asks for a chat to be re-embedded with the selected embedding model in the background
+type ReembedChat struct {
+	ChatID string
+	Name   string
+}
+
+// ChatReembedProgress reports a background re-embedding started from the chat list
+type ChatReembedProgress struct {
+	Name         string
+	Done         int
+	Total        int
+	progressChan <-chan reembedStatus
+	errChan      <-chan error
+}
+
+// ChatReembedded reports the end of a background re-embedding started from the chat list
+type ChatReembedded struct {
+	Name string
+	Err  error
+}
+
+// reembedStatus is a progress update of a running re-embedding
+type reembedStatus struct {
+	done  int
+	total int
+}
+
+// runReembed re-embeds a chat in a goroutine. A slow reader only sees the latest progress.
+// The progress channel is closed once the result is on the error channel.
+func runReembed(ctx context.Context, reembedder *rag.Reembedder, chatID, embedModel string, dimensions int) (<-chan reembedStatus, <-chan error) {
+	progressChan := make(chan reembedStatus, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(progressChan)
+		err := reembedder.ReembedChat(ctx, chatID, embedModel, dimensions, func(done, total int) {
+			// Drop an update the UI hasn't picked up yet, the next one supersedes it
+			select {
+			case <-progressChan:
+			default:
+			}
+			progressChan <- reembedStatus{done: done, total: total}
+		})
+		errChan <- err
+	}()
+
+	return progressChan, errChan
+}
+
+// StartChatReembed re-embeds a chat independently of any view, so it keeps running while other
+// chats are used. Progress arrives as ChatReembedProgress messages, which must be passed to
+// WaitForChatReembed to keep receiving them.
+func StartChatReembed(reembedder *rag.Reembedder, req ReembedChat, embedModel string, dimensions int) tea.Cmd {
+	progressChan, errChan := runReembed(context.Background(), reembedder, req.ChatID, embedModel, dimensions)
+	return WaitForChatReembed(ChatReembedProgress{Name: req.Name, progressChan: progressChan, errChan: errChan})
+}
+
+// WaitForChatReembed waits for the next update after msg
+func WaitForChatReembed(msg ChatReembedProgress) tea.Cmd {
+	return func() tea.Msg {
+		status, ok := <-msg.progressChan
+		if !ok {
+			return ChatReembedded{Name: msg.Name, Err: <-msg.errChan}
+		}
+		msg.Done = status.done
+		msg.Total = status.total
+		return msg
+	}
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	overlay "github.com/rmhubbert/bubbletea-overlay"
+
+	"rag-terminal/internal/logging"
+	"rag-terminal/internal/vector"
+)
+
+// SummaryViewerModel represents the conversation summary overlay foreground
+type SummaryViewerModel struct {
+	summary     *vector.ConversationSummary // nil until one was generated or written
+	editor      textarea.Model
+	width       int
+	height      int
+	vectorStore vector.VectorStore
+	chatID      string
+}
+
+// SummarySaved is sent when the user saves the edited summary
+type SummarySaved struct {
+	Content string
+}
+
+// SummaryViewerClosed is sent when the summary viewer is closed
+type SummaryViewerClosed struct{}
+
+func NewSummaryViewerModel(vectorStore vector.VectorStore) SummaryViewerModel {
+	ta := textarea.New()
+	ta.Placeholder = "No summary yet. One is generated as the conversation grows, or write your own."
+	ta.CharLimit = 8192
+	ta.ShowLineNumbers = false
+	ta.SetHeight(12)
+
+	return SummaryViewerModel{
+		editor:      ta,
+		vectorStore: vectorStore,
+	}
+}
+
+func (m SummaryViewerModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m *SummaryViewerModel) SetSummary(chatID string, summary *vector.ConversationSummary) {
+	m.chatID = chatID
+	m.summary = summary
+
+	m.editor.Reset()
+	if summary != nil {
+		m.editor.SetValue(summary.Content)
+	}
+	m.editor.SetWidth(m.overlayWidth() - 6)
+	m.editor.Focus()
+}
+
+// overlayWidth uses 50% of window width, like the facts viewer
+func (m SummaryViewerModel) overlayWidth() int {
+	overlayWidth := m.width / 2
+	if overlayWidth < 50 {
+		overlayWidth = 50 // Minimum width
+	}
+	return overlayWidth
+}
+
+func (m SummaryViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+s"))):
+			content := strings.TrimSpace(m.editor.Value())
+			return m, func() tea.Msg {
+				return SummarySaved{Content: content}
+			}
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+			// Close without saving
+			return m, func() tea.Msg {
+				return SummaryViewerClosed{}
+			}
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.editor.SetWidth(m.overlayWidth() - 6)
+	}
+
+	m.editor, cmd = m.editor.Update(msg)
+	return m, cmd
+}
+
+func (m SummaryViewerModel) View() string {
+	overlayWidth := m.overlayWidth()
+
+	var content strings.Builder
+	content.WriteString(GetFileSelectorTitleStyle(m.summary == nil).Render("Conversation Summary"))
+	content.WriteString("\n\n")
+
+	if m.summary != nil {
+		info := fmt.Sprintf("Covers %d turns • Updated %s", m.summary.Turns, m.summary.UpdatedAt.Format("2006-01-02 15:04"))
+		if m.summary.Edited {
+			info += " • Edited"
+		}
+		content.WriteString(GetFileSelectorItemStyle(overlayWidth, "dimmed").Render(info))
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(m.editor.View())
+	content.WriteString("\n\n")
+	content.WriteString(HelpTextSimpleStyle.Render("Ctrl+S: Save • Esc: Close without saving"))
+
+	return GetFileSelectorBorderStyle(overlayWidth, false).Render(content.String())
+}
+
+// SummaryViewerOverlayModel wraps the summary viewer with the overlay library
+type SummaryViewerOverlayModel struct {
+	summaryViewer SummaryViewerModel
+	visible       bool
+}
+
+func NewSummaryViewerOverlayModel(vectorStore vector.VectorStore) SummaryViewerOverlayModel {
+	return SummaryViewerOverlayModel{
+		summaryViewer: NewSummaryViewerModel(vectorStore),
+		visible:       false,
+	}
+}
+
+func (m *SummaryViewerOverlayModel) SetSummary(chatID string, summary *vector.ConversationSummary) {
+	m.summaryViewer.SetSummary(chatID, summary)
+}
+
+func (m *SummaryViewerOverlayModel) Show() {
+	m.visible = true
+}
+
+func (m *SummaryViewerOverlayModel) Hide() {
+	m.visible = false
+	m.summaryViewer.editor.Blur()
+}
+
+func (m *SummaryViewerOverlayModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *SummaryViewerOverlayModel) UpdateSize(width, height int) {
+	m.summaryViewer.width = width
+	m.summaryViewer.height = height
+	m.summaryViewer.editor.SetWidth(m.summaryViewer.overlayWidth() - 6)
+}
+
+func (m *SummaryViewerOverlayModel) UpdateSummaryViewer(msg tea.Msg) tea.Cmd {
+	if !m.visible {
+		return nil
+	}
+
+	var cmd tea.Cmd
+	var mdl tea.Model
+	mdl, cmd = m.summaryViewer.Update(msg)
+	m.summaryViewer = mdl.(SummaryViewerModel)
+	return cmd
+}
+
+// SaveSummary stores content as the chat's summary. The turns it covers are kept, so the next
+// regeneration folds only newer turns into the edited text.
+func (m *SummaryViewerOverlayModel) SaveSummary(ctx context.Context, content string) error {
+	session, err := m.summaryViewer.vectorStore.OpenChat(ctx, m.summaryViewer.chatID)
+	if err != nil {
+		logging.Error("Failed to open chat to save summary: %v", err)
+		return err
+	}
+	defer session.Close()
+
+	summary := &vector.ConversationSummary{}
+	if m.summaryViewer.summary != nil {
+		*summary = *m.summaryViewer.summary
+	}
+	summary.Content = content
+	summary.Edited = true
+
+	if err := session.StoreSummary(ctx, summary); err != nil {
+		logging.Error("Failed to save summary: %v", err)
+		return err
+	}
+
+	m.summaryViewer.summary = summary
+	return nil
+}
+
+func (m SummaryViewerOverlayModel) RenderOverlay(backgroundView string) string {
+	if !m.visible {
+		return backgroundView
+	}
+
+	overlayModel := overlay.New(
+		m.summaryViewer,
+		&staticViewModel{content: backgroundView},
+		overlay.Center, // horizontal position
+		overlay.Top,    // vertical position
+		0,              // x offset
+		1,              // y offset (minimal top margin)
+	)
+
+	return overlayModel.View()
+}
@@ -12,94 +12,55 @@ import (
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
+
+	"rag-terminal/internal/logging"
 )
 
+// BadgerStore keeps chat metadata on disk and hands out sessions for the per-chat databases
 type BadgerStore struct {
-	baseDir       string
-	currentChatID string
-	currentDB     *badger.DB
-	hnswIndex     *HNSWIndex
-	mu            sync.RWMutex
+	baseDir  string
+	analyzer KeywordAnalyzer // Fixed for the store's lifetime, every chat's keyword index is built with it
+	fusion   FusionWeights
+	maxIdle  int                      // Idle sessions kept open before the least recently used is closed
+	sessions map[string]*ChatSession  // Open chat databases by chat ID
+	opening  map[string]chan struct{} // Chats being opened by chat ID, closed once the open finished
+	closed   bool                     // Set by Close; chats can't be opened afterwards
+	useSeq   uint64                   // Incremented on every OpenChat to order sessions by recency
+	mu       sync.RWMutex
 }
 
-func NewBadgerStore(baseDir string) (*BadgerStore, error) {
+// NewBadgerStore opens the store under baseDir. Keyword indexes are built and queried with analyzer;
+// pass the same analyzer from every entry point, or indexes are rebuilt whenever it changes.
+func NewBadgerStore(baseDir string, analyzer KeywordAnalyzer) (*BadgerStore, error) {
+	if analyzer.Terms == nil {
+		analyzer = defaultAnalyzer
+	}
+
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
 	}
 
 	return &BadgerStore{
-		baseDir:   baseDir,
-		hnswIndex: NewHNSWIndex(DefaultHNSWConfig()),
+		baseDir:  baseDir,
+		analyzer: analyzer,
+		fusion:   DefaultFusionWeights(),
+		maxIdle:  DefaultMaxIdleChats,
+		sessions: make(map[string]*ChatSession),
+		opening:  make(map[string]chan struct{}),
 	}, nil
 }
 
-func (s *BadgerStore) OpenChat(ctx context.Context, chatID string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Close existing chat if open
-	if s.currentDB != nil {
-		if err := s.currentDB.Close(); err != nil {
-			return fmt.Errorf("failed to close current chat database: %w", err)
-		}
-		s.currentDB = nil
-		s.currentChatID = ""
-	}
-
-	// Open database for the specified chat
-	chatDBPath := filepath.Join(s.baseDir, chatID, "messages.db")
-	if err := os.MkdirAll(filepath.Dir(chatDBPath), 0755); err != nil {
-		return fmt.Errorf("failed to create chat directory: %w", err)
-	}
-
-	opts := badger.DefaultOptions(chatDBPath)
-	opts.Logger = nil // Disable logging
-
-	db, err := badger.Open(opts)
-	if err != nil {
-		return fmt.Errorf("failed to open chat database: %w", err)
-	}
-
-	s.currentDB = db
-	s.currentChatID = chatID
-
-	// Build HNSW index from existing vectors
-	if err := s.buildIndex(ctx); err != nil {
-		return fmt.Errorf("failed to build HNSW index: %w", err)
-	}
-
-	return nil
-}
-
-func (s *BadgerStore) CloseChat(ctx context.Context) error {
+func (s *ChatSession) StoreMessage(ctx context.Context, messageID, role, content string, embedding []float32, timestamp time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.currentDB == nil {
-		return nil // No chat open
-	}
-
-	if err := s.currentDB.Close(); err != nil {
-		return fmt.Errorf("failed to close chat database: %w", err)
-	}
-
-	s.currentDB = nil
-	s.currentChatID = ""
-	s.hnswIndex.Clear()
-	return nil
-}
-
-func (s *BadgerStore) StoreMessage(ctx context.Context, messageID, role, content string, embedding []float32, timestamp time.Time) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
 	msg := Message{
 		ID:        messageID,
-		ChatID:    s.currentChatID,
+		ChatID:    s.chatID,
 		Role:      role,
 		Content:   content,
 		Embedding: embedding,
@@ -111,29 +72,41 @@ func (s *BadgerStore) StoreMessage(ctx context.Context, messageID, role, content
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	key := fmt.Sprintf("msg:%s", messageID)
-	err = s.currentDB.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(key), data)
-	})
-
-	if err != nil {
-		return err
+	var stats keywordStats
+	if role == "context" {
+		if stats, _, err = s.loadKeywordStats(); err != nil {
+			return err
+		}
 	}
 
 	// Add to HNSW index if it has an embedding (context messages only)
-	if len(embedding) > 0 && role == "context" {
-		s.hnswIndex.Add(messageID, embedding, true, true)
+	var added string
+	if len(embedding) > 0 && role == "context" && s.hnswIndex.Add(messageID, embedding, true, true) {
+		added = messageID
 	}
 
-	return nil
+	// Store the message together with the graph nodes and postings it touched
+	key := fmt.Sprintf("msg:%s", messageID)
+	return s.updateWithNode(added, func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(key), data); err != nil {
+			return err
+		}
+		if role == "context" {
+			if err := s.indexKeywords(txn, &stats, messageID, content); err != nil {
+				return err
+			}
+			return s.writeKeywordStats(txn, stats)
+		}
+		return nil
+	})
 }
 
-func (s *BadgerStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, topK int) ([]Message, error) {
+func (s *ChatSession) SearchSimilar(ctx context.Context, queryEmbedding []float32, topK int) ([]Message, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.currentDB == nil {
-		return nil, fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return nil, ErrChatClosed
 	}
 
 	// Use HNSW index for fast approximate nearest neighbor search
@@ -147,7 +120,7 @@ func (s *BadgerStore) SearchSimilar(ctx context.Context, queryEmbedding []float3
 	// Retrieve full messages from database
 	resultMessages := make([]Message, 0, len(candidateIDs))
 
-	err := s.currentDB.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn *badger.Txn) error {
 		for _, id := range candidateIDs {
 			key := fmt.Sprintf("msg:%s", id)
 			item, err := txn.Get([]byte(key))
@@ -177,7 +150,7 @@ func (s *BadgerStore) SearchSimilar(ctx context.Context, queryEmbedding []float3
 	return resultMessages, nil
 }
 
-func (s *BadgerStore) GetMessages(ctx context.Context) ([]Message, error) {
+func (s *ChatSession) GetMessages(ctx context.Context) ([]Message, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -248,12 +221,12 @@ func (s *BadgerStore) UpdateChat(ctx context.Context, chat *Chat) error {
 // iterateWithPrefix is a generic helper that iterates through items with a given prefix
 // and calls processor for each item. The processor is responsible for unmarshalling
 // and handling the item value. This reduces code duplication across retrieval methods.
-func (s *BadgerStore) iterateWithPrefix(prefix []byte, processor func(*badger.Item) error) error {
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+func (s *ChatSession) iterateWithPrefix(prefix []byte, processor func(*badger.Item) error) error {
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
-	return s.currentDB.View(func(txn *badger.Txn) error {
+	return s.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = prefix
 		it := txn.NewIterator(opts)
@@ -286,6 +259,10 @@ func (s *BadgerStore) GetChat(ctx context.Context, chatID string) (*Chat, error)
 		return nil, fmt.Errorf("failed to unmarshal chat metadata: %w", err)
 	}
 
+	if chat.Usage, err = s.readChatUsage(chatID); err != nil {
+		return nil, err
+	}
+
 	return &chat, nil
 }
 
@@ -322,6 +299,9 @@ func (s *BadgerStore) ListChats(ctx context.Context) ([]Chat, error) {
 			continue
 		}
 
+		// Missing or unreadable totals just show as no usage
+		chat.Usage, _ = s.readChatUsage(chatID)
+
 		chats = append(chats, chat)
 	}
 
@@ -337,13 +317,17 @@ func (s *BadgerStore) DeleteChat(ctx context.Context, chatID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Close database if this is the currently open chat
-	if s.currentChatID == chatID && s.currentDB != nil {
-		if err := s.currentDB.Close(); err != nil {
+	// Close the database first; a chat still in use elsewhere can't be deleted
+	if _, ok := s.opening[chatID]; ok {
+		return fmt.Errorf("chat %s is in use", chatID)
+	}
+	if session, ok := s.sessions[chatID]; ok {
+		if session.refs > 0 {
+			return fmt.Errorf("chat %s is in use", chatID)
+		}
+		if err := s.closeSession(session); err != nil {
 			return fmt.Errorf("failed to close database before deletion: %w", err)
 		}
-		s.currentDB = nil
-		s.currentChatID = ""
 	}
 
 	// Remove entire chat directory
@@ -355,31 +339,33 @@ func (s *BadgerStore) DeleteChat(ctx context.Context, chatID string) error {
 	return nil
 }
 
+// Close closes every open chat database, including sessions that are still referenced.
+// Chats still being opened are closed as soon as their indexes are loaded.
 func (s *BadgerStore) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.currentDB != nil {
-		if err := s.currentDB.Close(); err != nil {
-			return fmt.Errorf("failed to close database: %w", err)
+	s.closed = true
+
+	var firstErr error
+	for _, session := range s.sessions {
+		if err := s.closeSession(session); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close database: %w", err)
 		}
-		s.currentDB = nil
-		s.currentChatID = ""
-		s.hnswIndex.Clear()
 	}
 
-	return nil
+	return firstErr
 }
 
 // Document storage methods
 
 // StoreDocument stores a document metadata in the chat context
-func (s *BadgerStore) StoreDocument(ctx context.Context, doc *Document) error {
+func (s *ChatSession) StoreDocument(ctx context.Context, doc *Document) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
 	data, err := json.Marshal(doc)
@@ -388,13 +374,13 @@ func (s *BadgerStore) StoreDocument(ctx context.Context, doc *Document) error {
 	}
 
 	key := fmt.Sprintf("doc:%s", doc.ID)
-	return s.currentDB.Update(func(txn *badger.Txn) error {
+	return s.db.Update(func(txn *badger.Txn) error {
 		return txn.Set([]byte(key), data)
 	})
 }
 
-// GetAllMessages retrieves all messages from the current chat
-func (s *BadgerStore) GetAllMessages(ctx context.Context) ([]Message, error) {
+// GetAllMessages retrieves all messages from the session's chat
+func (s *ChatSession) GetAllMessages(ctx context.Context) ([]Message, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -420,12 +406,12 @@ func (s *BadgerStore) GetAllMessages(ctx context.Context) ([]Message, error) {
 }
 
 // StoreDocumentChunk stores a document chunk with its embedding
-func (s *BadgerStore) StoreDocumentChunk(ctx context.Context, chunk *DocumentChunk) error {
+func (s *ChatSession) StoreDocumentChunk(ctx context.Context, chunk *DocumentChunk) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
 	data, err := json.Marshal(chunk)
@@ -433,25 +419,32 @@ func (s *BadgerStore) StoreDocumentChunk(ctx context.Context, chunk *DocumentChu
 		return fmt.Errorf("failed to marshal chunk: %w", err)
 	}
 
-	key := fmt.Sprintf("chunk:%s", chunk.ID)
-	err = s.currentDB.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(key), data)
-	})
-
+	stats, _, err := s.loadKeywordStats()
 	if err != nil {
 		return err
 	}
 
 	// Add to HNSW index if it has an embedding
-	if len(chunk.Embedding) > 0 {
-		s.hnswIndex.Add(chunk.ID, chunk.Embedding, false, false)
+	var added string
+	if len(chunk.Embedding) > 0 && s.hnswIndex.Add(chunk.ID, chunk.Embedding, false, false) {
+		added = chunk.ID
 	}
 
-	return nil
+	// Store the chunk together with the graph nodes and postings it touched
+	key := fmt.Sprintf("chunk:%s", chunk.ID)
+	return s.updateWithNode(added, func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(key), data); err != nil {
+			return err
+		}
+		if err := s.indexKeywords(txn, &stats, chunk.ID, chunk.Content); err != nil {
+			return err
+		}
+		return s.writeKeywordStats(txn, stats)
+	})
 }
 
-// GetDocuments retrieves all documents for the current chat
-func (s *BadgerStore) GetDocuments(ctx context.Context) ([]Document, error) {
+// GetDocuments retrieves all documents for the session's chat
+func (s *ChatSession) GetDocuments(ctx context.Context) ([]Document, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -481,19 +474,42 @@ func (s *BadgerStore) GetDocuments(ctx context.Context) ([]Document, error) {
 	return documents, nil
 }
 
-// GetDocumentCount returns the number of documents in the current chat
-func (s *BadgerStore) GetDocumentCount(ctx context.Context) (int, error) {
+// ForEachDocumentChunk calls fn for every stored chunk, embeddings included, without
+// loading all chunks into memory. fn must not call back into the session.
+func (s *ChatSession) ForEachDocumentChunk(ctx context.Context, fn func(chunk *DocumentChunk) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	err := s.iterateWithPrefix([]byte("chunk:"), func(item *badger.Item) error {
+		return item.Value(func(val []byte) error {
+			var chunk DocumentChunk
+			if err := json.Unmarshal(val, &chunk); err != nil {
+				return err
+			}
+			return fn(&chunk)
+		})
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to iterate document chunks: %w", err)
+	}
+
+	return nil
+}
+
+// GetDocumentCount returns the number of documents in the session's chat
+func (s *ChatSession) GetDocumentCount(ctx context.Context) (int, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.currentDB == nil {
-		return 0, fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return 0, ErrChatClosed
 	}
 
 	count := 0
 	prefix := []byte("doc:")
 
-	err := s.currentDB.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = prefix
 		opts.PrefetchValues = false // Only count keys
@@ -514,18 +530,18 @@ func (s *BadgerStore) GetDocumentCount(ctx context.Context) (int, error) {
 }
 
 // FindDocumentByHash checks if a document with the same content hash already exists
-func (s *BadgerStore) FindDocumentByHash(ctx context.Context, contentHash string) (*Document, error) {
+func (s *ChatSession) FindDocumentByHash(ctx context.Context, contentHash string) (*Document, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.currentDB == nil {
-		return nil, fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return nil, ErrChatClosed
 	}
 
 	var foundDoc *Document
 	prefix := []byte("doc:")
 
-	err := s.currentDB.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = prefix
 		it := txn.NewIterator(opts)
@@ -561,19 +577,219 @@ func (s *BadgerStore) FindDocumentByHash(ctx context.Context, contentHash string
 	return foundDoc, nil
 }
 
-// SearchSimilarContextAndChunks searches for similar content including Q&A pairs and document chunks
-func (s *BadgerStore) SearchSimilarContextAndChunks(ctx context.Context, queryEmbedding []float32, topK int) ([]Message, []DocumentChunk, error) {
+// DeleteDocument removes a document, all of its chunks and their HNSW nodes from the session's chat
+func (s *ChatSession) DeleteDocument(ctx context.Context, docID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return ErrChatClosed
+	}
+
+	if err := s.requireDocument(docID); err != nil {
+		return err
+	}
+
+	chunks, err := s.documentChunks(docID)
+	if err != nil {
+		return err
+	}
+
+	stats, _, err := s.loadKeywordStats()
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		s.hnswIndex.Remove(chunk.ID)
+	}
+
+	// A large document can exceed a single transaction, so use a write batch
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, chunk := range chunks {
+		if err := wb.Delete([]byte(fmt.Sprintf("chunk:%s", chunk.ID))); err != nil {
+			return fmt.Errorf("failed to delete chunk %s: %w", chunk.ID, err)
+		}
+		if err := s.unindexKeywords(wb, &stats, chunk.ID, chunk.Content); err != nil {
+			return fmt.Errorf("failed to unindex chunk %s: %w", chunk.ID, err)
+		}
+	}
+	if err := wb.Delete([]byte(fmt.Sprintf("doc:%s", docID))); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	if err := s.writeKeywordStats(wb, stats); err != nil {
+		return err
+	}
+	written, err := s.writeIndexChanges(wb)
+	if err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	s.hnswIndex.markPersisted(written)
+
+	logging.Info("Deleted document %s (%d chunks)", docID, len(chunks))
+	return nil
+}
+
+// ReindexDocument replaces the metadata and chunks of an existing document.
+// doc.ID must refer to a stored document; its old chunks and HNSW nodes are dropped
+// and the new chunks (with embeddings already populated) take their place.
+func (s *ChatSession) ReindexDocument(ctx context.Context, doc *Document, chunks []DocumentChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return ErrChatClosed
+	}
+
+	if err := s.requireDocument(doc.ID); err != nil {
+		return err
+	}
+
+	oldChunks, err := s.documentChunks(doc.ID)
+	if err != nil {
+		return err
+	}
+
+	stats, _, err := s.loadKeywordStats()
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range oldChunks {
+		s.hnswIndex.Remove(chunk.ID)
+	}
+	var added []string
+	for _, chunk := range chunks {
+		if len(chunk.Embedding) > 0 && s.hnswIndex.Add(chunk.ID, chunk.Embedding, false, false) {
+			added = append(added, chunk.ID)
+		}
+	}
+	// If the batch isn't written, the new chunks must not be returned by searches
+	failed := true
+	defer func() {
+		if failed {
+			for _, id := range added {
+				s.hnswIndex.Remove(id)
+			}
+		}
+	}()
+
+	doc.ChunkCount = len(chunks)
+	docData, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, chunk := range oldChunks {
+		if err := wb.Delete([]byte(fmt.Sprintf("chunk:%s", chunk.ID))); err != nil {
+			return fmt.Errorf("failed to delete chunk %s: %w", chunk.ID, err)
+		}
+		if err := s.unindexKeywords(wb, &stats, chunk.ID, chunk.Content); err != nil {
+			return fmt.Errorf("failed to unindex chunk %s: %w", chunk.ID, err)
+		}
+	}
+	for _, chunk := range chunks {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return fmt.Errorf("failed to marshal chunk: %w", err)
+		}
+		if err := wb.Set([]byte(fmt.Sprintf("chunk:%s", chunk.ID)), data); err != nil {
+			return fmt.Errorf("failed to store chunk %s: %w", chunk.ID, err)
+		}
+		if err := s.indexKeywords(wb, &stats, chunk.ID, chunk.Content); err != nil {
+			return fmt.Errorf("failed to index chunk %s: %w", chunk.ID, err)
+		}
+	}
+	if err := s.writeKeywordStats(wb, stats); err != nil {
+		return err
+	}
+	if err := wb.Set([]byte(fmt.Sprintf("doc:%s", doc.ID)), docData); err != nil {
+		return fmt.Errorf("failed to store document: %w", err)
+	}
+	written, err := s.writeIndexChanges(wb)
+	if err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("failed to reindex document: %w", err)
+	}
+	failed = false
+	s.hnswIndex.markPersisted(written)
+
+	logging.Info("Reindexed document %s: %d chunks replaced by %d", doc.FileName, len(oldChunks), len(chunks))
+	return nil
+}
+
+// requireDocument returns an error if no document with the given ID exists
+func (s *ChatSession) requireDocument(docID string) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(fmt.Sprintf("doc:%s", docID)))
+		if err == badger.ErrKeyNotFound {
+			return fmt.Errorf("document not found: %s", docID)
+		}
+		return err
+	})
+}
+
+// documentChunks returns all stored chunks belonging to a document
+func (s *ChatSession) documentChunks(docID string) ([]DocumentChunk, error) {
+	var chunks []DocumentChunk
+
+	err := s.iterateWithPrefix([]byte("chunk:"), func(item *badger.Item) error {
+		return item.Value(func(val []byte) error {
+			var chunk DocumentChunk
+			if err := json.Unmarshal(val, &chunk); err != nil {
+				return err
+			}
+			if chunk.DocumentID == docID {
+				chunks = append(chunks, chunk)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect document chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// SearchSimilarContextAndChunks searches for similar content including Q&A pairs and document chunks.
+// Vector results are fused with BM25 keyword results for query using reciprocal rank fusion.
+func (s *ChatSession) SearchSimilarContextAndChunks(ctx context.Context, query string, queryEmbedding []float32, topK int) ([]Message, []DocumentChunk, error) {
+	fusion := s.store.fusionWeights()
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.currentDB == nil {
-		return nil, nil, fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return nil, nil, ErrChatClosed
 	}
 
 	// Use HNSW index for fast search across all vectors (messages and chunks)
 	// Search for more candidates than needed since we'll split between messages and chunks
 	candidateIDs := s.hnswIndex.Search(queryEmbedding, topK*2, false)
 
+	// Keyword search catches exact identifiers the embedding model blurs together
+	if fusion.Keyword > 0 {
+		keywordIDs, err := s.keywordSearch(query, topK*2)
+		if err != nil {
+			logging.Error("Keyword search failed, using vector results only: %v", err)
+		} else if len(keywordIDs) > 0 {
+			logging.Debug("Fusing %d vector and %d keyword candidates", len(candidateIDs), len(keywordIDs))
+			candidateIDs = fuseRankings(candidateIDs, keywordIDs, fusion)
+		}
+	}
+
 	if len(candidateIDs) == 0 {
 		return []Message{}, []DocumentChunk{}, nil
 	}
@@ -582,7 +798,7 @@ func (s *BadgerStore) SearchSimilarContextAndChunks(ctx context.Context, queryEm
 	var contextMessages []Message
 	var chunks []DocumentChunk
 
-	err := s.currentDB.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn *badger.Txn) error {
 		for _, id := range candidateIDs {
 			// Try as message first
 			msgKey := fmt.Sprintf("msg:%s", id)
@@ -655,10 +871,10 @@ func (s *BadgerStore) SearchSimilarContextAndChunks(ctx context.Context, queryEm
 	return contextMessages[:messageCount], chunks[:chunkCount], nil
 }
 
-// buildIndex constructs the HNSW index from all vectors stored in the current chat database
-func (s *BadgerStore) buildIndex(ctx context.Context) error {
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+// buildIndex constructs the HNSW index from all vectors stored in the session's chat database
+func (s *ChatSession) buildIndex(ctx context.Context) error {
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
 	// Clear existing index
@@ -666,7 +882,7 @@ func (s *BadgerStore) buildIndex(ctx context.Context) error {
 
 	// Load all messages with embeddings
 	msgPrefix := []byte("msg:")
-	err := s.currentDB.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = msgPrefix
 		it := txn.NewIterator(opts)
@@ -698,7 +914,7 @@ func (s *BadgerStore) buildIndex(ctx context.Context) error {
 
 	// Load all document chunks with embeddings
 	chunkPrefix := []byte("chunk:")
-	err = s.currentDB.View(func(txn *badger.Txn) error {
+	err = s.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = chunkPrefix
 		it := txn.NewIterator(opts)
@@ -734,12 +950,12 @@ func (s *BadgerStore) buildIndex(ctx context.Context) error {
 // Profile management methods
 
 // StoreUserProfile stores the entire user profile for a chat
-func (s *BadgerStore) StoreUserProfile(ctx context.Context, profile *UserProfile) error {
+func (s *ChatSession) StoreUserProfile(ctx context.Context, profile *UserProfile) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
 	profile.UpdatedAt = time.Now()
@@ -749,23 +965,23 @@ func (s *BadgerStore) StoreUserProfile(ctx context.Context, profile *UserProfile
 	}
 
 	key := fmt.Sprintf("profile:%s", profile.ChatID)
-	return s.currentDB.Update(func(txn *badger.Txn) error {
+	return s.db.Update(func(txn *badger.Txn) error {
 		return txn.Set([]byte(key), data)
 	})
 }
 
 // GetUserProfile retrieves the user profile for a specific chat
-func (s *BadgerStore) GetUserProfile(ctx context.Context, chatID string) (*UserProfile, error) {
+func (s *ChatSession) GetUserProfile(ctx context.Context, chatID string) (*UserProfile, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.currentDB == nil {
-		return nil, fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return nil, ErrChatClosed
 	}
 
 	var profile *UserProfile
 
-	err := s.currentDB.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn *badger.Txn) error {
 		key := fmt.Sprintf("profile:%s", chatID)
 		item, err := txn.Get([]byte(key))
 		if err != nil {
@@ -799,17 +1015,17 @@ func (s *BadgerStore) GetUserProfile(ctx context.Context, chatID string) (*UserP
 }
 
 // UpsertProfileFact inserts or updates a single fact, and maintains history
-func (s *BadgerStore) UpsertProfileFact(ctx context.Context, chatID string, fact ProfileFact) error {
+func (s *ChatSession) UpsertProfileFact(ctx context.Context, chatID string, fact ProfileFact) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
 	now := time.Now()
 
-	return s.currentDB.Update(func(txn *badger.Txn) error {
+	return s.db.Update(func(txn *badger.Txn) error {
 		// Get current profile
 		profileKey := fmt.Sprintf("profile:%s", chatID)
 		item, err := txn.Get([]byte(profileKey))
@@ -880,17 +1096,17 @@ func (s *BadgerStore) UpsertProfileFact(ctx context.Context, chatID string, fact
 }
 
 // GetProfileFact retrieves a single fact from the user's profile
-func (s *BadgerStore) GetProfileFact(ctx context.Context, chatID string, key string) (*ProfileFact, error) {
+func (s *ChatSession) GetProfileFact(ctx context.Context, chatID string, key string) (*ProfileFact, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.currentDB == nil {
-		return nil, fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return nil, ErrChatClosed
 	}
 
 	var fact *ProfileFact
 
-	err := s.currentDB.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn *badger.Txn) error {
 		factKey := fmt.Sprintf("profile_fact:%s:%s", chatID, key)
 		item, err := txn.Get([]byte(factKey))
 		if err != nil {
@@ -911,15 +1127,15 @@ func (s *BadgerStore) GetProfileFact(ctx context.Context, chatID string, key str
 }
 
 // DeleteProfileFact removes a fact from the user's profile
-func (s *BadgerStore) DeleteProfileFact(ctx context.Context, chatID string, key string) error {
+func (s *ChatSession) DeleteProfileFact(ctx context.Context, chatID string, key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.currentDB == nil {
-		return fmt.Errorf("no chat is currently open")
+	if s.db == nil {
+		return ErrChatClosed
 	}
 
-	return s.currentDB.Update(func(txn *badger.Txn) error {
+	return s.db.Update(func(txn *badger.Txn) error {
 		// Get current profile
 		profileKey := fmt.Sprintf("profile:%s", chatID)
 		item, err := txn.Get([]byte(profileKey))
@@ -971,7 +1187,7 @@ func (s *BadgerStore) DeleteProfileFact(ctx context.Context, chatID string, key
 }
 
 // GetFactHistory retrieves the history of changes for a specific fact
-func (s *BadgerStore) GetFactHistory(ctx context.Context, chatID string, key string) ([]ProfileFact, error) {
+func (s *ChatSession) GetFactHistory(ctx context.Context, chatID string, key string) ([]ProfileFact, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -0,0 +1,320 @@
+package vector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"rag-terminal/internal/logging"
+)
+
+// EmbeddingMismatch is returned when a chat's vectors were produced by another embedding model
+// or dimension than the one in use. Searching such a chat compares incompatible vectors.
+type EmbeddingMismatch struct {
+	ChatModel      string // Model that produced the stored vectors
+	ChatDimensions int
+	Model          string // Model in use
+	Dimensions     int
+}
+
+func (e *EmbeddingMismatch) Error() string {
+	return fmt.Sprintf("chat was embedded with %s (%d dimensions), but %s (%d dimensions) is in use",
+		e.ChatModel, e.ChatDimensions, e.Model, e.Dimensions)
+}
+
+// CheckEmbedding returns an *EmbeddingMismatch if the chat's vectors don't come from model
+// with the given dimensions. Chats that haven't recorded a model yet match any model.
+func (c *Chat) CheckEmbedding(model string, dimensions int) error {
+	if c.EmbedModel == "" {
+		return nil
+	}
+	if c.EmbedModel != model || c.EmbeddingDimensions != dimensions {
+		return &EmbeddingMismatch{
+			ChatModel:      c.EmbedModel,
+			ChatDimensions: c.EmbeddingDimensions,
+			Model:          model,
+			Dimensions:     dimensions,
+		}
+	}
+	return nil
+}
+
+// RecordModels notes llmModel as the last used LLM. The embedding model is only recorded if the
+// chat doesn't name one yet; changing it takes a re-embedding. Returns whether anything changed.
+func (c *Chat) RecordModels(llmModel, embedModel string, dimensions int) bool {
+	changed := false
+	if llmModel != "" && c.LLMModel != llmModel {
+		c.LLMModel = llmModel
+		changed = true
+	}
+	if c.EmbedModel == "" && embedModel != "" {
+		c.EmbedModel = embedModel
+		c.EmbeddingDimensions = dimensions
+		changed = true
+	}
+	return changed
+}
+
+// Keys of an unfinished re-embedding: the checkpoint, and one marker per re-embedded record
+// holding the checksum of the vector it was given
+const (
+	reembedCheckpointKey = "reembed:checkpoint"
+	reembedDonePrefix    = "reembed:done:"
+)
+
+// EmbeddedText is the text behind a stored vector
+type EmbeddedText struct {
+	ID         string
+	Content    string
+	Reembedded bool // The vector was written by the unfinished re-embedding and hasn't been replaced since
+}
+
+// ReembedCheckpoint records the target of an unfinished re-embedding, so an interrupted run can
+// resume. Which records are done is tracked per record, since records stored in the meantime
+// can land anywhere in key order.
+type ReembedCheckpoint struct {
+	EmbedModel string    `json:"embed_model"`
+	Dimensions int       `json:"dimensions"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// embeddingChecksum identifies a vector, to tell whether a record still holds the one
+// a re-embedding gave it
+func embeddingChecksum(embedding []float32) uint32 {
+	buf := make([]byte, 0, 4*len(embedding))
+	for _, v := range embedding {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+	}
+	return crc32.ChecksumIEEE(buf)
+}
+
+// EmbeddedTexts returns the document chunks and context messages that carry a vector, chunks
+// first. These are exactly the entries the HNSW index is built from.
+func (s *ChatSession) EmbeddedTexts(ctx context.Context) ([]EmbeddedText, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reembedded := make(map[string]uint32)
+	err := s.iterateWithPrefix([]byte(reembedDonePrefix), func(item *badger.Item) error {
+		return item.Value(func(val []byte) error {
+			if len(val) == 4 {
+				reembedded[string(item.Key()[len(reembedDonePrefix):])] = binary.LittleEndian.Uint32(val)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read re-embedding progress: %w", err)
+	}
+	isReembedded := func(id string, embedding []float32) bool {
+		sum, ok := reembedded[id]
+		return ok && sum == embeddingChecksum(embedding)
+	}
+
+	var texts []EmbeddedText
+
+	err = s.iterateWithPrefix([]byte("chunk:"), func(item *badger.Item) error {
+		return item.Value(func(val []byte) error {
+			var chunk DocumentChunk
+			if err := json.Unmarshal(val, &chunk); err != nil {
+				return err
+			}
+			if len(chunk.Embedding) > 0 {
+				texts = append(texts, EmbeddedText{ID: chunk.ID, Content: chunk.Content, Reembedded: isReembedded(chunk.ID, chunk.Embedding)})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect chunks: %w", err)
+	}
+
+	err = s.iterateWithPrefix([]byte("msg:"), func(item *badger.Item) error {
+		return item.Value(func(val []byte) error {
+			var msg Message
+			if err := json.Unmarshal(val, &msg); err != nil {
+				return err
+			}
+			if msg.Role == "context" && len(msg.Embedding) > 0 {
+				texts = append(texts, EmbeddedText{ID: msg.ID, Content: msg.Content, Reembedded: isReembedded(msg.ID, msg.Embedding)})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect context messages: %w", err)
+	}
+
+	return texts, nil
+}
+
+// GetReembedCheckpoint returns the checkpoint of an unfinished re-embedding, or nil if there is none
+func (s *ChatSession) GetReembedCheckpoint(ctx context.Context) (*ReembedCheckpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.db == nil {
+		return nil, ErrChatClosed
+	}
+
+	var checkpoint *ReembedCheckpoint
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(reembedCheckpointKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		checkpoint = &ReembedCheckpoint{}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, checkpoint)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read re-embedding checkpoint: %w", err)
+	}
+
+	return checkpoint, nil
+}
+
+// ClearReembedCheckpoint removes the checkpoint and the per-record progress, once a re-embedding
+// is complete or before one for another model starts
+func (s *ChatSession) ClearReembedCheckpoint(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return ErrChatClosed
+	}
+
+	var markers [][]byte
+	err := s.iterateWithPrefix([]byte(reembedDonePrefix), func(item *badger.Item) error {
+		markers = append(markers, item.KeyCopy(nil))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read re-embedding progress: %w", err)
+	}
+
+	// One marker per record can exceed a single transaction, so use a write batch
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range markers {
+		if err := wb.Delete(key); err != nil {
+			return fmt.Errorf("failed to clear re-embedding progress: %w", err)
+		}
+	}
+	if err := wb.Delete([]byte(reembedCheckpointKey)); err != nil {
+		return fmt.Errorf("failed to clear re-embedding checkpoint: %w", err)
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("failed to clear re-embedding checkpoint: %w", err)
+	}
+	return nil
+}
+
+// UpdateEmbeddings replaces the vectors of stored chunks and messages, keyed by their IDs, and
+// marks them done under checkpoint in the same transaction, so progress and vectors can't
+// disagree after an interruption. Batches must stay within Badger's transaction size limit. The HNSW index is left
+// alone so searches keep working on the old vectors until RebuildIndex swaps them in.
+func (s *ChatSession) UpdateEmbeddings(ctx context.Context, embeddings map[string][]float32, checkpoint ReembedCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return ErrChatClosed
+	}
+
+	checkpoint.UpdatedAt = time.Now()
+	checkpointData, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal re-embedding checkpoint: %w", err)
+	}
+
+	err = s.db.Update(func(txn *badger.Txn) error {
+		for id, embedding := range embeddings {
+			key, data, err := updatedEmbedding(txn, id, embedding)
+			if err != nil {
+				return err
+			}
+			if key == nil {
+				logging.Debug("Skipping embedding of %s, it was deleted meanwhile", id)
+				continue
+			}
+			if err := txn.Set(key, data); err != nil {
+				return fmt.Errorf("failed to store embedding of %s: %w", id, err)
+			}
+			sum := binary.LittleEndian.AppendUint32(nil, embeddingChecksum(embedding))
+			if err := txn.Set([]byte(reembedDonePrefix+id), sum); err != nil {
+				return fmt.Errorf("failed to store re-embedding progress of %s: %w", id, err)
+			}
+		}
+
+		if err := txn.Set([]byte(reembedCheckpointKey), checkpointData); err != nil {
+			return fmt.Errorf("failed to store re-embedding checkpoint: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store embeddings: %w", err)
+	}
+	return nil
+}
+
+// updatedEmbedding returns the key and the re-encoded record of a chunk or message with a new vector.
+// The key is nil if neither exists.
+func updatedEmbedding(txn *badger.Txn, id string, embedding []float32) ([]byte, []byte, error) {
+	chunkKey := []byte(fmt.Sprintf("chunk:%s", id))
+	if item, err := txn.Get(chunkKey); err == nil {
+		var chunk DocumentChunk
+		if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &chunk) }); err != nil {
+			return nil, nil, fmt.Errorf("failed to read chunk %s: %w", id, err)
+		}
+		chunk.Embedding = embedding
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal chunk: %w", err)
+		}
+		return chunkKey, data, nil
+	} else if err != badger.ErrKeyNotFound {
+		return nil, nil, err
+	}
+
+	msgKey := []byte(fmt.Sprintf("msg:%s", id))
+	item, err := txn.Get(msgKey)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	var msg Message
+	if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &msg) }); err != nil {
+		return nil, nil, fmt.Errorf("failed to read message %s: %w", id, err)
+	}
+	msg.Embedding = embedding
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return msgKey, data, nil
+}
+
+// RebuildIndex rebuilds the HNSW graph from the stored vectors and replaces the persisted copy
+func (s *ChatSession) RebuildIndex(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return ErrChatClosed
+	}
+
+	logging.Info("Rebuilding HNSW index for chat %s on request", s.chatID)
+	return s.rebuildAndPersistIndex(ctx)
+}
@@ -4,6 +4,7 @@ import (
 	"container/heap"
 	"math"
 	"math/rand"
+	"slices"
 	"sync"
 )
 
@@ -14,6 +15,7 @@ type HNSWConfig struct {
 	EfSearch       int     // Size of dynamic candidate list during search
 	Ml             float64 // Normalization factor for level generation
 	MaxLevel       int     // Maximum level in the hierarchy
+	CompactRatio   float64 // Fraction of tombstoned nodes that triggers compaction
 }
 
 // DefaultHNSWConfig returns sensible default configuration
@@ -24,6 +26,7 @@ func DefaultHNSWConfig() *HNSWConfig {
 		EfSearch:       100,  // Higher = better recall, slower search
 		Ml:             1.0 / math.Log(2.0),
 		MaxLevel:       16,
+		CompactRatio:   0.2, // Compact once a fifth of the graph is tombstoned
 	}
 }
 
@@ -35,6 +38,7 @@ type HNSWNode struct {
 	Neighbors  [][]string // Neighbors at each level (level -> neighbor IDs)
 	IsMessage  bool       // true for Message, false for DocumentChunk
 	IsContext  bool       // true if role == "context"
+	Deleted    bool       // Tombstoned by Remove, still routes searches until compaction
 }
 
 // HNSWIndex is an in-memory HNSW index for fast approximate nearest neighbor search
@@ -43,6 +47,8 @@ type HNSWIndex struct {
 	nodes      map[string]*HNSWNode // ID -> Node
 	entryPoint string               // ID of the top-level entry point
 	maxLevel   int                  // Current maximum level in the index
+	dirty      map[string]struct{}  // Node IDs changed since the last persist
+	tombstones int                  // Number of nodes marked Deleted
 	mu         sync.RWMutex
 	rng        *rand.Rand
 }
@@ -55,18 +61,32 @@ func NewHNSWIndex(config *HNSWConfig) *HNSWIndex {
 	return &HNSWIndex{
 		config: config,
 		nodes:  make(map[string]*HNSWNode),
+		dirty:  make(map[string]struct{}),
 		rng:    rand.New(rand.NewSource(42)), // Fixed seed for reproducibility
 	}
 }
 
-// Add inserts a new vector into the index
-func (idx *HNSWIndex) Add(id string, vector []float32, isMessage bool, isContext bool) {
+// Add inserts a new vector into the index. Returns false if a live node with the ID exists.
+func (idx *HNSWIndex) Add(id string, vector []float32, isMessage bool, isContext bool) bool {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
-	// Check if already exists
-	if _, exists := idx.nodes[id]; exists {
-		return
+	return idx.addLocked(id, vector, isMessage, isContext)
+}
+
+// addLocked inserts a vector; the caller must hold idx.mu
+func (idx *HNSWIndex) addLocked(id string, vector []float32, isMessage bool, isContext bool) bool {
+	// Check if already exists; a tombstoned node is revived in place with the new vector
+	if existing, exists := idx.nodes[id]; exists {
+		if !existing.Deleted {
+			return false
+		}
+		existing.Deleted = false
+		existing.IsMessage = isMessage
+		existing.IsContext = isContext
+		idx.tombstones--
+		idx.relinkLocked(existing, vector)
+		return true
 	}
 
 	// Determine level for new node
@@ -88,22 +108,24 @@ func (idx *HNSWIndex) Add(id string, vector []float32, isMessage bool, isContext
 	}
 
 	idx.nodes[id] = node
+	idx.dirty[id] = struct{}{}
 
 	// If this is the first node
 	if idx.entryPoint == "" {
 		idx.entryPoint = id
 		idx.maxLevel = level
-		return
+		return true
 	}
 
 	// Insert into the graph
-	idx.insert(node)
+	idx.insert(node, idx.entryPoint)
 
 	// Update entry point if necessary
 	if level > idx.maxLevel {
 		idx.maxLevel = level
 		idx.entryPoint = id
 	}
+	return true
 }
 
 // Search performs k-nearest neighbor search
@@ -153,10 +175,9 @@ func (idx *HNSWIndex) Search(query []float32, k int, filterContext bool) []strin
 	return result
 }
 
-// insert adds a node to the HNSW graph structure
-func (idx *HNSWIndex) insert(node *HNSWNode) {
+// insert links a node into the HNSW graph structure, searching from ep
+func (idx *HNSWIndex) insert(node *HNSWNode, ep string) {
 	// Find nearest neighbors at each level
-	ep := idx.entryPoint
 	currDist := idx.distance(node.Vector, idx.nodes[ep].Vector)
 
 	// Navigate to insertion point from top level
@@ -168,6 +189,9 @@ func (idx *HNSWIndex) insert(node *HNSWNode) {
 
 			if level < len(epNode.Neighbors) {
 				for _, neighborID := range epNode.Neighbors[level] {
+					if neighborID == node.ID {
+						continue
+					}
 					neighbor := idx.nodes[neighborID]
 					d := idx.distance(node.Vector, neighbor.Vector)
 					if d < currDist {
@@ -184,6 +208,9 @@ func (idx *HNSWIndex) insert(node *HNSWNode) {
 	for level := node.Level; level >= 0; level-- {
 		candidates := idx.searchLayer(node.Vector, ep, idx.config.EfConstruction, level, false)
 
+		// A relinked node is reachable through one-way links and must not become its own neighbor
+		candidates = slices.DeleteFunc(candidates, func(c distanceNode) bool { return c.id == node.ID })
+
 		// Select M nearest neighbors
 		m := idx.config.M
 		if level == 0 {
@@ -201,6 +228,7 @@ func (idx *HNSWIndex) insert(node *HNSWNode) {
 			neighborNode := idx.nodes[neighbor.id]
 			if level < len(neighborNode.Neighbors) {
 				neighborNode.Neighbors[level] = append(neighborNode.Neighbors[level], node.ID)
+				idx.dirty[neighborNode.ID] = struct{}{}
 
 				// Prune neighbor's connections if needed
 				if len(neighborNode.Neighbors[level]) > m {
@@ -222,10 +250,12 @@ func (idx *HNSWIndex) searchLayer(query []float32, ep string, ef int, level int,
 	candidates := &minHeap{}
 	results := &maxHeap{}
 
-	// Initialize with entry point
+	// Initialize with entry point (a tombstoned entry point is only used for routing)
 	dist := idx.distance(query, idx.nodes[ep].Vector)
 	heap.Push(candidates, distanceNode{id: ep, distance: dist})
-	heap.Push(results, distanceNode{id: ep, distance: dist})
+	if !idx.nodes[ep].Deleted {
+		heap.Push(results, distanceNode{id: ep, distance: dist})
+	}
 	visited[ep] = true
 
 	// Explore graph
@@ -259,6 +289,11 @@ func (idx *HNSWIndex) searchLayer(query []float32, ep string, ef int, level int,
 
 			if d < results.Top().distance || results.Len() < ef {
 				heap.Push(candidates, distanceNode{id: neighborID, distance: d})
+
+				// Tombstoned nodes keep the graph connected but never appear in results
+				if neighbor.Deleted {
+					continue
+				}
 				heap.Push(results, distanceNode{id: neighborID, distance: d})
 
 				if results.Len() > ef {
@@ -339,15 +374,224 @@ func (idx *HNSWIndex) Clear() {
 	defer idx.mu.Unlock()
 
 	idx.nodes = make(map[string]*HNSWNode)
+	idx.dirty = make(map[string]struct{})
 	idx.entryPoint = ""
 	idx.maxLevel = 0
+	idx.tombstones = 0
 }
 
-// Size returns the number of nodes in the index
+// Size returns the number of live (non-tombstoned) nodes in the index
 func (idx *HNSWIndex) Size() int {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
-	return len(idx.nodes)
+	return len(idx.nodes) - idx.tombstones
+}
+
+// Remove tombstones a node so it no longer appears in search results.
+// Its links are kept for routing until compaction, which runs automatically
+// once the tombstoned fraction reaches CompactRatio.
+// Returns false if the node doesn't exist or was already removed.
+func (idx *HNSWIndex) Remove(id string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	node, exists := idx.nodes[id]
+	if !exists || node.Deleted {
+		return false
+	}
+
+	node.Deleted = true
+	idx.tombstones++
+	idx.dirty[id] = struct{}{}
+
+	if idx.config.CompactRatio > 0 && float64(idx.tombstones) >= idx.config.CompactRatio*float64(len(idx.nodes)) {
+		idx.compactLocked()
+	}
+
+	return true
+}
+
+// Update replaces the vector of an existing node and links it at its new position,
+// keeping its level and message/context flags.
+// Returns false if the node doesn't exist or was removed.
+func (idx *HNSWIndex) Update(id string, vector []float32) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	node, exists := idx.nodes[id]
+	if !exists || node.Deleted {
+		return false
+	}
+
+	idx.relinkLocked(node, vector)
+	return true
+}
+
+// relinkLocked moves a node to a new vector. Only the node's own neighbors are visited:
+// those linking back to it are repaired by bridging through its old neighbors, then the
+// node is inserted afresh. It stays in the graph throughout, so one-way links other
+// nodes hold to it remain valid. The caller must hold idx.mu.
+func (idx *HNSWIndex) relinkLocked(node *HNSWNode, vector []float32) {
+	moved := map[string]bool{node.ID: true}
+	start := "" // Highest old neighbor, to search from when the node is the entry point
+	for level := len(node.Neighbors) - 1; level >= 0; level-- {
+		for _, nid := range node.Neighbors[level] {
+			if start == "" {
+				start = nid
+			}
+			neighbor := idx.nodes[nid]
+			if level < len(neighbor.Neighbors) && slices.Contains(neighbor.Neighbors[level], node.ID) {
+				idx.repairNeighbors(neighbor, level, moved)
+				idx.dirty[nid] = struct{}{}
+			}
+		}
+	}
+
+	node.Vector = vector
+	for level := range node.Neighbors {
+		node.Neighbors[level] = make([]string, 0, idx.config.M)
+	}
+	idx.dirty[node.ID] = struct{}{}
+
+	if idx.entryPoint != node.ID {
+		start = idx.entryPoint
+	}
+	if start != "" {
+		idx.insert(node, start)
+	}
+}
+
+// Compact drops all tombstoned nodes from the graph, re-linking every neighbor list
+// that pointed into a deleted region. Returns the number of nodes dropped.
+func (idx *HNSWIndex) Compact() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	return idx.compactLocked()
+}
+
+// compactLocked implements Compact; the caller must hold idx.mu
+func (idx *HNSWIndex) compactLocked() int {
+	if idx.tombstones == 0 {
+		return 0
+	}
+
+	dead := make(map[string]bool, idx.tombstones)
+	for id, node := range idx.nodes {
+		if node.Deleted {
+			dead[id] = true
+		}
+	}
+
+	idx.unlinkLocked(dead)
+	return len(dead)
+}
+
+// unlinkLocked removes the given nodes from the graph. Every surviving neighbor list
+// that referenced one of them is repaired by bridging across the deleted region to
+// the nearest live nodes behind it, so connectivity holds up after many deletions.
+func (idx *HNSWIndex) unlinkLocked(dead map[string]bool) {
+	for _, node := range idx.nodes {
+		if dead[node.ID] {
+			continue
+		}
+		for level, neighbors := range node.Neighbors {
+			for _, nid := range neighbors {
+				if dead[nid] {
+					idx.repairNeighbors(node, level, dead)
+					idx.dirty[node.ID] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+
+	for id := range dead {
+		if node, exists := idx.nodes[id]; exists {
+			if node.Deleted {
+				idx.tombstones--
+			}
+			delete(idx.nodes, id)
+			idx.dirty[id] = struct{}{}
+		}
+	}
+
+	if dead[idx.entryPoint] {
+		idx.resetEntryPoint()
+	}
+}
+
+// repairNeighbors rebuilds a node's neighbor list at one level after some of its
+// neighbors were deleted. Candidates are the surviving neighbors plus the live nodes
+// reachable through the deleted ones; the nearest m are kept.
+func (idx *HNSWIndex) repairNeighbors(node *HNSWNode, level int, dead map[string]bool) {
+	m := idx.config.M
+	if level == 0 {
+		m = idx.config.M * 2
+	}
+
+	seen := map[string]bool{node.ID: true}
+	var candidates []distanceNode
+	var bridge []string
+
+	for _, nid := range node.Neighbors[level] {
+		seen[nid] = true
+		if dead[nid] {
+			bridge = append(bridge, nid)
+			continue
+		}
+		candidates = append(candidates, distanceNode{id: nid, distance: idx.distance(node.Vector, idx.nodes[nid].Vector)})
+	}
+
+	// Walk through the deleted region until live nodes are reached
+	for len(bridge) > 0 {
+		deadNode := idx.nodes[bridge[len(bridge)-1]]
+		bridge = bridge[:len(bridge)-1]
+
+		if level >= len(deadNode.Neighbors) {
+			continue
+		}
+		for _, nid := range deadNode.Neighbors[level] {
+			if seen[nid] {
+				continue
+			}
+			seen[nid] = true
+			if dead[nid] {
+				bridge = append(bridge, nid)
+				continue
+			}
+			candidates = append(candidates, distanceNode{id: nid, distance: idx.distance(node.Vector, idx.nodes[nid].Vector)})
+		}
+	}
+
+	sortDistanceNodes(candidates)
+	if len(candidates) > m {
+		candidates = candidates[:m]
+	}
+
+	node.Neighbors[level] = make([]string, len(candidates))
+	for i, c := range candidates {
+		node.Neighbors[level][i] = c.id
+	}
+}
+
+// resetEntryPoint picks the highest-level remaining node as the new entry point,
+// preferring live nodes over tombstoned ones
+func (idx *HNSWIndex) resetEntryPoint() {
+	idx.entryPoint = ""
+	idx.maxLevel = 0
+
+	bestDeleted := true
+	for id, node := range idx.nodes {
+		better := idx.entryPoint == "" ||
+			(bestDeleted && !node.Deleted) ||
+			(bestDeleted == node.Deleted && node.Level > idx.maxLevel)
+		if better {
+			idx.entryPoint = id
+			idx.maxLevel = node.Level
+			bestDeleted = node.Deleted
+		}
+	}
 }
 
 // distanceNode represents a node with its distance from query
@@ -0,0 +1,302 @@
+package vector
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+const (
+	testDimensions = 32
+	testNodes      = 1000
+	testQueries    = 50
+	testK          = 10
+	minRecall      = 0.9
+)
+
+func randomVector(rng *rand.Rand) []float32 {
+	vec := make([]float32, testDimensions)
+	for i := range vec {
+		vec[i] = float32(rng.NormFloat64())
+	}
+	return vec
+}
+
+// buildTestIndex inserts testNodes random vectors and returns the index with the vectors by ID.
+// Automatic compaction is off so tests control when tombstones are dropped.
+func buildTestIndex(t *testing.T, rng *rand.Rand) (*HNSWIndex, map[string][]float32) {
+	t.Helper()
+
+	config := DefaultHNSWConfig()
+	config.CompactRatio = 0
+	idx := NewHNSWIndex(config)
+
+	vectors := make(map[string][]float32, testNodes)
+	for i := 0; i < testNodes; i++ {
+		id := fmt.Sprintf("node-%d", i)
+		vectors[id] = randomVector(rng)
+		idx.Add(id, vectors[id], false, false)
+	}
+	return idx, vectors
+}
+
+// bruteForce returns the IDs of the k vectors nearest to query
+func bruteForce(query []float32, vectors map[string][]float32, k int) []string {
+	ids := make([]string, 0, len(vectors))
+	for id := range vectors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return CosineSimilarity(query, vectors[ids[i]]) > CosineSimilarity(query, vectors[ids[j]])
+	})
+	if len(ids) > k {
+		ids = ids[:k]
+	}
+	return ids
+}
+
+// recall measures the fraction of the exact k nearest live vectors the index finds
+func recall(idx *HNSWIndex, queries [][]float32, live map[string][]float32) float64 {
+	found, total := 0, 0
+	for _, query := range queries {
+		results := make(map[string]bool)
+		for _, id := range idx.Search(query, testK, false) {
+			results[id] = true
+		}
+		for _, id := range bruteForce(query, live, testK) {
+			if results[id] {
+				found++
+			}
+			total++
+		}
+	}
+	return float64(found) / float64(total)
+}
+
+// removeFraction tombstones about the given fraction of nodes and returns the surviving vectors
+func removeFraction(t *testing.T, idx *HNSWIndex, vectors map[string][]float32, rng *rand.Rand, fraction float64) map[string][]float32 {
+	t.Helper()
+
+	ids := make([]string, 0, len(vectors))
+	for id := range vectors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	live := make(map[string][]float32, len(vectors))
+	for id, vec := range vectors {
+		live[id] = vec
+	}
+	for _, id := range ids[:int(fraction*float64(len(ids)))] {
+		if !idx.Remove(id) {
+			t.Fatalf("Remove(%s) = false for a live node", id)
+		}
+		delete(live, id)
+	}
+	return live
+}
+
+// assertGraphIntact checks that no neighbor list points at a dropped node and that every node
+// is reachable from the entry point on the base layer
+func assertGraphIntact(t *testing.T, idx *HNSWIndex) {
+	t.Helper()
+
+	for id, node := range idx.nodes {
+		for level, neighbors := range node.Neighbors {
+			for _, nid := range neighbors {
+				if _, ok := idx.nodes[nid]; !ok {
+					t.Fatalf("node %s links to dropped node %s at level %d", id, nid, level)
+				}
+			}
+		}
+	}
+
+	entry, ok := idx.nodes[idx.entryPoint]
+	if !ok {
+		t.Fatalf("entry point %q is not in the graph", idx.entryPoint)
+	}
+	if entry.Level != idx.maxLevel {
+		t.Errorf("entry point level %d, max level %d", entry.Level, idx.maxLevel)
+	}
+
+	reached := map[string]bool{idx.entryPoint: true}
+	queue := []string{idx.entryPoint}
+	for len(queue) > 0 {
+		node := idx.nodes[queue[0]]
+		queue = queue[1:]
+		for _, nid := range node.Neighbors[0] {
+			if !reached[nid] {
+				reached[nid] = true
+				queue = append(queue, nid)
+			}
+		}
+	}
+	if len(reached) != len(idx.nodes) {
+		t.Errorf("%d of %d nodes reachable from the entry point", len(reached), len(idx.nodes))
+	}
+}
+
+func TestHNSWRecallAfterRemovals(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	idx, vectors := buildTestIndex(t, rng)
+
+	queries := make([][]float32, testQueries)
+	for i := range queries {
+		queries[i] = randomVector(rng)
+	}
+
+	if r := recall(idx, queries, vectors); r < minRecall {
+		t.Fatalf("recall@%d before removals = %.3f, want >= %.2f", testK, r, minRecall)
+	}
+
+	live := removeFraction(t, idx, vectors, rng, 0.3)
+	if got := idx.Size(); got != len(live) {
+		t.Errorf("Size() = %d after removals, want %d", got, len(live))
+	}
+
+	// Tombstoned nodes still route searches but must not be returned
+	for _, query := range queries {
+		for _, id := range idx.Search(query, testK, false) {
+			if _, ok := live[id]; !ok {
+				t.Fatalf("search returned removed node %s", id)
+			}
+		}
+	}
+	if r := recall(idx, queries, live); r < minRecall {
+		t.Errorf("recall@%d with tombstones = %.3f, want >= %.2f", testK, r, minRecall)
+	}
+
+	if dropped := idx.Compact(); dropped != len(vectors)-len(live) {
+		t.Errorf("Compact() dropped %d nodes, want %d", dropped, len(vectors)-len(live))
+	}
+	if len(idx.nodes) != len(live) || idx.tombstones != 0 {
+		t.Errorf("after Compact: %d nodes, %d tombstones; want %d nodes, 0 tombstones", len(idx.nodes), idx.tombstones, len(live))
+	}
+	assertGraphIntact(t, idx)
+
+	if r := recall(idx, queries, live); r < minRecall {
+		t.Errorf("recall@%d after Compact = %.3f, want >= %.2f", testK, r, minRecall)
+	}
+}
+
+func TestHNSWRemoveEntryPoint(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	idx, vectors := buildTestIndex(t, rng)
+
+	// Remove the entry point and whoever takes its place a few times over
+	for i := 0; i < 5; i++ {
+		entry := idx.entryPoint
+		if !idx.Remove(entry) {
+			t.Fatalf("Remove(%s) of the entry point failed", entry)
+		}
+		delete(vectors, entry)
+		idx.Compact()
+
+		if _, ok := idx.nodes[entry]; ok {
+			t.Fatalf("removed entry point %s is still in the graph", entry)
+		}
+		assertGraphIntact(t, idx)
+	}
+
+	query := randomVector(rng)
+	if r := recall(idx, [][]float32{query}, vectors); r < minRecall {
+		t.Errorf("recall@%d after replacing the entry point = %.3f, want >= %.2f", testK, r, minRecall)
+	}
+}
+
+func TestHNSWAutomaticCompaction(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	idx, vectors := buildTestIndex(t, rng)
+	idx.config.CompactRatio = 0.2
+
+	live := removeFraction(t, idx, vectors, rng, 0.2)
+	if idx.tombstones != 0 || len(idx.nodes) != len(live) {
+		t.Errorf("%d tombstones and %d nodes left after removing a fifth, want compaction to %d nodes",
+			idx.tombstones, len(idx.nodes), len(live))
+	}
+	assertGraphIntact(t, idx)
+}
+
+func TestHNSWRemoveTwice(t *testing.T) {
+	idx := NewHNSWIndex(nil)
+	idx.Add("a", []float32{1, 0}, false, false)
+	idx.Add("b", []float32{0, 1}, false, false)
+
+	if idx.Remove("missing") {
+		t.Error("Remove of an unknown node returned true")
+	}
+	if !idx.Remove("a") {
+		t.Fatal("Remove(a) returned false")
+	}
+	if idx.Remove("a") {
+		t.Error("second Remove(a) returned true")
+	}
+}
+
+func TestHNSWUpdate(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	idx, vectors := buildTestIndex(t, rng)
+
+	target := randomVector(rng)
+	if !idx.Update("node-7", target) {
+		t.Fatal("Update of an existing node returned false")
+	}
+	vectors["node-7"] = target
+	if idx.Update("missing", target) {
+		t.Error("Update of an unknown node returned true")
+	}
+
+	// A removed node stays removed
+	idx.Remove("node-8")
+	delete(vectors, "node-8")
+	if idx.Update("node-8", randomVector(rng)) {
+		t.Error("Update of a removed node returned true")
+	}
+	if !idx.nodes["node-8"].Deleted {
+		t.Error("Update revived a removed node")
+	}
+
+	// Moving the entry point searches from its old neighbors
+	entry := idx.entryPoint
+	vectors[entry] = randomVector(rng)
+	if !idx.Update(entry, vectors[entry]) {
+		t.Fatalf("Update of the entry point %s returned false", entry)
+	}
+	for id, node := range idx.nodes {
+		for level, neighbors := range node.Neighbors {
+			if slices.Contains(neighbors, id) {
+				t.Fatalf("node %s links to itself at level %d", id, level)
+			}
+		}
+	}
+
+	results := idx.Search(target, 1, false)
+	if len(results) != 1 || results[0] != "node-7" {
+		t.Errorf("Search for the updated vector = %v, want [node-7]", results)
+	}
+	if got := idx.Size(); got != len(vectors) {
+		t.Errorf("Size() = %d after Update, want %d", got, len(vectors))
+	}
+	assertGraphIntact(t, idx)
+
+	// Many updates only repair local neighborhoods; search quality must hold up
+	for i := 100; i < 300; i++ {
+		id := fmt.Sprintf("node-%d", i)
+		vectors[id] = randomVector(rng)
+		if !idx.Update(id, vectors[id]) {
+			t.Fatalf("Update(%s) returned false", id)
+		}
+	}
+	assertGraphIntact(t, idx)
+
+	queries := make([][]float32, testQueries)
+	for i := range queries {
+		queries[i] = randomVector(rng)
+	}
+	if r := recall(idx, queries, vectors); r < minRecall {
+		t.Errorf("recall@%d after updates = %.3f, want >= %.2f", testK, r, minRecall)
+	}
+}
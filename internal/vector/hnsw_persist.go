@@ -0,0 +1,408 @@
+package vector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"rag-terminal/internal/logging"
+)
+
+// The HNSW graph is persisted inside the chat database next to the vectors it indexes:
+//
+//	hnsw:meta       -> JSON header (format version, entry point, max level, node count)
+//	hnsw:node:<id>  -> binary node record (flags, level, vector, neighbor lists) + CRC32
+//
+// Node records are binary rather than JSON because decoding thousands of float arrays
+// from JSON is exactly the cost the persisted index is meant to avoid.
+const (
+	hnswFormatVersion = 1
+	hnswMetaKey       = "hnsw:meta"
+	hnswNodePrefix    = "hnsw:node:"
+	hnswKeyPrefix     = "hnsw:"
+)
+
+// errIndexStale is returned when the persisted graph can't be trusted and must be rebuilt
+var errIndexStale = errors.New("persisted HNSW index is stale or corrupt")
+
+// hnswMeta is the persisted header of an HNSW graph
+type hnswMeta struct {
+	Version    int    `json:"version"`
+	EntryPoint string `json:"entry_point"`
+	MaxLevel   int    `json:"max_level"`
+	NodeCount  int    `json:"node_count"`
+}
+
+const (
+	nodeFlagMessage = 1 << iota
+	nodeFlagContext
+	nodeFlagDeleted
+)
+
+// encodeHNSWNode serializes a node into its binary record
+func encodeHNSWNode(node *HNSWNode) []byte {
+	buf := make([]byte, 0, 16+len(node.Vector)*4)
+
+	var flags byte
+	if node.IsMessage {
+		flags |= nodeFlagMessage
+	}
+	if node.IsContext {
+		flags |= nodeFlagContext
+	}
+	if node.Deleted {
+		flags |= nodeFlagDeleted
+	}
+	buf = append(buf, flags)
+	buf = binary.AppendUvarint(buf, uint64(node.Level))
+
+	buf = binary.AppendUvarint(buf, uint64(len(node.Vector)))
+	for _, v := range node.Vector {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+	}
+
+	for level := 0; level <= node.Level; level++ {
+		var neighbors []string
+		if level < len(node.Neighbors) {
+			neighbors = node.Neighbors[level]
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(neighbors)))
+		for _, nid := range neighbors {
+			buf = binary.AppendUvarint(buf, uint64(len(nid)))
+			buf = append(buf, nid...)
+		}
+	}
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+}
+
+// decodeHNSWNode parses a binary node record, verifying its checksum
+func decodeHNSWNode(id string, data []byte) (*HNSWNode, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("node %s: record too short", id)
+	}
+
+	payload, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(payload) != sum {
+		return nil, fmt.Errorf("node %s: checksum mismatch", id)
+	}
+
+	r := &recordReader{buf: payload}
+	flags := r.byte()
+	level := int(r.uvarint())
+
+	dim := int(r.uvarint())
+	if r.err == nil && dim*4 > len(r.buf) {
+		return nil, fmt.Errorf("node %s: vector length %d exceeds record", id, dim)
+	}
+	vec := make([]float32, dim)
+	for i := range vec {
+		vec[i] = math.Float32frombits(r.uint32())
+	}
+
+	neighbors := make([][]string, level+1)
+	for l := 0; l <= level && r.err == nil; l++ {
+		count := int(r.uvarint())
+		if count > len(r.buf) {
+			return nil, fmt.Errorf("node %s: neighbor count %d exceeds record", id, count)
+		}
+		neighbors[l] = make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			neighbors[l] = append(neighbors[l], r.string())
+		}
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("node %s: %w", id, r.err)
+	}
+
+	return &HNSWNode{
+		ID:        id,
+		Vector:    vec,
+		Level:     level,
+		Neighbors: neighbors,
+		IsMessage: flags&nodeFlagMessage != 0,
+		IsContext: flags&nodeFlagContext != 0,
+		Deleted:   flags&nodeFlagDeleted != 0,
+	}, nil
+}
+
+// recordReader is a small cursor over a node record that remembers the first error
+type recordReader struct {
+	buf []byte
+	err error
+}
+
+func (r *recordReader) fail() {
+	if r.err == nil {
+		r.err = errors.New("truncated record")
+	}
+	r.buf = nil
+}
+
+func (r *recordReader) byte() byte {
+	if len(r.buf) < 1 {
+		r.fail()
+		return 0
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *recordReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *recordReader) uint32() uint32 {
+	if len(r.buf) < 4 {
+		r.fail()
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return v
+}
+
+func (r *recordReader) string() string {
+	n := int(r.uvarint())
+	if n > len(r.buf) {
+		r.fail()
+		return ""
+	}
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
+
+// pendingChanges encodes every node modified since the last persist. Dirty IDs that no longer
+// exist in the graph are returned as removed. The dirty set is kept until markPersisted confirms
+// the write, so changes of a failed transaction are written by the next one.
+func (idx *HNSWIndex) pendingChanges() (updated map[string][]byte, removed []string, meta hnswMeta) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	updated = make(map[string][]byte, len(idx.dirty))
+	for id := range idx.dirty {
+		if node, ok := idx.nodes[id]; ok {
+			updated[id] = encodeHNSWNode(node)
+		} else {
+			removed = append(removed, id)
+		}
+	}
+
+	meta = hnswMeta{
+		Version:    hnswFormatVersion,
+		EntryPoint: idx.entryPoint,
+		MaxLevel:   idx.maxLevel,
+		NodeCount:  len(idx.nodes),
+	}
+	return updated, removed, meta
+}
+
+// markPersisted clears nodes from the dirty set once their records were committed
+func (idx *HNSWIndex) markPersisted(ids []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, id := range ids {
+		delete(idx.dirty, id)
+	}
+}
+
+// restore replaces the index contents with a previously persisted graph.
+// The graph is validated first so that a dangling reference can't panic a later search.
+func (idx *HNSWIndex) restore(nodes map[string]*HNSWNode, meta hnswMeta) error {
+	if len(nodes) != meta.NodeCount {
+		return fmt.Errorf("%w: expected %d nodes, found %d", errIndexStale, meta.NodeCount, len(nodes))
+	}
+	if len(nodes) > 0 {
+		if _, ok := nodes[meta.EntryPoint]; !ok {
+			return fmt.Errorf("%w: entry point %q missing", errIndexStale, meta.EntryPoint)
+		}
+	}
+	for _, node := range nodes {
+		for _, level := range node.Neighbors {
+			for _, nid := range level {
+				if _, ok := nodes[nid]; !ok {
+					return fmt.Errorf("%w: node %s links to missing node %s", errIndexStale, node.ID, nid)
+				}
+			}
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.nodes = nodes
+	idx.dirty = make(map[string]struct{})
+	idx.entryPoint = meta.EntryPoint
+	idx.maxLevel = meta.MaxLevel
+	idx.tombstones = 0
+	for _, node := range nodes {
+		if node.Deleted {
+			idx.tombstones++
+		}
+	}
+	if len(nodes) == 0 {
+		idx.entryPoint = ""
+		idx.maxLevel = 0
+	}
+	return nil
+}
+
+// loadIndex restores the HNSW graph of the session's chat in a single pass over its hnsw: keys
+func (s *ChatSession) loadIndex() error {
+	var meta *hnswMeta
+	nodes := make(map[string]*HNSWNode)
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(hnswMetaKey))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				return fmt.Errorf("%w: no persisted index", errIndexStale)
+			}
+			return err
+		}
+		if err := item.Value(func(val []byte) error {
+			meta = &hnswMeta{}
+			return json.Unmarshal(val, meta)
+		}); err != nil {
+			return fmt.Errorf("%w: unreadable header: %v", errIndexStale, err)
+		}
+		if meta.Version != hnswFormatVersion {
+			return fmt.Errorf("%w: format version %d, want %d", errIndexStale, meta.Version, hnswFormatVersion)
+		}
+
+		prefix := []byte(hnswNodePrefix)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			id := string(item.Key()[len(prefix):])
+			err := item.Value(func(val []byte) error {
+				node, err := decodeHNSWNode(id, val)
+				if err != nil {
+					return fmt.Errorf("%w: %v", errIndexStale, err)
+				}
+				nodes[id] = node
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.hnswIndex.restore(nodes, *meta)
+}
+
+// indexWriter is the subset of *badger.Txn and *badger.WriteBatch used to persist graph changes
+type indexWriter interface {
+	Set(key, val []byte) error
+	Delete(key []byte) error
+}
+
+// writeIndexChanges writes the nodes touched since the last persist into w and returns their IDs,
+// to be passed to markPersisted once w is committed.
+// Called in the same transaction as the record that caused the change so both land together.
+// The header is written last so an interrupted write batch fails the node count check on load.
+func (s *ChatSession) writeIndexChanges(w indexWriter) ([]string, error) {
+	updated, removed, meta := s.hnswIndex.pendingChanges()
+
+	written := make([]string, 0, len(updated)+len(removed))
+	for id, data := range updated {
+		if err := w.Set([]byte(hnswNodePrefix+id), data); err != nil {
+			return nil, err
+		}
+		written = append(written, id)
+	}
+	for _, id := range removed {
+		if err := w.Delete([]byte(hnswNodePrefix + id)); err != nil {
+			return nil, err
+		}
+		written = append(written, id)
+	}
+
+	metaData, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal index header: %w", err)
+	}
+	if err := w.Set([]byte(hnswMetaKey), metaData); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// updateWithNode runs update in a transaction that also persists the graph changes of adding
+// node id. An empty id means no node was added. If the transaction fails, the node is
+// tombstoned again so no search returns a record that wasn't stored; the graph changes
+// stay dirty and are written by the next transaction.
+func (s *ChatSession) updateWithNode(id string, update func(txn *badger.Txn) error) error {
+	if id == "" {
+		return s.db.Update(update)
+	}
+
+	var written []string
+	err := s.db.Update(func(txn *badger.Txn) error {
+		if err := update(txn); err != nil {
+			return err
+		}
+		var err error
+		written, err = s.writeIndexChanges(txn)
+		return err
+	})
+	if err != nil {
+		s.hnswIndex.Remove(id)
+		return err
+	}
+
+	s.hnswIndex.markPersisted(written)
+	return nil
+}
+
+// rebuildAndPersistIndex rebuilds the graph from the stored vectors and replaces the persisted copy.
+// A write batch is used because a full graph easily exceeds a single transaction's size limit.
+func (s *ChatSession) rebuildAndPersistIndex(ctx context.Context) error {
+	if err := s.db.DropPrefix([]byte(hnswKeyPrefix)); err != nil {
+		return fmt.Errorf("failed to drop persisted index: %w", err)
+	}
+
+	if err := s.buildIndex(ctx); err != nil {
+		return err
+	}
+
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	written, err := s.writeIndexChanges(wb)
+	if err != nil {
+		return fmt.Errorf("failed to persist index: %w", err)
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("failed to persist index: %w", err)
+	}
+	s.hnswIndex.markPersisted(written)
+
+	logging.Info("Rebuilt and persisted HNSW index for chat %s (%d nodes)", s.chatID, s.hnswIndex.Size())
+	return nil
+}
@@ -0,0 +1,269 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestHNSWNodeRoundTrip(t *testing.T) {
+	node := &HNSWNode{
+		ID:        "msg-1",
+		Vector:    []float32{0.5, -1.25, 3},
+		Level:     2,
+		Neighbors: [][]string{{"a", "b"}, {"c"}, {}},
+		IsMessage: true,
+		IsContext: true,
+		Deleted:   true,
+	}
+
+	decoded, err := decodeHNSWNode(node.ID, encodeHNSWNode(node))
+	if err != nil {
+		t.Fatalf("decodeHNSWNode failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, node) {
+		t.Errorf("decoded node = %+v, want %+v", decoded, node)
+	}
+}
+
+func TestDecodeHNSWNodeDetectsCorruption(t *testing.T) {
+	node := &HNSWNode{
+		ID:        "chunk-1",
+		Vector:    []float32{1, 2, 3, 4},
+		Level:     1,
+		Neighbors: [][]string{{"chunk-2", "chunk-3"}, {"chunk-2"}},
+	}
+	data := encodeHNSWNode(node)
+
+	for i := range data {
+		corrupt := append([]byte(nil), data...)
+		corrupt[i] ^= 0x40
+		if _, err := decodeHNSWNode(node.ID, corrupt); err == nil {
+			t.Errorf("decodeHNSWNode accepted a record with byte %d flipped", i)
+		}
+	}
+	if _, err := decodeHNSWNode(node.ID, data[:len(data)-1]); err == nil {
+		t.Error("decodeHNSWNode accepted a truncated record")
+	}
+}
+
+func TestHNSWIndexRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	idx, vectors := buildTestIndex(t, rng)
+	removeFraction(t, idx, vectors, rng, 0.1)
+
+	updated, removed, meta := idx.pendingChanges()
+	if len(removed) != 0 {
+		t.Errorf("pendingChanges reported %d removed nodes without compaction", len(removed))
+	}
+
+	nodes := make(map[string]*HNSWNode, len(updated))
+	for id, data := range updated {
+		node, err := decodeHNSWNode(id, data)
+		if err != nil {
+			t.Fatalf("decodeHNSWNode(%s) failed: %v", id, err)
+		}
+		nodes[id] = node
+	}
+
+	restored := NewHNSWIndex(nil)
+	if err := restored.restore(nodes, meta); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(restored.nodes, idx.nodes) {
+		t.Error("restored nodes differ from the original graph")
+	}
+	if restored.entryPoint != idx.entryPoint || restored.maxLevel != idx.maxLevel || restored.tombstones != idx.tombstones {
+		t.Errorf("restored entry %s, level %d, tombstones %d; want %s, %d, %d",
+			restored.entryPoint, restored.maxLevel, restored.tombstones, idx.entryPoint, idx.maxLevel, idx.tombstones)
+	}
+
+	for i := 0; i < testQueries; i++ {
+		query := randomVector(rng)
+		if got, want := restored.Search(query, testK, false), idx.Search(query, testK, false); !reflect.DeepEqual(got, want) {
+			t.Fatalf("restored index returned %v, original %v", got, want)
+		}
+	}
+}
+
+func TestRestoreRejectsDanglingLinks(t *testing.T) {
+	nodes := map[string]*HNSWNode{
+		"a": {ID: "a", Vector: []float32{1, 0}, Neighbors: [][]string{{"b"}}},
+	}
+	err := NewHNSWIndex(nil).restore(nodes, hnswMeta{Version: hnswFormatVersion, EntryPoint: "a", NodeCount: 1})
+	if !errors.Is(err, errIndexStale) {
+		t.Errorf("restore with a link to a missing node returned %v, want errIndexStale", err)
+	}
+}
+
+func TestCorruptIndexIsRebuiltOnOpen(t *testing.T) {
+	ctx := context.Background()
+	baseDir := t.TempDir()
+	const chatID = "chat-1"
+	const chunks = 50
+
+	// Store chunks, which persists their graph nodes
+	store, err := NewBadgerStore(baseDir, KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := store.OpenChat(ctx, chatID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(6))
+	for i := 0; i < chunks; i++ {
+		chunk := &DocumentChunk{
+			ID:         fmt.Sprintf("chunk-%d", i),
+			DocumentID: "doc-1",
+			ChatID:     chatID,
+			Content:    fmt.Sprintf("chunk %d", i),
+			Embedding:  randomVector(rng),
+		}
+		if err := session.StoreDocumentChunk(ctx, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session.Close()
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a byte inside one node record
+	corruptKey := []byte(hnswNodePrefix + "chunk-7")
+	opts := badger.DefaultOptions(filepath.Join(baseDir, chatID, "messages.db"))
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(corruptKey)
+		if err != nil {
+			return err
+		}
+		data, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		data[len(data)/2] ^= 0xff
+		return txn.Set(corruptKey, data)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	probe := &ChatSession{chatID: chatID, db: db, hnswIndex: NewHNSWIndex(nil)}
+	if err := probe.loadIndex(); !errors.Is(err, errIndexStale) {
+		t.Errorf("loadIndex of a corrupt graph returned %v, want errIndexStale", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening the chat must rebuild the graph from the stored vectors instead of loading it
+	store, err = NewBadgerStore(baseDir, KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	session, err = store.OpenChat(ctx, chatID)
+	if err != nil {
+		t.Fatalf("OpenChat of a chat with a corrupt graph failed: %v", err)
+	}
+	defer session.Close()
+
+	if got := session.hnswIndex.Size(); got != chunks {
+		t.Errorf("rebuilt index holds %d nodes, want %d", got, chunks)
+	}
+	err = session.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(corruptKey)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			_, err := decodeHNSWNode("chunk-7", val)
+			return err
+		})
+	})
+	if err != nil {
+		t.Errorf("corrupt record was not replaced by the rebuild: %v", err)
+	}
+	if err := session.loadIndex(); err != nil {
+		t.Errorf("loadIndex after the rebuild failed: %v", err)
+	}
+}
+
+func TestFailedStoreLeavesIndexConsistent(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewBadgerStore(t.TempDir(), KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	session, err := store.OpenChat(ctx, "chat-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	rng := rand.New(rand.NewSource(7))
+	storeChunk := func(id, content string, embedding []float32) error {
+		return session.StoreDocumentChunk(ctx, &DocumentChunk{
+			ID:         id,
+			DocumentID: "doc-1",
+			ChatID:     "chat-1",
+			Content:    content,
+			Embedding:  embedding,
+		})
+	}
+	for i := 0; i < 20; i++ {
+		if err := storeChunk(fmt.Sprintf("chunk-%d", i), fmt.Sprintf("chunk %d", i), randomVector(rng)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One posting key per distinct term pushes the transaction past badger's size limit
+	terms := make([]string, 200000)
+	for i := range terms {
+		terms[i] = fmt.Sprintf("t%d", i)
+	}
+	oversized := randomVector(rng)
+	err = storeChunk("oversized", strings.Join(terms, " "), oversized)
+	if !errors.Is(err, badger.ErrTxnTooBig) {
+		t.Fatalf("storing the oversized chunk returned %v, want ErrTxnTooBig", err)
+	}
+
+	for _, id := range session.hnswIndex.Search(oversized, 5, false) {
+		if id == "oversized" {
+			t.Fatal("search returned the chunk whose transaction failed")
+		}
+	}
+	if len(session.hnswIndex.dirty) == 0 {
+		t.Fatal("graph changes of the failed transaction were dropped from the dirty set")
+	}
+
+	// The next store writes the changes left over from the failed one
+	if err := storeChunk("chunk-20", "chunk 20", randomVector(rng)); err != nil {
+		t.Fatal(err)
+	}
+	if len(session.hnswIndex.dirty) != 0 {
+		t.Errorf("%d graph changes left unwritten after a successful store", len(session.hnswIndex.dirty))
+	}
+
+	loaded := &ChatSession{chatID: "chat-1", db: session.db, hnswIndex: NewHNSWIndex(nil)}
+	if err := loaded.loadIndex(); err != nil {
+		t.Fatalf("loadIndex after the failed store returned %v", err)
+	}
+	if !reflect.DeepEqual(loaded.hnswIndex.nodes, session.hnswIndex.nodes) {
+		t.Error("persisted graph differs from the graph in memory")
+	}
+}
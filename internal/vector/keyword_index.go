@@ -0,0 +1,352 @@
+package vector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"rag-terminal/internal/logging"
+)
+
+// The keyword index is a BM25 inverted index over document chunks and Q&A context messages,
+// stored in the chat database next to the records it indexes:
+//
+//	bm25:stats          -> JSON header (format version, analyzer ID, document count, total length)
+//	bm25:t:<term>:<id>  -> uvarint term frequency + uvarint document length
+//
+// Terms never contain ':' so a term's postings are exactly the keys under "bm25:t:<term>:".
+const (
+	keywordFormatVersion = 1
+	keywordStatsKey      = "bm25:stats"
+	keywordTermPrefix    = "bm25:t:"
+	keywordKeyPrefix     = "bm25:"
+
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// TermAnalyzer splits text into the terms stored in the keyword index
+type TermAnalyzer func(text string) []string
+
+// KeywordAnalyzer is the term analyzer a store indexes and queries keywords with. ID names the
+// analyzer and its version; an index built with a different ID is rebuilt when its chat is opened,
+// since postings of other terms could neither be matched nor removed.
+type KeywordAnalyzer struct {
+	ID    string
+	Terms TermAnalyzer
+}
+
+// defaultAnalyzer is used when a store is created without a term analyzer
+var defaultAnalyzer = KeywordAnalyzer{ID: "words-v1", Terms: defaultTerms}
+
+// FusionWeights controls how vector and keyword rankings are combined with reciprocal rank fusion
+type FusionWeights struct {
+	Vector  float64 // Weight of the HNSW ranking
+	Keyword float64 // Weight of the BM25 ranking
+	K       int     // RRF rank constant; higher values flatten the contribution of top ranks
+}
+
+// DefaultFusionWeights returns equal weights with the customary RRF constant
+func DefaultFusionWeights() FusionWeights {
+	return FusionWeights{Vector: 1.0, Keyword: 1.0, K: 60}
+}
+
+// keywordStats is the persisted header of the keyword index
+type keywordStats struct {
+	Version     int    `json:"version"`
+	Analyzer    string `json:"analyzer"`
+	DocCount    int    `json:"doc_count"`
+	TotalLength int    `json:"total_length"`
+}
+
+// SetFusionWeights configures how keyword results are fused with vector results. Weights only
+// affect queries, so they apply to open chats as well.
+func (s *BadgerStore) SetFusionWeights(weights FusionWeights) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fusion = weights
+}
+
+// fusionWeights returns the configured fusion weights
+func (s *BadgerStore) fusionWeights() FusionWeights {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.fusion
+}
+
+// defaultTerms is a plain lowercase word splitter for stores created without an analyzer
+func defaultTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// termFrequencies analyzes content into per-term counts and the document length in terms
+func (s *ChatSession) termFrequencies(content string) (map[string]int, int) {
+	terms := s.store.analyzer.Terms(content)
+	freqs := make(map[string]int, len(terms))
+	for _, term := range terms {
+		if term == "" || strings.ContainsRune(term, ':') {
+			continue
+		}
+		freqs[term]++
+	}
+	return freqs, len(terms)
+}
+
+func keywordPostingKey(term, id string) []byte {
+	return []byte(keywordTermPrefix + term + ":" + id)
+}
+
+// indexKeywords writes the postings of one record and accounts for it in stats
+func (s *ChatSession) indexKeywords(w indexWriter, stats *keywordStats, id, content string) error {
+	freqs, length := s.termFrequencies(content)
+	if len(freqs) == 0 {
+		return nil
+	}
+
+	for term, tf := range freqs {
+		val := binary.AppendUvarint(nil, uint64(tf))
+		val = binary.AppendUvarint(val, uint64(length))
+		if err := w.Set(keywordPostingKey(term, id), val); err != nil {
+			return err
+		}
+	}
+
+	stats.DocCount++
+	stats.TotalLength += length
+	return nil
+}
+
+// unindexKeywords removes the postings of one record. The content must be what was indexed,
+// since postings are found by re-analyzing it.
+func (s *ChatSession) unindexKeywords(w indexWriter, stats *keywordStats, id, content string) error {
+	freqs, length := s.termFrequencies(content)
+	if len(freqs) == 0 {
+		return nil
+	}
+
+	for term := range freqs {
+		if err := w.Delete(keywordPostingKey(term, id)); err != nil {
+			return err
+		}
+	}
+
+	stats.DocCount--
+	stats.TotalLength -= length
+	if stats.DocCount < 0 || stats.TotalLength < 0 {
+		stats.DocCount, stats.TotalLength = 0, 0
+	}
+	return nil
+}
+
+// loadKeywordStats reads the keyword index header; found is false if the chat has no index yet
+func (s *ChatSession) loadKeywordStats() (stats keywordStats, found bool, err error) {
+	err = s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(keywordStatsKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &stats)
+		})
+	})
+	if err != nil {
+		return keywordStats{}, false, fmt.Errorf("failed to read keyword index header: %w", err)
+	}
+	return stats, found, nil
+}
+
+func (s *ChatSession) writeKeywordStats(w indexWriter, stats keywordStats) error {
+	stats.Version = keywordFormatVersion
+	stats.Analyzer = s.store.analyzer.ID
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keyword index header: %w", err)
+	}
+	return w.Set([]byte(keywordStatsKey), data)
+}
+
+// ensureKeywordIndex builds the keyword index for chats created before it existed, and rebuilds
+// indexes of an older format or built with another analyzer
+func (s *ChatSession) ensureKeywordIndex(ctx context.Context) error {
+	stats, found, err := s.loadKeywordStats()
+	if err != nil {
+		return err
+	}
+	if found && stats.Version == keywordFormatVersion && stats.Analyzer == s.store.analyzer.ID {
+		return nil
+	}
+	if found {
+		logging.Info("Rebuilding keyword index for chat %s (analyzer %q, now %q)", s.chatID, stats.Analyzer, s.store.analyzer.ID)
+	}
+
+	if err := s.db.DropPrefix([]byte(keywordKeyPrefix)); err != nil {
+		return fmt.Errorf("failed to drop keyword index: %w", err)
+	}
+
+	stats = keywordStats{}
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	err = s.iterateWithPrefix([]byte("chunk:"), func(item *badger.Item) error {
+		return item.Value(func(val []byte) error {
+			var chunk DocumentChunk
+			if err := json.Unmarshal(val, &chunk); err != nil {
+				return err
+			}
+			return s.indexKeywords(wb, &stats, chunk.ID, chunk.Content)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index chunks: %w", err)
+	}
+
+	err = s.iterateWithPrefix([]byte("msg:"), func(item *badger.Item) error {
+		return item.Value(func(val []byte) error {
+			var msg Message
+			if err := json.Unmarshal(val, &msg); err != nil {
+				return err
+			}
+			if msg.Role != "context" {
+				return nil
+			}
+			return s.indexKeywords(wb, &stats, msg.ID, msg.Content)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index context messages: %w", err)
+	}
+
+	if err := s.writeKeywordStats(wb, stats); err != nil {
+		return err
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("failed to persist keyword index: %w", err)
+	}
+
+	logging.Info("Built keyword index for chat %s (%d records)", s.chatID, stats.DocCount)
+	return nil
+}
+
+// keywordSearch ranks indexed records against the query with BM25 and returns up to limit IDs
+func (s *ChatSession) keywordSearch(query string, limit int) ([]string, error) {
+	queryFreqs, _ := s.termFrequencies(query)
+	if len(queryFreqs) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	stats, found, err := s.loadKeywordStats()
+	if err != nil {
+		return nil, err
+	}
+	if !found || stats.DocCount == 0 {
+		return nil, nil
+	}
+	avgLength := float64(stats.TotalLength) / float64(stats.DocCount)
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	scores := make(map[string]float64)
+	err = s.db.View(func(txn *badger.Txn) error {
+		for term := range queryFreqs {
+			prefix := []byte(keywordTermPrefix + term + ":")
+
+			type posting struct {
+				id     string
+				tf     float64
+				length float64
+			}
+			var postings []posting
+
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = prefix
+			it := txn.NewIterator(opts)
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				item := it.Item()
+				id := string(item.Key()[len(prefix):])
+				err := item.Value(func(val []byte) error {
+					tf, n := binary.Uvarint(val)
+					if n <= 0 {
+						return fmt.Errorf("corrupt posting for %s", id)
+					}
+					length, m := binary.Uvarint(val[n:])
+					if m <= 0 {
+						return fmt.Errorf("corrupt posting for %s", id)
+					}
+					postings = append(postings, posting{id: id, tf: float64(tf), length: float64(length)})
+					return nil
+				})
+				if err != nil {
+					it.Close()
+					return err
+				}
+			}
+			it.Close()
+
+			df := float64(len(postings))
+			idf := math.Log(1 + (float64(stats.DocCount)-df+0.5)/(df+0.5))
+			for _, p := range postings {
+				norm := p.tf + bm25K1*(1-bm25B+bm25B*p.length/avgLength)
+				scores[p.id] += idf * p.tf * (bm25K1 + 1) / norm
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search keyword index: %w", err)
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+// fuseRankings combines ranked ID lists with weighted reciprocal rank fusion
+func fuseRankings(vectorIDs, keywordIDs []string, weights FusionWeights) []string {
+	k := float64(weights.K)
+	scores := make(map[string]float64, len(vectorIDs)+len(keywordIDs))
+	var order []string
+
+	add := func(ids []string, weight float64) {
+		for rank, id := range ids {
+			if _, ok := scores[id]; !ok {
+				order = append(order, id)
+			}
+			scores[id] += weight / (k + float64(rank+1))
+		}
+	}
+	add(vectorIDs, weights.Vector)
+	add(keywordIDs, weights.Keyword)
+
+	// Stable sort keeps vector order for ties, e.g. when keyword weight is zero
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	return order
+}
@@ -0,0 +1,211 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"rag-terminal/internal/logging"
+)
+
+// DefaultMaxIdleChats is how many chat databases stay open without holders, so switching back
+// to a recent chat doesn't reload its index
+const DefaultMaxIdleChats = 4
+
+// ChatSession provides the per-chat stores
+var (
+	_ MessageStore  = (*ChatSession)(nil)
+	_ DocumentStore = (*ChatSession)(nil)
+	_ ProfileStore  = (*ChatSession)(nil)
+)
+
+// ErrChatClosed is returned when a session is used after its database was closed
+var ErrChatClosed = errors.New("chat session is closed")
+
+// errStoreClosed is returned when a chat is opened after the store was closed
+var errStoreClosed = errors.New("chat store is closed")
+
+// ChatSession is a handle on one chat's database and indexes.
+// Sessions for different chats are independent, so they can be used concurrently.
+// Every session returned by OpenChat must be released with Close.
+type ChatSession struct {
+	store     *BadgerStore
+	chatID    string
+	db        *badger.DB
+	hnswIndex *HNSWIndex
+	mu        sync.RWMutex
+
+	// Guarded by store.mu
+	refs     int
+	lastUsed uint64
+}
+
+// ChatID returns the ID of the chat this session belongs to
+func (s *ChatSession) ChatID() string {
+	return s.chatID
+}
+
+// Close releases the handle. The database stays open for reuse until it is
+// evicted as the least recently used idle session.
+func (s *ChatSession) Close() error {
+	s.store.release(s)
+	return nil
+}
+
+// OpenChat returns a session for the chat, opening its database and loading its indexes
+// if no session is open yet. Concurrent callers for the same chat share one session.
+// Loading runs without the store lock, so opening a large chat doesn't hold up other chats.
+func (s *BadgerStore) OpenChat(ctx context.Context, chatID string) (*ChatSession, error) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return nil, errStoreClosed
+		}
+
+		if session, ok := s.sessions[chatID]; ok {
+			s.acquire(session)
+			s.mu.Unlock()
+			return session, nil
+		}
+
+		// Another caller is opening the chat; check again once it is done, since its
+		// session may have been closed again or its open may have failed for its own reasons
+		if pending, ok := s.opening[chatID]; ok {
+			s.mu.Unlock()
+			select {
+			case <-pending:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		done := make(chan struct{})
+		s.opening[chatID] = done
+		s.mu.Unlock()
+
+		session, err := s.openSession(ctx, chatID)
+
+		s.mu.Lock()
+		delete(s.opening, chatID)
+		close(done)
+		if err == nil && s.closed {
+			session.db.Close()
+			err = errStoreClosed
+		}
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+		s.sessions[chatID] = session
+		s.acquire(session)
+		s.mu.Unlock()
+		return session, nil
+	}
+}
+
+// acquire adds a reference to an open session. Must be called with s.mu held.
+func (s *BadgerStore) acquire(session *ChatSession) {
+	session.refs++
+	s.useSeq++
+	session.lastUsed = s.useSeq
+	s.evictIdle()
+}
+
+// openSession opens the database of a chat and restores its indexes
+func (s *BadgerStore) openSession(ctx context.Context, chatID string) (*ChatSession, error) {
+	chatDBPath := filepath.Join(s.baseDir, chatID, "messages.db")
+	if err := os.MkdirAll(filepath.Dir(chatDBPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create chat directory: %w", err)
+	}
+
+	opts := badger.DefaultOptions(chatDBPath)
+	opts.Logger = nil // Disable logging
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat database: %w", err)
+	}
+
+	session := &ChatSession{
+		store:     s,
+		chatID:    chatID,
+		db:        db,
+		hnswIndex: NewHNSWIndex(DefaultHNSWConfig()),
+	}
+
+	// Load the persisted HNSW graph, falling back to a full rebuild if it's missing or corrupt
+	if err := session.loadIndex(); err != nil {
+		logging.Info("Rebuilding HNSW index for chat %s: %v", chatID, err)
+		if err := session.rebuildAndPersistIndex(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to build HNSW index: %w", err)
+		}
+	}
+
+	if err := session.ensureKeywordIndex(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to build keyword index: %w", err)
+	}
+
+	return session, nil
+}
+
+// release drops one reference to a session and closes idle sessions over the limit
+func (s *BadgerStore) release(session *ChatSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session.refs > 0 {
+		session.refs--
+	}
+	s.evictIdle()
+}
+
+// evictIdle closes least recently used sessions without holders until at most maxIdle remain.
+// Must be called with s.mu held.
+func (s *BadgerStore) evictIdle() {
+	idle := 0
+	for _, session := range s.sessions {
+		if session.refs == 0 {
+			idle++
+		}
+	}
+
+	for idle > s.maxIdle {
+		var oldest *ChatSession
+		for _, session := range s.sessions {
+			if session.refs == 0 && (oldest == nil || session.lastUsed < oldest.lastUsed) {
+				oldest = session
+			}
+		}
+
+		if err := s.closeSession(oldest); err != nil {
+			logging.Error("Failed to close idle chat %s: %v", oldest.chatID, err)
+		}
+		idle--
+	}
+}
+
+// closeSession closes a session's database and forgets it. Must be called with s.mu held.
+func (s *BadgerStore) closeSession(session *ChatSession) error {
+	delete(s.sessions, session.chatID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.db == nil {
+		return nil
+	}
+
+	err := session.db.Close()
+	session.db = nil
+	session.hnswIndex.Clear()
+	return err
+}
@@ -0,0 +1,66 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestOpenChatSharesSessions(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewBadgerStore(t.TempDir(), KeywordAnalyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 8
+	sessions := make([][]*ChatSession, 2)
+	for i := range sessions {
+		sessions[i] = make([]*ChatSession, callers)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*callers)
+	for chat := range sessions {
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(chat, i int) {
+				defer wg.Done()
+				session, err := store.OpenChat(ctx, fmt.Sprintf("chat-%d", chat))
+				if err != nil {
+					errs <- err
+					return
+				}
+				sessions[chat][i] = session
+			}(chat, i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("OpenChat failed: %v", err)
+	}
+
+	for chat := range sessions {
+		for i, session := range sessions[chat] {
+			if session != sessions[chat][0] {
+				t.Errorf("caller %d of chat-%d got a different session", i, chat)
+			}
+		}
+		if refs := sessions[chat][0].refs; refs != callers {
+			t.Errorf("chat-%d session has %d references, want %d", chat, refs, callers)
+		}
+	}
+	if len(store.opening) != 0 {
+		t.Errorf("%d opens still pending", len(store.opening))
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.OpenChat(ctx, "chat-0"); !errors.Is(err, errStoreClosed) {
+		t.Errorf("OpenChat after Close returned %v, want errStoreClosed", err)
+	}
+}
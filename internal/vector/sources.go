@@ -0,0 +1,62 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Source is a document chunk that was put into a prompt under a citation label
+type Source struct {
+	ID        string `json:"id"` // Citation label the model was asked to use, e.g. "S1"
+	FilePath  string `json:"file_path"`
+	ChunkID   string `json:"chunk_id"`
+	StartLine int    `json:"start_line,omitempty"` // 1-based; zero when the lines couldn't be determined
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// Location formats the source as file:line range, or just the file if the lines are unknown
+func (s Source) Location() string {
+	switch {
+	case s.StartLine == 0:
+		return s.FilePath
+	case s.StartLine == s.EndLine:
+		return fmt.Sprintf("%s:%d", s.FilePath, s.StartLine)
+	default:
+		return fmt.Sprintf("%s:%d-%d", s.FilePath, s.StartLine, s.EndLine)
+	}
+}
+
+// citationPattern matches citations like [S1] or [S1, S3]
+var citationPattern = regexp.MustCompile(`\[(S\d+(?:\s*,\s*S\d+)*)\]`)
+
+var citationIDPattern = regexp.MustCompile(`S\d+`)
+
+// CitedSources returns the sources an answer cites, in source order. Models don't always cite,
+// so an answer without any known citation gets all of its sources.
+func CitedSources(answer string, sources []Source) []Source {
+	cited := make(map[string]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, id := range citationIDPattern.FindAllString(match[1], -1) {
+			cited[id] = true
+		}
+	}
+
+	var result []Source
+	for _, source := range sources {
+		if cited[source.ID] {
+			result = append(result, source)
+		}
+	}
+	if len(result) == 0 {
+		return sources
+	}
+	return result
+}
+
+// RecordSources attaches the sources an answer was given to an assistant message
+func (s *ChatSession) RecordSources(ctx context.Context, messageID string, sources []Source) error {
+	return s.updateMessage(messageID, func(msg *Message) {
+		msg.Sources = sources
+	})
+}
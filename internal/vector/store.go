@@ -7,12 +7,9 @@ import (
 
 // ChatStore manages chat metadata and lifecycle
 type ChatStore interface {
-	// OpenChat opens the database for a specific chat
-	// Must be called before any chat-specific operations
-	OpenChat(ctx context.Context, chatID string) error
-
-	// CloseChat gracefully closes the current chat's database
-	CloseChat(ctx context.Context) error
+	// OpenChat returns a session for chat-specific operations
+	// The session must be released with Close when done
+	OpenChat(ctx context.Context, chatID string) (*ChatSession, error)
 
 	// StoreChat stores chat metadata (creates new chat database)
 	StoreChat(ctx context.Context, chat *Chat) error
@@ -27,25 +24,37 @@ type ChatStore interface {
 	DeleteChat(ctx context.Context, chatID string) error
 }
 
-// MessageStore manages messages and message embeddings
+// MessageStore manages messages and message embeddings of a chat session
 type MessageStore interface {
-	// StoreMessage stores a message with its embedding in the currently open chat
+	// StoreMessage stores a message with its embedding in the session's chat
 	StoreMessage(ctx context.Context, messageID, role, content string, embedding []float32, timestamp time.Time) error
 
-	// SearchSimilar searches for similar messages by vector similarity in the currently open chat
+	// SearchSimilar searches for similar messages by vector similarity in the session's chat
 	SearchSimilar(ctx context.Context, queryEmbedding []float32, topK int) ([]Message, error)
 
-	// GetMessages retrieves all messages for the currently open chat in chronological order
+	// GetMessages retrieves all messages for the session's chat in chronological order
 	GetMessages(ctx context.Context) ([]Message, error)
+
+	// RecordUsage attaches token usage to an assistant message and adds it to the chat's totals
+	RecordUsage(ctx context.Context, messageID string, usage TokenUsage) error
+
+	// RecordSources attaches the citable sources of an answer to an assistant message
+	RecordSources(ctx context.Context, messageID string, sources []Source) error
 }
 
-// DocumentStore manages documents and document chunks
+// DocumentStore manages documents and document chunks of a chat session
 type DocumentStore interface {
-	// GetDocuments retrieves all documents for the currently open chat
+	// GetDocuments retrieves all documents for the session's chat
 	GetDocuments(ctx context.Context) ([]Document, error)
+
+	// DeleteDocument removes a document, its chunks and their index entries from the session's chat
+	DeleteDocument(ctx context.Context, docID string) error
+
+	// ReindexDocument replaces an existing document's metadata and chunks with freshly embedded ones
+	ReindexDocument(ctx context.Context, doc *Document, chunks []DocumentChunk) error
 }
 
-// ProfileStore manages user profile information and facts
+// ProfileStore manages user profile information and facts of a chat session
 type ProfileStore interface {
 	// StoreUserProfile stores the entire user profile for a chat
 	StoreUserProfile(ctx context.Context, profile *UserProfile) error
@@ -66,15 +75,21 @@ type ProfileStore interface {
 	GetFactHistory(ctx context.Context, chatID string, key string) ([]ProfileFact, error)
 }
 
-// VectorStore is a composite interface providing complete vector storage functionality.
-// It combines chat management, message storage, document management, and user profiles.
+// SummaryStore manages the rolling conversation summary of a chat session
+type SummaryStore interface {
+	// GetSummary retrieves the conversation summary, or nil if none was made yet
+	GetSummary(ctx context.Context) (*ConversationSummary, error)
+
+	// StoreSummary replaces the conversation summary
+	StoreSummary(ctx context.Context, summary *ConversationSummary) error
+}
+
+// VectorStore is the top-level storage interface.
+// Messages, documents, profiles and summaries are reached through the ChatSession returned by OpenChat.
 type VectorStore interface {
 	ChatStore
-	MessageStore
-	DocumentStore
-	ProfileStore
 
-	// Close closes any open database connection and cleans up resources
+	// Close closes all open chat databases and cleans up resources
 	Close() error
 }
 
@@ -85,6 +100,8 @@ type Message struct {
 	Content   string
 	Embedding []float32
 	Timestamp time.Time
+	Usage     *TokenUsage `json:",omitempty"` // Set on assistant messages once the response is complete
+	Sources   []Source    `json:",omitempty"` // Document chunks an assistant message was given to cite
 }
 
 type Chat struct {
@@ -96,10 +113,26 @@ type Chat struct {
 	// RAG parameters
 	Temperature   float64
 	TopK          int
-	UseReranking  bool // When true, uses LLM-based reranking
+	UseReranking  bool // When true, reranks retrieved context with the reranking model (LLM scoring as fallback)
 	MaxTokens     int
 	ContextWindow int // Total context window size (input + output tokens)
 	FileCount     int // Number of files embedded in this chat
+
+	// Retrieval query preparation; both cost extra LLM calls per message
+	UseQueryRewriting bool // When true, follow-ups are rewritten by the LLM into standalone search queries
+	UseMultiQuery     bool // When true, also retrieves for LLM-generated sub-queries and a hypothetical answer (HyDE)
+
+	// Models
+	LLMModel            string // Text generation model the chat was last used with
+	EmbedModel          string // Model that produced the stored vectors; empty for chats from before it was recorded
+	EmbeddingDimensions int    // Dimensions requested from EmbedModel
+
+	// Watch mode
+	WatchEnabled bool     // When true, WatchPaths are polled and changed files re-embedded
+	WatchPaths   []string // Absolute root paths loaded into this chat
+
+	// Token usage of all responses, kept in its own file so metadata updates can't overwrite it
+	Usage ChatUsage `json:"-"`
 }
 
 // Document represents a file that has been loaded into the chat context
@@ -117,6 +150,10 @@ type Document struct {
 	UploadedAt  time.Time         `json:"uploaded_at"`
 }
 
+// ChunkOffsetsBytes marks chunks whose StartPos and EndPos are byte offsets into the parsed file
+// content. Chunks indexed without it may hold line numbers there, as code chunks used to.
+const ChunkOffsetsBytes = 1
+
 // DocumentChunk represents a chunk of a document that has been embedded
 type DocumentChunk struct {
 	ID         string    `json:"id"`
@@ -127,7 +164,8 @@ type DocumentChunk struct {
 	Embedding  []float32 `json:"embedding"`
 	StartPos   int       `json:"start_pos"`
 	EndPos     int       `json:"end_pos"`
-	FilePath   string    `json:"file_path"` // Denormalized for easy retrieval
+	Offsets    int       `json:"offsets,omitempty"` // Format of StartPos and EndPos; ChunkOffsetsBytes or 0 if unknown
+	FilePath   string    `json:"file_path"`         // Denormalized for easy retrieval
 }
 
 // FactCategory defines hierarchical fact organization
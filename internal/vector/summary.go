@@ -0,0 +1,77 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// summaryKey holds the rolling summary of a chat's conversation
+const summaryKey = "summary"
+
+// ConversationSummary is a rolling summary of a chat's conversation. It is regenerated from the
+// previous summary and the turns that came after CoveredUntil, so it keeps the overall thread of
+// long chats that retrieval of single Q&A pairs loses.
+type ConversationSummary struct {
+	Content      string    `json:"content"`
+	Turns        int       `json:"turns"`         // Question/answer turns covered by Content
+	CoveredUntil time.Time `json:"covered_until"` // Timestamp of the last message covered by Content
+	Edited       bool      `json:"edited"`        // Content was changed by the user since it was generated
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// GetSummary returns the conversation summary of the chat, or nil if none was made yet
+func (s *ChatSession) GetSummary(ctx context.Context) (*ConversationSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.db == nil {
+		return nil, ErrChatClosed
+	}
+
+	var summary *ConversationSummary
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(summaryKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		summary = &ConversationSummary{}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, summary)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+// StoreSummary replaces the conversation summary of the chat
+func (s *ChatSession) StoreSummary(ctx context.Context, summary *ConversationSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return ErrChatClosed
+	}
+
+	summary.UpdatedAt = time.Now()
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversation summary: %w", err)
+	}
+
+	err = s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(summaryKey), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store conversation summary: %w", err)
+	}
+	return nil
+}
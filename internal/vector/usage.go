@@ -0,0 +1,145 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// TokenUsage records the tokens one assistant response consumed. The estimates are counted
+// with the same tokenizer as the token budget, so budgets can be checked against real counts.
+type TokenUsage struct {
+	PromptTokens              int  `json:"prompt_tokens"`
+	CompletionTokens          int  `json:"completion_tokens"`
+	EstimatedPromptTokens     int  `json:"estimated_prompt_tokens"`
+	EstimatedCompletionTokens int  `json:"estimated_completion_tokens"`
+	Reported                  bool `json:"reported"` // False when the backend reported nothing and the counts are the estimates
+}
+
+// ChatUsage sums the token usage of all responses in a chat
+type ChatUsage struct {
+	Responses                 int `json:"responses"`
+	ReportedResponses         int `json:"reported_responses"`
+	PromptTokens              int `json:"prompt_tokens"`
+	CompletionTokens          int `json:"completion_tokens"`
+	EstimatedPromptTokens     int `json:"estimated_prompt_tokens"`
+	EstimatedCompletionTokens int `json:"estimated_completion_tokens"`
+}
+
+// Add counts one more response
+func (u *ChatUsage) Add(usage TokenUsage) {
+	u.Responses++
+	if usage.Reported {
+		u.ReportedResponses++
+	}
+	u.PromptTokens += usage.PromptTokens
+	u.CompletionTokens += usage.CompletionTokens
+	u.EstimatedPromptTokens += usage.EstimatedPromptTokens
+	u.EstimatedCompletionTokens += usage.EstimatedCompletionTokens
+}
+
+// TotalTokens returns prompt plus completion tokens
+func (u ChatUsage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// EstimatedTotalTokens returns the estimated prompt plus completion tokens
+func (u ChatUsage) EstimatedTotalTokens() int {
+	return u.EstimatedPromptTokens + u.EstimatedCompletionTokens
+}
+
+// RecordUsage attaches the token usage to a stored assistant message and adds it to the chat's totals
+func (s *ChatSession) RecordUsage(ctx context.Context, messageID string, usage TokenUsage) error {
+	if err := s.setMessageUsage(messageID, usage); err != nil {
+		return err
+	}
+
+	// Totals live next to the chat metadata, so the chat list can show them without opening databases
+	return s.store.addChatUsage(s.chatID, usage)
+}
+
+func (s *ChatSession) setMessageUsage(messageID string, usage TokenUsage) error {
+	return s.updateMessage(messageID, func(msg *Message) {
+		msg.Usage = &usage
+	})
+}
+
+// updateMessage applies update to a stored message and writes it back
+func (s *ChatSession) updateMessage(messageID string, update func(msg *Message)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return ErrChatClosed
+	}
+
+	key := []byte(fmt.Sprintf("msg:%s", messageID))
+	return s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to get message %s: %w", messageID, err)
+		}
+
+		var msg Message
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &msg)
+		}); err != nil {
+			return fmt.Errorf("failed to unmarshal message: %w", err)
+		}
+
+		update(&msg)
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		return txn.Set(key, data)
+	})
+}
+
+// addChatUsage adds one response to the totals in usage.json
+func (s *BadgerStore) addChatUsage(chatID string, usage TokenUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total, err := s.readChatUsage(chatID)
+	if err != nil {
+		return err
+	}
+	total.Add(usage)
+
+	data, err := json.Marshal(total)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chat usage: %w", err)
+	}
+
+	usagePath := filepath.Join(s.baseDir, chatID, "usage.json")
+	if err := os.WriteFile(usagePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write chat usage: %w", err)
+	}
+
+	return nil
+}
+
+// readChatUsage loads the totals of a chat; chats without responses have none yet.
+// Must be called with s.mu held.
+func (s *BadgerStore) readChatUsage(chatID string) (ChatUsage, error) {
+	var total ChatUsage
+
+	data, err := os.ReadFile(filepath.Join(s.baseDir, chatID, "usage.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return total, nil
+		}
+		return total, fmt.Errorf("failed to read chat usage: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &total); err != nil {
+		return total, fmt.Errorf("failed to unmarshal chat usage: %w", err)
+	}
+
+	return total, nil
+}
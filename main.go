@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,8 +10,13 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"rag-terminal/internal/backend"
+	"rag-terminal/internal/cli"
+	"rag-terminal/internal/config"
+	"rag-terminal/internal/document"
+	"rag-terminal/internal/export"
+	"rag-terminal/internal/llm"
 	"rag-terminal/internal/logging"
-	"rag-terminal/internal/nexa"
 	"rag-terminal/internal/rag"
 	"rag-terminal/internal/ui"
 	"rag-terminal/internal/vector"
@@ -19,7 +25,8 @@ import (
 type appState int
 
 const (
-	stateModelSelect appState = iota
+	stateBackendStatus appState = iota
+	stateModelSelect
 	stateChatList
 	stateChatCreate
 	stateChatView
@@ -27,22 +34,28 @@ const (
 
 type model struct {
 	state       appState
-	nexaClient  *nexa.Client
+	backend     llm.Backend
 	vectorStore vector.VectorStore
 	pipeline    rag.Pipeline
 
 	// UI models
-	modelSelectModel ui.ModelSelectModel
-	chatListModel    ui.ChatListModel
-	chatCreateModel  ui.ChatCreateModel
-	chatViewModel    ui.ChatViewModel
+	backendStatusModel ui.BackendStatusModel
+	modelSelectModel   ui.ModelSelectModel
+	chatListModel      ui.ChatListModel
+	chatCreateModel    ui.ChatCreateModel
+	chatViewModel      ui.ChatViewModel
 
 	// Selected models
-	llmModel   string
-	embedModel string
+	llmModel    string
+	embedModel  string
+	rerankModel string // Optional, empty when no reranking model was selected
 
-	// Current chat
+	// Dimensions requested from the embedding model, recorded on chats next to the model
+	embedDimensions int
+
+	// Current chat, and the session keeping its database open while the chat view is shown
 	currentChat *vector.Chat
+	chatSession *vector.ChatSession
 
 	// Screen size
 	width  int
@@ -53,6 +66,11 @@ type model struct {
 }
 
 func main() {
+	// Subcommands run without the interactive UI
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	// Initialize logging
 	if err := logging.InitLogger(); err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
@@ -72,32 +90,42 @@ func main() {
 		log.Fatalf("Failed to create database directory: %v", err)
 	}
 
-	vectorStore, err := vector.NewBadgerStore(dbPath)
+	vectorStore, err := vector.NewBadgerStore(dbPath, document.KeywordAnalyzer())
 	if err != nil {
 		log.Fatalf("Failed to initialize vector store: %v", err)
 	}
 	defer vectorStore.Close()
 
-	// Create Nexa client
-	nexaClient := nexa.NewClient("")
+	// Create the client for the configured backend
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 
-	// Get available models
-	models, err := nexaClient.GetModels()
+	client, err := backend.New(cfg.Backend)
 	if err != nil {
-		log.Fatalf("Failed to get models: %v", err)
+		log.Fatalf("Failed to create backend: %v", err)
 	}
 
 	// Create initial model
 	initialModel := model{
-		state:       stateModelSelect,
-		nexaClient:  nexaClient,
-		vectorStore: vectorStore,
-		width:       80,
-		height:      24,
+		state:           stateModelSelect,
+		backend:         client,
+		vectorStore:     vectorStore,
+		embedDimensions: cfg.EmbeddingDimensions,
+		width:           80,
+		height:          24,
 	}
 
-	// Create model select UI
-	initialModel.modelSelectModel = ui.NewModelSelectModel(models, 80, 24)
+	// Check the backend before showing models; problems get a screen to fix them instead of a fatal error
+	diag := backend.CheckHealth(context.Background(), client, cfg.Backend)
+	if diag.Healthy() {
+		initialModel.modelSelectModel = ui.NewModelSelectModel(diag.Models, 80, 24)
+	} else {
+		logging.Error("Backend health check failed for %s at %s", diag.Provider, diag.BaseURL)
+		initialModel.state = stateBackendStatus
+		initialModel.backendStatusModel = ui.NewBackendStatusModel(cfg.Backend, diag, 80, 24)
+	}
 
 	// Run the program
 	p := tea.NewProgram(initialModel, tea.WithAltScreen())
@@ -108,6 +136,8 @@ func main() {
 
 func (m model) Init() tea.Cmd {
 	switch m.state {
+	case stateBackendStatus:
+		return m.backendStatusModel.Init()
 	case stateModelSelect:
 		return m.modelSelectModel.Init()
 	case stateChatList:
@@ -128,6 +158,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Update current screen
 		switch m.state {
+		case stateBackendStatus:
+			newModel, cmd := m.backendStatusModel.Update(msg)
+			m.backendStatusModel = newModel.(ui.BackendStatusModel)
+			return m, cmd
 		case stateModelSelect:
 			newModel, cmd := m.modelSelectModel.Update(msg)
 			m.modelSelectModel = newModel.(ui.ModelSelectModel)
@@ -151,11 +185,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+	case ui.BackendReady:
+		// Remember a base URL the user had to fix, so the next start works right away
+		cfg, err := config.Load()
+		if err == nil && cfg.Backend != msg.Config {
+			cfg.Backend = msg.Config
+			if err := config.Save(cfg); err != nil {
+				logging.Error("Failed to save backend config: %v", err)
+			}
+		}
+
+		// Transition to model select
+		m.backend = msg.Backend
+		m.state = stateModelSelect
+		m.modelSelectModel = ui.NewModelSelectModel(msg.Models, m.width, m.height)
+		return m, m.modelSelectModel.Init()
+
 	case ui.ModelSelectionComplete:
 		// Transition to chat list
 		m.llmModel = msg.LLMModel
 		m.embedModel = msg.EmbedModel
-		m.pipeline = rag.NewPipeline(m.nexaClient, m.vectorStore)
+		m.rerankModel = msg.RerankModel
+		m.pipeline = rag.NewPipeline(m.backend, m.vectorStore, m.rerankModel)
 
 		chats, err := m.vectorStore.ListChats(context.Background())
 		if err != nil {
@@ -164,7 +215,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.state = stateChatList
-		m.chatListModel = ui.NewChatListModel(chats, m.width, m.height)
+		m.chatListModel = ui.NewChatListModel(chats, m.embedModel, m.embedDimensions, m.width, m.height)
 		return m, m.chatListModel.Init()
 
 	case ui.CreateNewChat:
@@ -174,34 +225,49 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.chatCreateModel.Init()
 
 	case ui.ChatCreated:
-		// Save chat and transition to chat view
+		// Save chat with the models it is created with, then transition to chat view
+		msg.Chat.RecordModels(m.llmModel, m.embedModel, m.embedDimensions)
 		if err := m.vectorStore.StoreChat(context.Background(), msg.Chat); err != nil {
 			m.err = err
 			return m, tea.Quit
 		}
 
 		// Open the chat database
-		if err := m.vectorStore.OpenChat(context.Background(), msg.Chat.ID); err != nil {
+		session, err := m.vectorStore.OpenChat(context.Background(), msg.Chat.ID)
+		if err != nil {
 			m.err = err
 			return m, tea.Quit
 		}
 
+		m.chatSession = session
 		m.currentChat = msg.Chat
 		m.state = stateChatView
-		m.chatViewModel = ui.NewChatViewModel(msg.Chat, m.pipeline, m.vectorStore, m.llmModel, m.embedModel, m.width, m.height)
+		m.chatViewModel = ui.NewChatViewModel(msg.Chat, m.pipeline, m.vectorStore, m.llmModel, m.embedModel, m.rerankModel, m.width, m.height)
 		return m, m.chatViewModel.Init()
 
 	case ui.ChatSelected:
 		// Open the chat database
-		if err := m.vectorStore.OpenChat(context.Background(), msg.Chat.ID); err != nil {
+		session, err := m.vectorStore.OpenChat(context.Background(), msg.Chat.ID)
+		if err != nil {
 			m.err = err
 			return m, tea.Quit
 		}
 
+		chat := &msg.Chat
+		m.recordChatModels(chat)
+
 		// Transition to chat view
-		m.currentChat = &msg.Chat
+		m.chatSession = session
+		m.currentChat = chat
 		m.state = stateChatView
-		m.chatViewModel = ui.NewChatViewModel(&msg.Chat, m.pipeline, m.vectorStore, m.llmModel, m.embedModel, m.width, m.height)
+		m.chatViewModel = ui.NewChatViewModel(chat, m.pipeline, m.vectorStore, m.llmModel, m.embedModel, m.rerankModel, m.width, m.height)
+
+		// Vectors from another embedding model can't be searched with this one; the view offers to re-embed
+		var mismatch *vector.EmbeddingMismatch
+		if errors.As(chat.CheckEmbedding(m.embedModel, m.embedDimensions), &mismatch) {
+			logging.Info("Chat %s: %v", chat.ID, mismatch)
+			m.chatViewModel.SetEmbeddingMismatch(mismatch)
+		}
 		return m, m.chatViewModel.Init()
 
 	case ui.DeleteChat:
@@ -220,11 +286,48 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.chatListModel.RefreshChats(chats)
 		return m, nil
 
+	case ui.ExportChat:
+		// Write the export in the background; the chat list shows where it went
+		store := m.vectorStore
+		return m, func() tea.Msg {
+			dir, err := export.DefaultDir()
+			if err != nil {
+				return ui.ChatExported{Err: err}
+			}
+			path, err := export.ToFile(context.Background(), store, msg.ChatID, msg.Format, dir)
+			return ui.ChatExported{Path: path, Err: err}
+		}
+
+	case ui.ReembedChat:
+		// Runs independently of the screens, so the user can keep working meanwhile
+		return m, ui.StartChatReembed(m.pipeline.GetReembedder(), msg, m.embedModel, m.embedDimensions)
+
+	case ui.ChatReembedProgress:
+		cmd := ui.WaitForChatReembed(msg)
+		if m.state == stateChatList {
+			newModel, _ := m.chatListModel.Update(msg)
+			m.chatListModel = newModel.(ui.ChatListModel)
+		}
+		return m, cmd
+
+	case ui.ChatReembedded:
+		if msg.Err != nil {
+			logging.Error("Re-embedding %s failed: %v", msg.Name, msg.Err)
+		}
+		if m.state == stateChatList {
+			if chats, err := m.vectorStore.ListChats(context.Background()); err == nil {
+				m.chatListModel.RefreshChats(chats)
+			}
+			newModel, _ := m.chatListModel.Update(msg)
+			m.chatListModel = newModel.(ui.ChatListModel)
+		}
+		return m, nil
+
 	case ui.BackToChatList:
-		// Close current chat database
-		if err := m.vectorStore.CloseChat(context.Background()); err != nil {
-			m.err = err
-			return m, tea.Quit
+		// Release the current chat; its database stays cached for a quick return
+		if m.chatSession != nil {
+			m.chatSession.Close()
+			m.chatSession = nil
 		}
 
 		// Transition back to chat list
@@ -235,12 +338,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.state = stateChatList
-		m.chatListModel = ui.NewChatListModel(chats, m.width, m.height)
+		m.chatListModel = ui.NewChatListModel(chats, m.embedModel, m.embedDimensions, m.width, m.height)
 		return m, m.chatListModel.Init()
 	}
 
 	// Delegate to current screen
 	switch m.state {
+	case stateBackendStatus:
+		newModel, cmd := m.backendStatusModel.Update(msg)
+		m.backendStatusModel = newModel.(ui.BackendStatusModel)
+		return m, cmd
+
 	case stateModelSelect:
 		newModel, cmd := m.modelSelectModel.Update(msg)
 		m.modelSelectModel = newModel.(ui.ModelSelectModel)
@@ -265,12 +373,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// recordChatModels notes the selected models on the chat and saves it if anything changed
+func (m model) recordChatModels(chat *vector.Chat) {
+	if !chat.RecordModels(m.llmModel, m.embedModel, m.embedDimensions) {
+		return
+	}
+
+	if badgerStore, ok := m.vectorStore.(*vector.BadgerStore); ok {
+		if err := badgerStore.UpdateChat(context.Background(), chat); err != nil {
+			logging.Error("Failed to record models of chat %s: %v", chat.ID, err)
+		}
+	}
+}
+
 func (m model) View() string {
 	if m.err != nil {
 		return fmt.Sprintf("Error: %v\n\nPress Ctrl+C to quit", m.err)
 	}
 
 	switch m.state {
+	case stateBackendStatus:
+		return m.backendStatusModel.View()
 	case stateModelSelect:
 		return m.modelSelectModel.View()
 	case stateChatList: